@@ -1,13 +1,45 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
 	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/abiargs"
+	"github.com/fuckEthereum/src/artifact"
+	"github.com/fuckEthereum/src/blockref"
+	"github.com/fuckEthereum/src/counterevents"
+	"github.com/fuckEthereum/src/create2"
+	"github.com/fuckEthereum/src/ens"
+	"github.com/fuckEthereum/src/explorer"
+	"github.com/fuckEthereum/src/follower"
+	"github.com/fuckEthereum/src/indexer"
+	"github.com/fuckEthereum/src/multicall"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/proxy"
+	"github.com/fuckEthereum/src/registry"
+	"github.com/fuckEthereum/src/solcgen"
 	"github.com/fuckEthereum/src/task1"
 	"github.com/fuckEthereum/src/task2"
+	"github.com/fuckEthereum/src/txhistory"
+	"github.com/fuckEthereum/src/units"
+	"github.com/fuckEthereum/src/verify"
+	"github.com/fuckEthereum/src/watcher"
 )
 
 func main() {
@@ -15,11 +47,47 @@ func main() {
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "task1":
-			runTask1()
+			runTask1(hasFlag(os.Args[2:], "--dry-run"))
 		case "task2":
-			runTask2()
+			runTask2(hasFlag(os.Args[2:], "--dry-run"), hasFlag(os.Args[2:], "--force"))
 		case "setup":
 			runSetup()
+		case "resolve":
+			runResolve(os.Args[2:])
+		case "history":
+			runHistory(os.Args[2:])
+		case "index":
+			runIndex(os.Args[2:])
+		case "watch":
+			runWatch(os.Args[2:])
+		case "follow":
+			runFollow(os.Args[2:])
+		case "events":
+			runEvents(os.Args[2:])
+		case "counter-history":
+			runCounterHistory(os.Args[2:])
+		case "balance":
+			runBalance(os.Args[2:])
+		case "count":
+			runCount(os.Args[2:])
+		case "counts":
+			runCounts(os.Args[2:])
+		case "gen":
+			runGen(os.Args[2:])
+		case "deploy":
+			runDeploy(os.Args[2:])
+		case "deployments":
+			runDeployments(os.Args[2:])
+		case "proxy":
+			runProxy(os.Args[2:])
+		case "contract":
+			runContract(os.Args[2:])
+		case "verify":
+			runVerify(os.Args[2:])
+		case "block":
+			runBlock(os.Args[2:])
+		case "blocks":
+			runBlocks(os.Args[2:])
 		default:
 			printUsage()
 		}
@@ -28,11 +96,11 @@ func main() {
 	}
 }
 
-func runTask1() {
+func runTask1(dryRun bool) {
 	fmt.Println("🚀 开始执行 Task 1: ETH 转账测试...")
 
 	// Execute ETH transfer
-	err := task1.TransferETH()
+	err := task1.TransferETH(dryRun)
 	if err != nil {
 		log.Printf("转账失败: %v", err)
 		return
@@ -41,11 +109,11 @@ func runTask1() {
 	fmt.Println("✅ Task 1 转账测试完成！")
 }
 
-func runTask2() {
+func runTask2(dryRun, force bool) {
 	fmt.Println("🚀 开始执行 Task 2: Abigen 智能合约交互...")
 
 	// Execute contract interaction demo
-	err := task2.RunTask2()
+	err := task2.RunTask2(dryRun, force)
 	if err != nil {
 		log.Printf("智能合约交互失败: %v", err)
 		return
@@ -71,6 +139,1892 @@ func runSetup() {
 	fmt.Println("✅ Abigen 设置完成！")
 }
 
+func runResolve(args []string) {
+	if len(args) != 1 {
+		fmt.Println("使用方法: go run main.go resolve <name.eth|0x地址>")
+		return
+	}
+
+	profile, err := network.FromEnv()
+	if err != nil {
+		log.Printf("网络配置错误: %v", err)
+		return
+	}
+
+	client, err := ethclient.Dial(profile.RPCURL)
+	if err != nil {
+		log.Printf("连接以太坊网络失败: %v", err)
+		return
+	}
+	defer client.Close()
+
+	resolver := ens.NewResolver(client, ens.DefaultRegistry)
+	input := args[0]
+
+	if common.IsHexAddress(input) {
+		name, err := resolver.ReverseResolve(context.Background(), common.HexToAddress(input))
+		if err != nil {
+			log.Printf("反向解析失败: %v", err)
+			return
+		}
+		fmt.Printf("🔎 %s → %s\n", common.HexToAddress(input).Hex(), name)
+		return
+	}
+
+	address, err := resolver.ResolveAddress(context.Background(), input)
+	if err != nil {
+		log.Printf("解析失败: %v", err)
+		return
+	}
+	fmt.Printf("🔎 %s → %s\n", input, address.Hex())
+}
+
+func runHistory(args []string) {
+	if len(args) == 0 {
+		printHistoryUsage()
+		return
+	}
+
+	switch args[0] {
+	case "list":
+		runHistoryList(args[1:])
+	case "export":
+		runHistoryExport(args[1:])
+	case "reconcile":
+		runHistoryReconcile(args[1:])
+	default:
+		printHistoryUsage()
+	}
+}
+
+// historyFilterFlags registers the shared query flags on a flag set
+func historyFilterFlags(fs *flag.FlagSet) func() (txhistory.Filter, error) {
+	account := fs.String("account", "", "只显示与该地址相关的交易")
+	status := fs.String("status", "", "按状态过滤 (PENDING/SUCCESS/FAILED/DROPPED)")
+	since := fs.String("since", "", "起始日期 (YYYY-MM-DD，含)")
+	until := fs.String("until", "", "结束日期 (YYYY-MM-DD，不含)")
+
+	return func() (txhistory.Filter, error) {
+		filter := txhistory.Filter{Account: *account, Status: *status}
+		if *since != "" {
+			t, err := time.Parse("2006-01-02", *since)
+			if err != nil {
+				return filter, fmt.Errorf("invalid --since: %v", err)
+			}
+			filter.Since = t
+		}
+		if *until != "" {
+			t, err := time.Parse("2006-01-02", *until)
+			if err != nil {
+				return filter, fmt.Errorf("invalid --until: %v", err)
+			}
+			filter.Until = t
+		}
+		return filter, nil
+	}
+}
+
+func queryHistory(filter txhistory.Filter) ([]*txhistory.Record, error) {
+	store, err := txhistory.Open(txhistory.PathFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	return store.Query(filter)
+}
+
+func runHistoryList(args []string) {
+	fs := flag.NewFlagSet("history list", flag.ExitOnError)
+	filterFromFlags := historyFilterFlags(fs)
+	fs.Parse(args)
+
+	filter, err := filterFromFlags()
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+
+	records, err := queryHistory(filter)
+	if err != nil {
+		log.Printf("查询交易历史失败: %v", err)
+		return
+	}
+
+	fmt.Printf("📜 共 %d 笔交易\n", len(records))
+	for _, rec := range records {
+		value, _ := units.Parse(rec.Value + " wei")
+		to := rec.To
+		if to == "" {
+			to = "(合约创建)"
+		}
+		fmt.Printf("%s  %-8s  %s  %s → %s  %s  nonce=%d  %s\n",
+			rec.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			rec.Status, rec.Hash, rec.From, to, value.Format(units.Ether, -1), rec.Nonce, rec.Label)
+	}
+}
+
+func runHistoryExport(args []string) {
+	fs := flag.NewFlagSet("history export", flag.ExitOnError)
+	output := fs.String("o", "txhistory.csv", "CSV 输出文件")
+	filterFromFlags := historyFilterFlags(fs)
+	fs.Parse(args)
+
+	filter, err := filterFromFlags()
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+
+	records, err := queryHistory(filter)
+	if err != nil {
+		log.Printf("查询交易历史失败: %v", err)
+		return
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		log.Printf("创建文件失败: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if err := txhistory.WriteCSV(file, records); err != nil {
+		log.Printf("导出 CSV 失败: %v", err)
+		return
+	}
+	fmt.Printf("✅ 已导出 %d 笔交易到 %s\n", len(records), *output)
+}
+
+func runHistoryReconcile(args []string) {
+	fs := flag.NewFlagSet("history reconcile", flag.ExitOnError)
+	interval := fs.Duration("interval", 30*time.Second, "检查间隔")
+	dropAfter := fs.Duration("drop-after", time.Hour, "节点不再知道的交易超过该时长后标记为 DROPPED")
+	once := fs.Bool("once", false, "只检查一次")
+	fs.Parse(args)
+
+	profile, err := network.FromEnv()
+	if err != nil {
+		log.Printf("网络配置错误: %v", err)
+		return
+	}
+
+	reconciler := txhistory.NewReconciler(txhistory.PathFromEnv(), task1.HistoryConnector(profile.RPCURL), *dropAfter)
+
+	if *once {
+		changed, err := reconciler.Once(context.Background())
+		if err != nil {
+			log.Printf("同步失败: %v", err)
+			return
+		}
+		fmt.Printf("✅ 同步完成，%d 笔交易状态更新\n", changed)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("🔄 每 %s 同步一次待确认交易 (Ctrl+C 退出)...\n", *interval)
+	reconciler.Run(ctx, *interval)
+}
+
+func printHistoryUsage() {
+	fmt.Println("使用方法:")
+	fmt.Println("  go run main.go history list      [--account 地址] [--status 状态] [--since 日期] [--until 日期]")
+	fmt.Println("  go run main.go history export    [-o 文件.csv] [同上过滤参数]")
+	fmt.Println("  go run main.go history reconcile [--interval 30s] [--drop-after 1h] [--once]")
+}
+
+func runIndex(args []string) {
+	if len(args) == 0 {
+		printIndexUsage()
+		return
+	}
+
+	switch args[0] {
+	case "scan":
+		runIndexScan(args[1:])
+	case "follow":
+		runIndexFollow(args[1:])
+	case "show":
+		runIndexShow(args[1:])
+	default:
+		printIndexUsage()
+	}
+}
+
+// indexConfigFlags registers the shared indexer flags on a flag set
+func indexConfigFlags(fs *flag.FlagSet) func(client *ethclient.Client) (indexer.Config, error) {
+	watch := fs.String("watch", "", "逗号分隔的地址或 ENS 名称")
+	counter := fs.String("counter", os.Getenv("COUNTER_ADDRESS"), "记录该 Counter 合约的事件")
+
+	return func(client *ethclient.Client) (indexer.Config, error) {
+		var config indexer.Config
+		for _, input := range strings.Split(*watch, ",") {
+			input = strings.TrimSpace(input)
+			if input == "" {
+				continue
+			}
+			address, err := task1.ResolveAddress(client, input)
+			if err != nil {
+				return config, err
+			}
+			config.Watch = append(config.Watch, address)
+		}
+		if *counter != "" {
+			address, err := task1.ResolveAddress(client, *counter)
+			if err != nil {
+				return config, err
+			}
+			config.Counters = append(config.Counters, address)
+		}
+		if len(config.Watch) == 0 && len(config.Counters) == 0 {
+			return config, fmt.Errorf("nothing to index: use --watch and/or --counter")
+		}
+		return config, nil
+	}
+}
+
+// openIndexer connects to the configured network and opens the index store
+func openIndexer(config indexer.Config, client *ethclient.Client) (*indexer.Indexer, *indexer.Store, error) {
+	store, err := indexer.Open(indexer.PathFromEnv())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fetch := func(number *uint64) (*types.Block, error) {
+		return task1.QueryBlock(client, number)
+	}
+	ix, err := indexer.New(client, fetch, store, config)
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	return ix, store, nil
+}
+
+func dialProfile() (*ethclient.Client, error) {
+	profile, err := network.FromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("网络配置错误: %v", err)
+	}
+	client, err := ethclient.Dial(profile.RPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("连接以太坊网络失败: %v", err)
+	}
+	return client, nil
+}
+
+func runIndexScan(args []string) {
+	fs := flag.NewFlagSet("index scan", flag.ExitOnError)
+	from := fs.Uint64("from", 0, "起始区块 (含)")
+	to := fs.Uint64("to", 0, "结束区块 (含，默认最新区块)")
+	configFromFlags := indexConfigFlags(fs)
+	fs.Parse(args)
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	config, err := configFromFlags(client)
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+
+	ix, store, err := openIndexer(config, client)
+	if err != nil {
+		log.Printf("打开索引失败: %v", err)
+		return
+	}
+	defer store.Close()
+
+	end := *to
+	if end == 0 {
+		head, err := client.BlockNumber(context.Background())
+		if err != nil {
+			log.Printf("获取最新区块失败: %v", err)
+			return
+		}
+		end = head
+	}
+
+	fmt.Printf("🔍 扫描区块 %d - %d...\n", *from, end)
+	found, err := ix.Range(context.Background(), *from, end)
+	if err != nil {
+		log.Printf("扫描失败: %v", err)
+		return
+	}
+	fmt.Printf("✅ 扫描完成，记录 %d 条活动\n", found)
+}
+
+func runIndexFollow(args []string) {
+	fs := flag.NewFlagSet("index follow", flag.ExitOnError)
+	start := fs.Uint64("start", 0, "没有检查点时从该区块开始 (默认当前最新区块)")
+	confirmations := fs.Uint64("confirmations", 0, "落后最新区块的确认数")
+	interval := fs.Duration("interval", 12*time.Second, "HTTP 节点的轮询间隔")
+	configFromFlags := indexConfigFlags(fs)
+	fs.Parse(args)
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	config, err := configFromFlags(client)
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+	config.StartBlock = *start
+	config.Confirmations = *confirmations
+	if config.StartBlock == 0 {
+		head, err := client.BlockNumber(context.Background())
+		if err != nil {
+			log.Printf("获取最新区块失败: %v", err)
+			return
+		}
+		config.StartBlock = head
+	}
+
+	ix, store, err := openIndexer(config, client)
+	if err != nil {
+		log.Printf("打开索引失败: %v", err)
+		return
+	}
+	defer store.Close()
+
+	if cp, ok, err := store.Checkpoint(); err == nil && ok {
+		fmt.Printf("📍 从检查点 #%d 继续\n", cp.Number)
+	} else {
+		fmt.Printf("📍 从区块 #%d 开始\n", config.StartBlock)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Println("🔄 持续索引中 (Ctrl+C 退出)...")
+	err = followHeads(ctx, *interval, func(event follower.Event) {
+		if event.Type != follower.EventBlock {
+			return
+		}
+		if _, err := ix.Sync(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("⚠️  索引同步失败: %v\n", err)
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("索引中断: %v", err)
+	}
+}
+
+func runIndexShow(args []string) {
+	fs := flag.NewFlagSet("index show", flag.ExitOnError)
+	from := fs.Uint64("from", 0, "起始区块 (含)")
+	to := fs.Uint64("to", 0, "结束区块 (含，0 表示不限)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || !common.IsHexAddress(fs.Arg(0)) {
+		printIndexUsage()
+		return
+	}
+	address := common.HexToAddress(fs.Arg(0))
+
+	store, err := indexer.Open(indexer.PathFromEnv())
+	if err != nil {
+		log.Printf("打开索引失败: %v", err)
+		return
+	}
+	defer store.Close()
+
+	activities, err := store.ByAddress(address, *from, *to)
+	if err != nil {
+		log.Printf("查询失败: %v", err)
+		return
+	}
+
+	fmt.Printf("📜 %s 共 %d 条活动\n", address.Hex(), len(activities))
+	for _, a := range activities {
+		switch a.Kind {
+		case indexer.KindEvent:
+			fmt.Printf("#%d  %s  %s %v  tx=%s\n", a.BlockNumber, a.Time.Local().Format("2006-01-02 15:04:05"), a.Event, a.Fields, a.TxHash)
+		case indexer.KindCreation:
+			fmt.Printf("#%d  %s  创建合约 %s  tx=%s\n", a.BlockNumber, a.Time.Local().Format("2006-01-02 15:04:05"), a.Contract, a.TxHash)
+		default:
+			value, _ := units.Parse(a.Value + " wei")
+			fmt.Printf("#%d  %s  %s → %s  %s  status=%d  tx=%s\n", a.BlockNumber, a.Time.Local().Format("2006-01-02 15:04:05"), a.From, a.To, value.Format(units.Ether, -1), a.Status, a.TxHash)
+		}
+	}
+}
+
+func printIndexUsage() {
+	fmt.Println("使用方法:")
+	fmt.Println("  go run main.go index scan   --from N [--to M] --watch 地址,... [--counter 地址]")
+	fmt.Println("  go run main.go index follow [--start N] [--confirmations 2] [--interval 12s] --watch 地址,... [--counter 地址]")
+	fmt.Println("  go run main.go index show   [--from N] [--to M] <地址>")
+}
+
+func runWatch(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件 (默认 WATCH_FILE 或 watch.json)")
+	fs.Parse(args)
+
+	var config *watcher.Config
+	var err error
+	if *configPath != "" {
+		config, err = watcher.Load(*configPath)
+	} else {
+		config, err = watcher.LoadFromEnv()
+	}
+	if err != nil {
+		log.Printf("加载监控配置失败: %v", err)
+		return
+	}
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	w, err := watcher.New(client, config)
+	if err != nil {
+		log.Printf("创建余额监控失败: %v", err)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	interval, _ := config.Interval()
+	fmt.Printf("👀 监控 %d 个地址的余额 (Ctrl+C 退出)...\n", len(config.Targets))
+	err = followHeads(ctx, interval, func(event follower.Event) {
+		if event.Type != follower.EventBlock {
+			return
+		}
+		if err := w.OnHead(ctx, event.Header.Number.Uint64()); err != nil && ctx.Err() == nil {
+			fmt.Printf("⚠️  余额检查失败: %v\n", err)
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("余额监控中断: %v", err)
+	}
+}
+
+// followHeads runs a block follower against the configured network until ctx is cancelled
+func followHeads(ctx context.Context, interval time.Duration, handle func(follower.Event)) error {
+	profile, err := network.FromEnv()
+	if err != nil {
+		return fmt.Errorf("网络配置错误: %v", err)
+	}
+
+	events := make(chan follower.Event, 16)
+	f := follower.New(follower.DialURL(profile.RPCURL), follower.Config{PollInterval: interval})
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- f.Run(ctx, events)
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			handle(event)
+		case err := <-errc:
+			return err
+		}
+	}
+}
+
+func runFollow(args []string) {
+	fs := flag.NewFlagSet("follow", flag.ExitOnError)
+	interval := fs.Duration("interval", 12*time.Second, "HTTP 节点的轮询间隔")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Println("🔄 跟踪新区块 (Ctrl+C 退出)...")
+	err := followHeads(ctx, *interval, func(event follower.Event) {
+		switch event.Type {
+		case follower.EventReorg:
+			ancestor := "未知"
+			if event.Ancestor != nil {
+				ancestor = fmt.Sprintf("#%d", event.Ancestor.Number.Uint64())
+			}
+			fmt.Printf("🔀 链重组: 回滚 %d 个区块，共同祖先 %s\n", len(event.Removed), ancestor)
+			for _, header := range event.Removed {
+				fmt.Printf("   ✖ #%d %s\n", header.Number.Uint64(), header.Hash().Hex())
+			}
+		case follower.EventBlock:
+			header := event.Header
+			fmt.Printf("📦 #%d %s (%s)\n", header.Number.Uint64(), header.Hash().Hex(),
+				time.Unix(int64(header.Time), 0).Format("15:04:05"))
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("区块跟踪中断: %v", err)
+	}
+}
+
+func runEvents(args []string) {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	counter := fs.String("counter", os.Getenv("COUNTER_ADDRESS"), "Counter 合约地址")
+	start := fs.Uint64("from", 0, "没有检查点时的起始区块 (通常为合约部署区块)")
+	batch := fs.Uint64("batch", 2000, "补齐历史事件时每次查询的区块数")
+	interval := fs.Duration("interval", 12*time.Second, "HTTP 节点的轮询间隔")
+	fs.Parse(args)
+
+	if !common.IsHexAddress(*counter) {
+		fmt.Println("使用方法: go run main.go events --counter 0x合约地址 [--from 部署区块] [--batch 2000]")
+		return
+	}
+	address := common.HexToAddress(*counter)
+
+	profile, err := network.FromEnv()
+	if err != nil {
+		log.Printf("网络配置错误: %v", err)
+		return
+	}
+
+	stream, err := counterevents.New(counterevents.DialURL(profile.RPCURL), counterevents.Config{
+		Address:      address,
+		StartBlock:   *start,
+		BatchSize:    *batch,
+		PollInterval: *interval,
+	})
+	if err != nil {
+		log.Printf("创建事件流失败: %v", err)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("📡 从区块 #%d 开始接收 Counter %s 的事件 (Ctrl+C 退出)...\n", stream.Checkpoint().Block, address.Hex())
+	err = stream.Run(ctx, func(ev counterevents.Event) error {
+		if ev.Removed {
+			fmt.Printf("↩️  #%d %s(%s) 因链重组被移除 tx=%s\n", ev.Raw.BlockNumber, ev.Name, ev.NewCount, ev.Raw.TxHash.Hex())
+			return nil
+		}
+		fmt.Printf("📣 #%d %s(%s) tx=%s\n", ev.Raw.BlockNumber, ev.Name, ev.NewCount, ev.Raw.TxHash.Hex())
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("事件流中断: %v", err)
+	}
+	fmt.Printf("📍 已处理到区块 #%d\n", stream.Checkpoint().LastBlock())
+}
+
+func runCounterHistory(args []string) {
+	fs := flag.NewFlagSet("counter-history", flag.ExitOnError)
+	counter := fs.String("counter", os.Getenv("COUNTER_ADDRESS"), "Counter 合约地址")
+	from := fs.Uint64("from", 0, "起始区块 (通常为合约部署区块)")
+	to := fs.Uint64("to", 0, "结束区块 (默认最新区块)")
+	batch := fs.Uint64("batch", 2000, "每次查询日志的区块数")
+	format := fs.String("format", "table", "输出格式: table, csv, json")
+	output := fs.String("o", "", "输出文件 (默认标准输出)")
+	fs.Parse(args)
+
+	if !common.IsHexAddress(*counter) {
+		fmt.Println("使用方法: go run main.go counter-history --counter 0x合约地址 [--from N] [--to M] [--format table|csv|json] [-o 文件]")
+		return
+	}
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	end := *to
+	if end == 0 {
+		if end, err = client.BlockNumber(context.Background()); err != nil {
+			log.Printf("获取最新区块失败: %v", err)
+			return
+		}
+	}
+
+	projection, err := counterevents.Project(context.Background(), client, common.HexToAddress(*counter), *from, end, *batch)
+	if err != nil {
+		log.Printf("重建计数器历史失败: %v", err)
+		return
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Printf("创建文件失败: %v", err)
+			return
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch *format {
+	case "csv":
+		err = projection.WriteCSV(out)
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(projection)
+	default:
+		fmt.Fprintf(out, "📈 Counter %s 区块 %d - %d，初始值 %s，共 %d 个事件\n",
+			projection.Address.Hex(), projection.From, projection.To, projection.Initial, len(projection.Points))
+		for _, p := range projection.Points {
+			mark := "✅"
+			if p.Divergence != "" {
+				mark = "❌ " + p.Divergence
+			} else if p.OnChain == nil {
+				mark = ""
+			}
+			fmt.Fprintf(out, "#%d  %s  %-16s → %s  by %s  tx=%s  %s\n",
+				p.Block, p.Time.Local().Format("2006-01-02 15:04:05"), p.Event, p.Value, p.Sender.Hex(), p.TxHash.Hex(), mark)
+		}
+	}
+	if err != nil {
+		log.Printf("输出失败: %v", err)
+		return
+	}
+
+	// Keep machine-readable output on stdout clean
+	summary := os.Stdout
+	if *format != "table" && *output == "" {
+		summary = os.Stderr
+	}
+	if projection.Divergences > 0 {
+		fmt.Fprintf(summary, "⚠️  发现 %d 处与链上状态不一致\n", projection.Divergences)
+	} else {
+		fmt.Fprintln(summary, "✅ 事件重建结果与链上 getCount 一致")
+	}
+}
+
+func runBalance(args []string) {
+	fs := flag.NewFlagSet("balance", flag.ExitOnError)
+	block := fs.String("block", "latest", "区块号、区块哈希或 latest/safe/finalized/pending/earliest")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println("使用方法: go run main.go balance [--block 区块] <地址|name.eth>...")
+		return
+	}
+	at, err := blockref.Parse(*block)
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+
+	profile, err := network.FromEnv()
+	if err != nil {
+		log.Printf("网络配置错误: %v", err)
+		return
+	}
+
+	if fs.NArg() == 1 {
+		balance, err := task1.GetAccountBalanceAt(fs.Arg(0), profile.RPCURL, at)
+		if err != nil {
+			log.Printf("查询余额失败: %v", err)
+			return
+		}
+		fmt.Printf("💰 %s @ %s: %s\n", fs.Arg(0), at, units.FormatWei(balance, units.Ether, -1))
+		return
+	}
+
+	// Several addresses go out as one batched request
+	results, err := task1.GetAccountBalancesAt(fs.Args(), profile.RPCURL, at)
+	if err != nil {
+		log.Printf("查询余额失败: %v", err)
+		return
+	}
+	fmt.Printf("💰 余额 @ %s\n", at)
+	for i, result := range results {
+		if result.Err != nil {
+			fmt.Printf("   ❌ %s: %v\n", fs.Arg(i), result.Err)
+			continue
+		}
+		fmt.Printf("   %s: %s\n", fs.Arg(i), units.FormatWei(result.Value, units.Ether, -1))
+	}
+}
+
+func runCount(args []string) {
+	fs := flag.NewFlagSet("count", flag.ExitOnError)
+	counter := fs.String("counter", os.Getenv("COUNTER_ADDRESS"), "Counter 合约地址")
+	block := fs.String("block", "latest", "区块号、区块哈希或 latest/safe/finalized/pending/earliest")
+	txHash := fs.String("tx", "", "比较该交易前后的计数值")
+	fs.Parse(args)
+
+	if !common.IsHexAddress(*counter) {
+		fmt.Println("使用方法: go run main.go count --counter 0x合约地址 [--block 区块 | --tx 交易哈希]")
+		return
+	}
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	caller, err := contracts.NewCounterCaller(common.HexToAddress(*counter), client)
+	if err != nil {
+		log.Printf("绑定合约失败: %v", err)
+		return
+	}
+	countAt := func(at blockref.Ref) (*big.Int, error) {
+		count, err := caller.GetCount(at.CallOpts(context.Background()))
+		return count, blockref.Wrap(err, at)
+	}
+
+	if *txHash == "" {
+		at, err := blockref.Parse(*block)
+		if err != nil {
+			log.Printf("参数错误: %v", err)
+			return
+		}
+		count, err := countAt(at)
+		if err != nil {
+			log.Printf("查询计数失败: %v", err)
+			return
+		}
+		fmt.Printf("🔢 Counter @ %s: %s\n", at, count)
+		return
+	}
+
+	receipt, err := client.TransactionReceipt(context.Background(), common.HexToHash(*txHash))
+	if err != nil {
+		log.Printf("获取交易收据失败: %v", err)
+		return
+	}
+	blockNumber := receipt.BlockNumber.Uint64()
+	if blockNumber == 0 {
+		log.Printf("交易位于创世区块，无法比较")
+		return
+	}
+
+	before, err := countAt(blockref.Number(blockNumber - 1))
+	if err != nil {
+		log.Printf("查询交易前计数失败: %v", err)
+		return
+	}
+	after, err := countAt(blockref.Number(blockNumber))
+	if err != nil {
+		log.Printf("查询交易后计数失败: %v", err)
+		return
+	}
+	fmt.Printf("🔢 交易 %s (区块 #%d)\n", *txHash, blockNumber)
+	fmt.Printf("   之前 (#%d): %s\n", blockNumber-1, before)
+	fmt.Printf("   之后 (#%d): %s\n", blockNumber, after)
+	if receipt.TransactionIndex > 0 {
+		fmt.Println("   ⚠️  同一区块中该交易之前还有其他交易，差值可能包含它们的影响")
+	}
+}
+
+func runBlock(args []string) {
+	fs := flag.NewFlagSet("block", flag.ExitOnError)
+	receipts := fs.Bool("receipts", false, "批量获取收据，显示状态、gas 用量和手续费")
+	batch := fs.Int("batch", explorer.DefaultBatchSize, "每个 JSON-RPC 批次的收据数")
+	workers := fs.Int("workers", explorer.DefaultWorkers, "并发批次数")
+	fs.Parse(args)
+
+	selector := "latest"
+	if fs.NArg() > 0 {
+		selector = fs.Arg(0)
+	}
+	at, err := blockref.Parse(selector)
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	block, err := task1.QueryBlockAt(client, at)
+	if err != nil {
+		log.Printf("查询区块失败: %v", err)
+		return
+	}
+
+	info, err := explorer.Inspect(context.Background(), client.Client(), block, explorer.Options{
+		Receipts:  *receipts,
+		BatchSize: *batch,
+		Workers:   *workers,
+	})
+	if err != nil {
+		log.Printf("解析区块失败: %v", err)
+		return
+	}
+
+	header := info.Header
+	fmt.Printf("📦 区块 #%d\n", header.Number.Uint64())
+	fmt.Printf("   哈希: %s\n", info.Hash.Hex())
+	fmt.Printf("   父哈希: %s\n", header.ParentHash.Hex())
+	fmt.Printf("   时间: %s\n", info.Time.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("   出块者: %s\n", header.Coinbase.Hex())
+	fmt.Printf("   Gas: %d / %d (%.1f%%)\n", header.GasUsed, header.GasLimit, float64(header.GasUsed)*100/float64(header.GasLimit))
+	if header.BaseFee != nil {
+		fmt.Printf("   Base Fee: %s\n", units.FormatWei(header.BaseFee, units.Gwei, 4))
+		fmt.Printf("   燃烧: %s\n", units.FormatWei(info.Burnt, units.Ether, 6))
+	}
+	if header.BlobGasUsed != nil {
+		fmt.Printf("   Blob Gas: 已用 %d，超额 %d\n", *header.BlobGasUsed, *header.ExcessBlobGas)
+	}
+	if header.WithdrawalsHash != nil {
+		fmt.Printf("   提款: %d 笔\n", len(info.Withdrawals))
+		for _, w := range info.Withdrawals {
+			// Withdrawal amounts are in gwei
+			amount := new(big.Int).Mul(new(big.Int).SetUint64(w.Amount), big.NewInt(1e9))
+			fmt.Printf("     #%d validator %d → %s %s\n", w.Index, w.Validator, w.Address.Hex(), units.FormatWei(amount, units.Ether, 6))
+		}
+	}
+
+	fmt.Printf("   交易: %d 笔\n", len(info.Txs))
+	for _, tx := range info.Txs {
+		to := "(合约创建)"
+		if tx.To != nil {
+			to = tx.To.Hex()
+		}
+		line := fmt.Sprintf("     %3d %s type=%d %s → %s %s", tx.Index, tx.Hash.Hex(), tx.Type, tx.From.Hex(), to, units.FormatWei(tx.Value, units.Ether, -1))
+		if tx.Receipt != nil {
+			status := "✅"
+			if tx.Receipt.Status == types.ReceiptStatusFailed {
+				status = "❌"
+			}
+			line += fmt.Sprintf(" %s gas=%d", status, tx.Receipt.GasUsed)
+			if tx.Fee != nil {
+				line += " fee=" + units.FormatWei(tx.Fee, units.Ether, 8)
+			}
+		}
+		fmt.Println(line)
+	}
+}
+
+func runBlocks(args []string) {
+	fs := flag.NewFlagSet("blocks", flag.ExitOnError)
+	from := fs.Int64("from", -1, "起始区块 (含，默认最近 100 个区块)")
+	to := fs.Uint64("to", 0, "结束区块 (含，默认最新区块)")
+	count := fs.Uint64("last", 100, "汇总最近 N 个区块 (未指定 --from 时)")
+	batch := fs.Int("batch", explorer.DefaultBatchSize, "每个 JSON-RPC 批次的区块数")
+	workers := fs.Int("workers", explorer.DefaultWorkers, "并发批次数")
+	fs.Parse(args)
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	end := *to
+	if end == 0 {
+		if end, err = client.BlockNumber(context.Background()); err != nil {
+			log.Printf("获取最新区块失败: %v", err)
+			return
+		}
+	}
+	var start uint64
+	switch {
+	case *from >= 0:
+		start = uint64(*from)
+	case end+1 > *count:
+		start = end + 1 - *count
+	}
+
+	summaries, totals, err := explorer.Summarize(context.Background(), client.Client(), start, end, explorer.Options{
+		BatchSize: *batch,
+		Workers:   *workers,
+	})
+	if err != nil {
+		log.Printf("汇总区块失败: %v", err)
+		return
+	}
+
+	fmt.Printf("📊 区块 %d - %d\n", start, end)
+	fmt.Printf("%10s  %-19s  %5s  %22s  %14s  %16s\n", "区块", "时间", "交易", "Gas 用量", "Base Fee", "燃烧 (ETH)")
+	for _, s := range summaries {
+		baseFee := "-"
+		if s.BaseFee != nil {
+			baseFee = units.FormatWei(s.BaseFee, units.Gwei, 2)
+		}
+		fmt.Printf("%10d  %-19s  %5d  %10d (%5.1f%%)  %14s  %16s\n",
+			s.Number, s.Time.Local().Format("2006-01-02 15:04:05"), s.TxCount,
+			s.GasUsed, float64(s.GasUsed)*100/float64(s.GasLimit), baseFee, units.NewAmount(s.Burnt).Number(units.Ether, 6))
+	}
+	fmt.Printf("✅ 共 %d 个区块，%d 笔交易，Gas %d，燃烧 %s", totals.Blocks, totals.TxCount, totals.GasUsed, units.FormatWei(totals.Burnt, units.Ether, 6))
+	if totals.BlobGasUsed > 0 {
+		fmt.Printf("，Blob Gas %d", totals.BlobGasUsed)
+	}
+	fmt.Println()
+}
+
+func runCounts(args []string) {
+	fs := flag.NewFlagSet("counts", flag.ExitOnError)
+	block := fs.String("block", "latest", "区块号、区块哈希或 latest/safe/finalized/pending/earliest")
+	individual := fs.Bool("no-multicall", false, "不使用 Multicall3，逐个调用")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println("使用方法: go run main.go counts [--block 区块] [--no-multicall] <0x合约地址>...")
+		return
+	}
+	at, err := blockref.Parse(*block)
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+
+	calls := make([]multicall.Call, fs.NArg())
+	for i, arg := range fs.Args() {
+		if !common.IsHexAddress(arg) {
+			log.Printf("无效的合约地址: %s", arg)
+			return
+		}
+		call, err := multicall.CounterGetCount(common.HexToAddress(arg))
+		if err != nil {
+			log.Printf("构造调用失败: %v", err)
+			return
+		}
+		// One broken deployment should not hide the others
+		calls[i] = call.Optional()
+	}
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	mc := multicall.New(client)
+	aggregate := mc.Aggregate
+	if *individual {
+		aggregate = mc.Individually
+	}
+	results, err := aggregate(at.CallOpts(context.Background()), calls)
+	if err != nil {
+		log.Printf("查询计数失败: %v", blockref.Wrap(err, at))
+		return
+	}
+
+	fmt.Printf("🔢 Counter 计数 @ %s\n", at)
+	for i, result := range results {
+		count, err := multicall.Value[*big.Int](result)
+		if err != nil {
+			fmt.Printf("   ❌ %s: %v\n", calls[i].Target.Hex(), err)
+			continue
+		}
+		fmt.Printf("   %s: %s\n", calls[i].Target.Hex(), count)
+	}
+}
+
+func runGen(args []string) {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	check := fs.Bool("check", false, "只检查已提交的绑定是否与源码一致，不一致时以非零状态退出")
+	artifacts := fs.String("artifacts", "", "使用预编译产物目录 (<Name>.abi/.bin)，不调用 solc")
+	version := fs.String("solc-version", solcgen.VersionFromEnv(), "固定的 solc 版本")
+	install := fs.Bool("install", false, "缓存中没有 solc 时从 binaries.soliditylang.org 下载")
+	fs.Parse(args)
+
+	var (
+		contracts []solcgen.Contract
+		err       error
+	)
+	if *artifacts != "" {
+		contracts, err = solcgen.LoadArtifacts(*artifacts)
+		if err != nil {
+			log.Printf("读取编译产物失败: %v", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📦 从 %s 读取 %d 个合约\n", *artifacts, len(contracts))
+	} else {
+		sources, err := solcgen.Sources(solcgen.ContractsDir)
+		if err != nil {
+			log.Printf("查找合约源码失败: %v", err)
+			os.Exit(1)
+		}
+		if *install {
+			path, err := solcgen.Install(*version)
+			if err != nil {
+				log.Printf("安装 solc 失败: %v", err)
+				os.Exit(1)
+			}
+			fmt.Printf("📥 solc %s: %s\n", *version, path)
+		}
+		solc, err := solcgen.Find(*version)
+		if err != nil {
+			log.Printf("找不到 solc: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("🔨 使用 solc %s 编译 %s...\n", solc.Version, strings.Join(sources, ", "))
+		contracts, err = solc.Compile(".", sources)
+		if err != nil {
+			log.Printf("编译失败: %v", err)
+			os.Exit(1)
+		}
+		if !*check {
+			if err := solcgen.WriteArtifacts(solcgen.ArtifactsDir, contracts); err != nil {
+				log.Printf("保存编译产物失败: %v", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	bindings, err := solcgen.Generate(solcgen.ContractsDir, contracts)
+	if err != nil {
+		log.Printf("生成绑定失败: %v", err)
+		os.Exit(1)
+	}
+
+	if *check {
+		stale, err := solcgen.Stale(bindings)
+		if err != nil {
+			log.Printf("检查绑定失败: %v", err)
+			os.Exit(1)
+		}
+		if len(stale) > 0 {
+			fmt.Println("❌ 以下绑定与合约源码不一致，请运行 go run main.go gen 重新生成:")
+			for _, path := range stale {
+				fmt.Printf("   %s\n", path)
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("✅ %d 个绑定均为最新\n", len(bindings))
+		return
+	}
+
+	changed, err := solcgen.Write(bindings)
+	if err != nil {
+		log.Printf("写入绑定失败: %v", err)
+		os.Exit(1)
+	}
+	for _, path := range changed {
+		fmt.Printf("📝 已更新 %s\n", path)
+	}
+	fmt.Printf("✅ 已生成 %d 个绑定 (%d 个有变化)\n", len(bindings), len(changed))
+}
+
+// libraryFlags collects repeated --lib Name=0xAddress flags
+type libraryFlags map[string]common.Address
+
+func (l libraryFlags) String() string {
+	parts := make([]string, 0, len(l))
+	for name, address := range l {
+		parts = append(parts, name+"="+address.Hex())
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l libraryFlags) Set(value string) error {
+	name, address, ok := strings.Cut(value, "=")
+	if !ok || name == "" || !common.IsHexAddress(address) {
+		return fmt.Errorf("expected Name=0xAddress or file.sol:Name=0xAddress, got %q", value)
+	}
+	l[name] = common.HexToAddress(address)
+	return nil
+}
+
+func runDeploy(args []string) {
+	fs := flag.NewFlagSet("deploy", flag.ExitOnError)
+	path := fs.String("artifact", "", "Foundry (out/X.sol/X.json) 或 Hardhat (artifacts/.../X.json) 编译产物 (默认内置 Counter)")
+	argsJSON := fs.String("args", "", "构造函数参数 JSON 数组，例如 '[\"0x...\", 100]' (代替位置参数)")
+	value := fs.String("value", "", "随部署发送的 ETH，例如 \"0.01 ether\"")
+	dryRun := fs.Bool("dry-run", false, "只模拟部署，不签名")
+	force := fs.Bool("force", false, "即使部署记录中已有相同字节码和构造参数的部署也重新部署")
+	useCreate2 := fs.Bool("create2", false, "通过 CREATE2 工厂部署到确定性地址 (本地链会自动部署工厂)")
+	saltFlag := fs.String("salt", "", "CREATE2 salt: 32 字节以内的十六进制、十进制数或任意标签 (取 keccak256)，默认 0")
+	predict := fs.Bool("predict", false, "只计算 CREATE2 地址并检查是否已有代码，不发送交易")
+	libs := libraryFlags{}
+	fs.Var(libs, "lib", "链接库地址 Name=0x... 或 file.sol:Name=0x...，可重复")
+	fs.Usage = func() {
+		fmt.Println("使用方法: go run main.go deploy [--artifact 产物.json] [--lib Name=0x...] [--args JSON | 构造参数...] [--value 金额] [--dry-run] [--force]")
+		fmt.Println("         go run main.go deploy --create2 [--salt 标签] [--predict] [--artifact 产物.json] ...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	counterABI, err := contracts.CounterMetaData.GetAbi()
+	if err != nil {
+		log.Printf("解析 Counter ABI 失败: %v", err)
+		return
+	}
+	art := &artifact.Artifact{Name: "Counter", ABI: *counterABI, Bytecode: strings.TrimPrefix(contracts.CounterMetaData.Bin, "0x")}
+	if *path != "" {
+		loaded, err := artifact.Load(*path)
+		if err != nil {
+			log.Printf("读取编译产物失败: %v", err)
+			return
+		}
+		art = loaded
+		fmt.Printf("📦 %s (%s 产物)\n", art.Name, art.Format)
+	}
+	if libraries := art.Libraries(); len(libraries) > 0 {
+		fmt.Printf("🔗 需要链接的库: %s\n", strings.Join(libraries, ", "))
+	}
+
+	var constructorArgs []interface{}
+	if *argsJSON != "" {
+		constructorArgs, err = abiargs.ParseJSON(art.ABI.Constructor.Inputs, []byte(*argsJSON))
+	} else {
+		constructorArgs, err = abiargs.Parse(art.ABI.Constructor.Inputs, fs.Args())
+	}
+	if err != nil {
+		log.Printf("构造函数参数错误: %v", err)
+		return
+	}
+
+	var wei *big.Int
+	if *value != "" {
+		amount, err := units.Parse(*value)
+		if err != nil {
+			log.Printf("金额错误: %v", err)
+			return
+		}
+		wei = amount.Wei()
+	}
+
+	salt, err := create2.ParseSalt(*saltFlag)
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+	if *predict {
+		predictCreate2(art, constructorArgs, libs, salt)
+		return
+	}
+
+	profile, err := network.FromEnv()
+	if err != nil {
+		log.Printf("网络配置错误: %v", err)
+		return
+	}
+	privateKey := os.Getenv("PRIVATE_KEY")
+	if privateKey == "" {
+		log.Print("❌ 未设置 PRIVATE_KEY 环境变量")
+		return
+	}
+
+	ci, err := task2.NewContractInteraction(profile, privateKey)
+	if err != nil {
+		log.Printf("初始化失败: %v", err)
+		return
+	}
+	defer ci.Close()
+	if *dryRun {
+		ci.EnableDryRun()
+	}
+	if *force {
+		ci.ForceDeploy()
+	}
+
+	var address common.Address
+	if *useCreate2 {
+		address, err = ci.DeployArtifactDeterministic(art, constructorArgs, libs, wei, salt)
+	} else {
+		address, err = ci.DeployArtifact(art, constructorArgs, libs, wei)
+	}
+	if err != nil {
+		log.Printf("部署失败: %v", err)
+		return
+	}
+	if address != (common.Address{}) {
+		fmt.Printf("✅ %s 已部署: %s\n", art.Name, address.Hex())
+	}
+}
+
+// predictCreate2 prints the CREATE2 address of a deployment and whether it already has code
+func predictCreate2(art *artifact.Artifact, constructorArgs []interface{}, libs map[string]common.Address, salt common.Hash) {
+	bytecode, err := art.Link(libs)
+	if err != nil {
+		log.Printf("链接失败: %v", err)
+		return
+	}
+	initCode, err := create2.InitCode(&art.ABI, bytecode, constructorArgs...)
+	if err != nil {
+		log.Printf("构造函数参数错误: %v", err)
+		return
+	}
+	address := create2.Address(create2.FactoryAddress, salt, initCode)
+	fmt.Printf("🏭 工厂: %s\n", create2.FactoryAddress.Hex())
+	fmt.Printf("🧂 Salt: %s\n", salt.Hex())
+	fmt.Printf("🎯 %s 的 CREATE2 地址: %s\n", art.Name, address.Hex())
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	defer client.Close()
+	for _, check := range []struct {
+		label   string
+		address common.Address
+	}{{"工厂", create2.FactoryAddress}, {art.Name, address}} {
+		code, err := client.CodeAt(context.Background(), check.address, nil)
+		if err != nil {
+			log.Printf("查询合约代码失败: %v", err)
+			return
+		}
+		if len(code) > 0 {
+			fmt.Printf("✅ %s 已部署 (%d 字节)\n", check.label, len(code))
+		} else {
+			fmt.Printf("⬜ %s 尚未部署\n", check.label)
+		}
+	}
+}
+
+func runProxy(args []string) {
+	if len(args) == 0 {
+		printProxyUsage()
+		return
+	}
+
+	switch args[0] {
+	case "deploy":
+		runProxyDeploy(args[1:])
+	case "upgrade":
+		runProxyUpgrade(args[1:])
+	case "info":
+		runProxyInfo(args[1:])
+	case "check-layout":
+		runProxyCheckLayout(args[1:])
+	default:
+		printProxyUsage()
+	}
+}
+
+func printProxyUsage() {
+	fmt.Println("使用方法:")
+	fmt.Println("  go run main.go proxy deploy --artifact 产物.json [--kind transparent|uups] [--admin 0x...] [--init 方法 [--init-args JSON]] [--layout 文件] [--lib Name=0x...] [--dry-run] [--force]")
+	fmt.Println("  go run main.go proxy upgrade --artifact 产物.json [--old-layout 文件] [--layout 文件] [--init 方法 [--init-args JSON]] [--unsafe-skip-layout-check] [--dry-run] <代理地址|合约名>")
+	fmt.Println("  go run main.go proxy info <代理地址|合约名>...")
+	fmt.Println("  go run main.go proxy check-layout <旧布局> <新布局>")
+	fmt.Println("")
+	fmt.Println("代理遵循 ERC-1967：实现地址和管理员地址通过 eth_getStorageAt 从 EIP-1967 槽读取")
+	fmt.Println("transparent: 管理员 (默认当前账户) 调用 upgradeToAndCall 升级，管理员的其他调用不会转发给实现")
+	fmt.Println("uups: 实现合约自己提供 upgradeToAndCall (如 OpenZeppelin UUPSUpgradeable)，部署前检查 proxiableUUID")
+	fmt.Println("存储布局来自 solc storageLayout 输出: Foundry 产物 (extra_output = [\"storageLayout\"])、gen 生成的 build/<Name>.storage-layout.json 或 --layout 指定的文件")
+	fmt.Println("升级前会比较新旧实现的存储布局，旧布局默认取部署记录中当前实现的布局")
+}
+
+// proxyFlags registers the flags shared by proxy deploy and upgrade
+func proxyFlags(fs *flag.FlagSet) func() (*artifact.Artifact, task2.ProxyInit, error) {
+	path := fs.String("artifact", "", "实现合约的 Foundry/Hardhat 编译产物")
+	layout := fs.String("layout", "", "实现合约的存储布局 JSON (代替产物中的 storageLayout)")
+	initMethod := fs.String("init", "", "通过代理调用的初始化方法，例如 initialize")
+	initArgs := fs.String("init-args", "", "初始化方法参数 JSON 数组")
+
+	return func() (*artifact.Artifact, task2.ProxyInit, error) {
+		var init task2.ProxyInit
+		if *path == "" {
+			return nil, init, fmt.Errorf("缺少 --artifact")
+		}
+		art, err := artifact.Load(*path)
+		if err != nil {
+			return nil, init, err
+		}
+		if *layout != "" {
+			data, err := os.ReadFile(*layout)
+			if err != nil {
+				return nil, init, err
+			}
+			art.StorageLayout = data
+		}
+		if *initMethod != "" {
+			method, err := abiargs.FindMethod(&art.ABI, *initMethod)
+			if err != nil {
+				return nil, init, err
+			}
+			init.Method = method.Name
+			if *initArgs != "" {
+				if init.Args, err = abiargs.ParseJSON(method.Inputs, []byte(*initArgs)); err != nil {
+					return nil, init, err
+				}
+			}
+		}
+		return art, init, nil
+	}
+}
+
+// newContractInteraction connects with the configured network and PRIVATE_KEY
+func newContractInteraction() (*task2.ContractInteraction, error) {
+	profile, err := network.FromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("网络配置错误: %v", err)
+	}
+	privateKey := os.Getenv("PRIVATE_KEY")
+	if privateKey == "" {
+		return nil, fmt.Errorf("未设置 PRIVATE_KEY 环境变量")
+	}
+	return task2.NewContractInteraction(profile, privateKey)
+}
+
+func runProxyDeploy(args []string) {
+	fs := flag.NewFlagSet("proxy deploy", flag.ExitOnError)
+	loadArtifact := proxyFlags(fs)
+	kindFlag := fs.String("kind", string(proxy.Transparent), "代理类型: transparent 或 uups")
+	admin := fs.String("admin", "", "transparent 代理的管理员 (默认当前账户)")
+	dryRun := fs.Bool("dry-run", false, "只模拟，不签名")
+	force := fs.Bool("force", false, "即使部署记录中已有相同部署也重新部署")
+	libs := libraryFlags{}
+	fs.Var(libs, "lib", "链接库地址 Name=0x... 或 file.sol:Name=0x...，可重复")
+	fs.Parse(args)
+
+	kind, err := proxy.ParseKind(*kindFlag)
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+	var adminAddress common.Address
+	if *admin != "" {
+		if kind != proxy.Transparent || !common.IsHexAddress(*admin) {
+			log.Printf("参数错误: --admin 只用于 transparent 代理，且必须是地址")
+			return
+		}
+		adminAddress = common.HexToAddress(*admin)
+	}
+	art, init, err := loadArtifact()
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		printProxyUsage()
+		return
+	}
+
+	ci, err := newContractInteraction()
+	if err != nil {
+		log.Printf("初始化失败: %v", err)
+		return
+	}
+	defer ci.Close()
+	if *dryRun {
+		ci.EnableDryRun()
+	}
+	if *force {
+		ci.ForceDeploy()
+	}
+
+	info, err := ci.DeployProxy(art, libs, kind, adminAddress, init)
+	if err != nil {
+		log.Printf("代理部署失败: %v", err)
+		return
+	}
+	if info != nil {
+		fmt.Printf("✅ %s 代理已部署: %s (实现 %s)\n", art.Name, info.Address.Hex(), info.Implementation.Hex())
+	}
+}
+
+func runProxyUpgrade(args []string) {
+	fs := flag.NewFlagSet("proxy upgrade", flag.ExitOnError)
+	loadArtifact := proxyFlags(fs)
+	oldLayout := fs.String("old-layout", "", "当前实现的存储布局 JSON (默认取部署记录)")
+	unsafeSkip := fs.Bool("unsafe-skip-layout-check", false, "存储布局未知或不兼容时仍然升级")
+	dryRun := fs.Bool("dry-run", false, "只模拟，不签名")
+	libs := libraryFlags{}
+	fs.Var(libs, "lib", "链接库地址 Name=0x... 或 file.sol:Name=0x...，可重复")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		printProxyUsage()
+		return
+	}
+	art, init, err := loadArtifact()
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+	var current *proxy.StorageLayout
+	if *oldLayout != "" {
+		if current, err = proxy.LoadLayout(*oldLayout); err != nil {
+			log.Printf("参数错误: %v", err)
+			return
+		}
+	}
+
+	ci, err := newContractInteraction()
+	if err != nil {
+		log.Printf("初始化失败: %v", err)
+		return
+	}
+	defer ci.Close()
+	if *dryRun {
+		ci.EnableDryRun()
+	}
+
+	address, err := ci.ResolveContract(fs.Arg(0))
+	if err != nil {
+		log.Printf("解析地址失败: %v", err)
+		return
+	}
+	if _, err := ci.UpgradeProxy(address, art, libs, init, current, *unsafeSkip); err != nil {
+		log.Printf("升级失败: %v", err)
+		if errors.Is(err, proxy.ErrIncompatibleLayout) {
+			os.Exit(1)
+		}
+	}
+}
+
+func runProxyInfo(args []string) {
+	if len(args) == 0 {
+		printProxyUsage()
+		return
+	}
+
+	ci, err := newContractInteraction()
+	if err != nil {
+		log.Printf("初始化失败: %v", err)
+		return
+	}
+	defer ci.Close()
+
+	for _, arg := range args {
+		address, err := ci.ResolveContract(arg)
+		if err != nil {
+			log.Printf("解析地址失败: %v", err)
+			continue
+		}
+		info, err := ci.InspectProxy(address)
+		if err != nil {
+			log.Printf("读取代理失败: %v", err)
+			continue
+		}
+		task2.PrintProxyInfo(info)
+	}
+}
+
+func runProxyCheckLayout(args []string) {
+	if len(args) != 2 {
+		printProxyUsage()
+		return
+	}
+	current, err := proxy.LoadLayout(args[0])
+	if err != nil {
+		log.Printf("读取旧布局失败: %v", err)
+		os.Exit(1)
+	}
+	next, err := proxy.LoadLayout(args[1])
+	if err != nil {
+		log.Printf("读取新布局失败: %v", err)
+		os.Exit(1)
+	}
+
+	issues := proxy.CheckLayout(current, next)
+	for _, issue := range issues {
+		if issue.Severity == proxy.Error {
+			fmt.Printf("❌ %s\n", issue.Message)
+		} else {
+			fmt.Printf("⚠️  %s\n", issue.Message)
+		}
+	}
+	if proxy.HasErrors(issues) {
+		fmt.Println("❌ 存储布局不兼容，升级会破坏代理存储")
+		os.Exit(1)
+	}
+	fmt.Println("✅ 存储布局兼容")
+}
+
+func runDeployments(args []string) {
+	if len(args) == 0 {
+		runDeploymentsList(args)
+		return
+	}
+
+	switch args[0] {
+	case "list":
+		runDeploymentsList(args[1:])
+	case "prune":
+		runDeploymentsPrune(args[1:])
+	default:
+		printDeploymentsUsage()
+	}
+}
+
+func printDeploymentsUsage() {
+	fmt.Println("使用方法:")
+	fmt.Println("  go run main.go deployments [list] [--name 合约名]")
+	fmt.Println("  go run main.go deployments prune [--name 合约名] [--address 0x...] [--dead]")
+	fmt.Println("")
+	fmt.Println("部署记录保存在 $DEPLOYMENTS_DIR/<chainId>.json (默认 deployments/)")
+	fmt.Println("prune 删除匹配所有条件的记录；--dead 只删除地址上已无代码的记录 (例如本地链重置后)")
+}
+
+// loadRegistry opens the deployment registry of the configured network
+func loadRegistry() (*ethclient.Client, *registry.Registry, error) {
+	client, err := dialProfile()
+	if err != nil {
+		return nil, nil, err
+	}
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("获取链 ID 失败: %v", err)
+	}
+	reg, err := registry.Load(registry.DirFromEnv(), chainID)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return client, reg, nil
+}
+
+func runDeploymentsList(args []string) {
+	fs := flag.NewFlagSet("deployments list", flag.ExitOnError)
+	name := fs.String("name", "", "只显示该合约名的部署")
+	fs.Parse(args)
+
+	client, reg, err := loadRegistry()
+	if err != nil {
+		log.Printf("读取部署记录失败: %v", err)
+		return
+	}
+	defer client.Close()
+
+	fmt.Printf("🗂️  %s (链 %d)\n", reg.Path(), reg.ChainID)
+	count := 0
+	for _, d := range reg.Deployments {
+		if *name != "" && !strings.EqualFold(d.Name, *name) {
+			continue
+		}
+		count++
+		fmt.Printf("📍 %-12s %s  区块 %d  %s\n", d.Name, d.Address.Hex(), d.Block, d.DeployedAt.Format(time.RFC3339))
+		fmt.Printf("   交易: %s  部署者: %s\n", d.TxHash.Hex(), d.Deployer.Hex())
+		fmt.Printf("   字节码哈希: %s", d.BytecodeHash.Hex())
+		if len(d.ConstructorArgs) > 0 {
+			fmt.Printf("  构造参数: %s", d.ConstructorArgs)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("共 %d 条部署记录\n", count)
+}
+
+func runDeploymentsPrune(args []string) {
+	fs := flag.NewFlagSet("deployments prune", flag.ExitOnError)
+	name := fs.String("name", "", "只删除该合约名的部署")
+	address := fs.String("address", "", "只删除该地址的部署")
+	dead := fs.Bool("dead", false, "只删除地址上已无代码的部署")
+	fs.Parse(args)
+
+	if *name == "" && *address == "" && !*dead {
+		printDeploymentsUsage()
+		return
+	}
+	if *address != "" && !common.IsHexAddress(*address) {
+		log.Printf("无效地址: %s", *address)
+		return
+	}
+
+	client, reg, err := loadRegistry()
+	if err != nil {
+		log.Printf("读取部署记录失败: %v", err)
+		return
+	}
+	defer client.Close()
+
+	var checkErr error
+	removed := reg.Prune(func(d registry.Deployment) bool {
+		if *name != "" && !strings.EqualFold(d.Name, *name) {
+			return false
+		}
+		if *address != "" && d.Address != common.HexToAddress(*address) {
+			return false
+		}
+		if *dead {
+			code, err := client.CodeAt(context.Background(), d.Address, nil)
+			if err != nil {
+				checkErr = err
+				return false
+			}
+			return len(code) == 0
+		}
+		return true
+	})
+	if checkErr != nil {
+		log.Printf("查询合约代码失败: %v", checkErr)
+		return
+	}
+	if len(removed) == 0 {
+		fmt.Println("ℹ️  没有匹配的部署记录")
+		return
+	}
+
+	if err := reg.Save(); err != nil {
+		log.Printf("保存部署记录失败: %v", err)
+		return
+	}
+	for _, d := range removed {
+		fmt.Printf("🗑️  已删除 %s %s (区块 %d)\n", d.Name, d.Address.Hex(), d.Block)
+	}
+	fmt.Printf("✅ 删除 %d 条，剩余 %d 条\n", len(removed), len(reg.Deployments))
+}
+
+func runContract(args []string) {
+	if len(args) == 0 || (args[0] != "call" && args[0] != "send") {
+		fmt.Println("使用方法: go run main.go contract call|send [选项] <地址> <ABI 文件> <方法> [参数...]")
+		fmt.Println("  call  [--block 区块] [--from 地址] [--args JSON]  只读调用并以 JSON 输出返回值")
+		fmt.Println("  send  [--value 金额] [--dry-run] [--args JSON]    发送交易并以 JSON 输出收据和事件")
+		fmt.Println("  ABI 文件可以是 ABI JSON 数组，也可以是 Foundry/Hardhat 编译产物；重载方法用签名，例如 'transfer(address,uint256)'")
+		return
+	}
+	mode := args[0]
+
+	fs := flag.NewFlagSet("contract "+mode, flag.ExitOnError)
+	argsJSON := fs.String("args", "", "方法参数 JSON 数组 (代替位置参数)")
+	block := fs.String("block", "latest", "call: 区块号、区块哈希或 latest/safe/finalized/pending/earliest")
+	from := fs.String("from", "", "call: 调用者地址 (msg.sender)")
+	value := fs.String("value", "", "send: 随交易发送的 ETH，例如 \"0.01 ether\"")
+	dryRun := fs.Bool("dry-run", false, "send: 只模拟，不签名")
+	fs.Parse(args[1:])
+
+	if fs.NArg() < 3 {
+		fmt.Printf("使用方法: go run main.go contract %s [选项] <地址> <ABI 文件> <方法> [参数...]\n", mode)
+		return
+	}
+	contractABI, err := artifact.LoadABI(fs.Arg(1))
+	if err != nil {
+		log.Printf("读取 ABI 失败: %v", err)
+		return
+	}
+	method, err := abiargs.FindMethod(contractABI, fs.Arg(2))
+	if err != nil {
+		log.Printf("方法错误: %v", err)
+		return
+	}
+	var methodArgs []interface{}
+	if *argsJSON != "" {
+		methodArgs, err = abiargs.ParseJSON(method.Inputs, []byte(*argsJSON))
+	} else {
+		methodArgs, err = abiargs.Parse(method.Inputs, fs.Args()[3:])
+	}
+	if err != nil {
+		log.Printf("参数错误: %v", err)
+		return
+	}
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+	address, err := task1.ResolveAddress(client, fs.Arg(0))
+	if err != nil {
+		log.Printf("解析合约地址失败: %v", err)
+		return
+	}
+
+	if mode == "call" {
+		at, err := blockref.Parse(*block)
+		if err != nil {
+			log.Printf("参数错误: %v", err)
+			return
+		}
+		opts := at.CallOpts(context.Background())
+		if *from != "" {
+			if !common.IsHexAddress(*from) {
+				log.Printf("无效的调用者地址: %s", *from)
+				return
+			}
+			opts.From = common.HexToAddress(*from)
+		}
+
+		contract := bind.NewBoundContract(address, *contractABI, client, nil, nil)
+		var out []interface{}
+		if err := contract.Call(opts, &out, method.Name, methodArgs...); err != nil {
+			log.Printf("调用 %s 失败: %v", method.Sig, blockref.Wrap(err, at))
+			return
+		}
+		printJSON(map[string]interface{}{
+			"contract": address.Hex(),
+			"method":   method.Sig,
+			"block":    at.String(),
+			"outputs":  abiargs.FormatValues(method.Outputs, out),
+		})
+		return
+	}
+
+	var wei *big.Int
+	if *value != "" {
+		amount, err := units.Parse(*value)
+		if err != nil {
+			log.Printf("金额错误: %v", err)
+			return
+		}
+		wei = amount.Wei()
+	}
+
+	profile, err := network.FromEnv()
+	if err != nil {
+		log.Printf("网络配置错误: %v", err)
+		return
+	}
+	privateKey := os.Getenv("PRIVATE_KEY")
+	if privateKey == "" {
+		log.Print("❌ 未设置 PRIVATE_KEY 环境变量")
+		return
+	}
+	ci, err := task2.NewContractInteraction(profile, privateKey)
+	if err != nil {
+		log.Printf("初始化失败: %v", err)
+		return
+	}
+	defer ci.Close()
+	if *dryRun {
+		ci.EnableDryRun()
+	}
+
+	result, err := ci.Transact(address, contractABI, method.Name, methodArgs, wei)
+	if err != nil {
+		log.Printf("发送 %s 失败: %v", method.Sig, err)
+		return
+	}
+	if result == nil {
+		return
+	}
+
+	events := make([]map[string]interface{}, 0, len(result.Events))
+	for _, event := range result.Events {
+		events = append(events, map[string]interface{}{
+			"event":    event.Name,
+			"address":  event.Log.Address.Hex(),
+			"logIndex": event.Log.Index,
+			"fields":   abiargs.FormatFields(contractABI.Events[event.Name].Inputs, event.Fields),
+		})
+	}
+	printJSON(map[string]interface{}{
+		"contract":    address.Hex(),
+		"method":      method.Sig,
+		"txHash":      result.Tx.Hash().Hex(),
+		"blockNumber": result.Receipt.BlockNumber.Uint64(),
+		"status":      result.Receipt.Status,
+		"gasUsed":     result.Receipt.GasUsed,
+		"events":      events,
+	})
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Printf("JSON 编码失败: %v", err)
+		return
+	}
+	fmt.Println(string(data))
+}
+
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	path := fs.String("artifact", "", "Foundry/Hardhat 编译产物 (默认: 内置的 Counter)")
+	libs := libraryFlags{}
+	fs.Var(libs, "lib", "链接库地址 Name=0x... 或 file.sol:Name=0x...，可重复")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println("使用方法: go run main.go verify [--artifact 产物.json] [--lib Name=0x...] <合约地址>...")
+		return
+	}
+
+	var (
+		expected verify.Expected
+		err      error
+	)
+	if *path != "" {
+		art, loadErr := artifact.Load(*path)
+		if loadErr != nil {
+			log.Printf("读取编译产物失败: %v", loadErr)
+			return
+		}
+		expected, err = verify.FromArtifact(art, libs)
+	} else {
+		expected, err = verify.FromCreation("Counter", common.FromHex(contracts.CounterMetaData.Bin))
+	}
+	if err != nil {
+		log.Printf("无法确定期望的运行时字节码: %v", err)
+		return
+	}
+	_, wantMeta, _ := verify.SplitMetadata(expected.Runtime)
+	fmt.Printf("📦 期望: %s (%d 字节)%s\n", expected.Name, len(expected.Runtime), describeMetadata(wantMeta))
+
+	client, err := dialProfile()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer client.Close()
+
+	mismatches := 0
+	for _, arg := range fs.Args() {
+		address, err := task1.ResolveAddress(client, arg)
+		if err != nil {
+			log.Printf("解析地址失败: %v", err)
+			mismatches++
+			continue
+		}
+		result, err := verify.Verify(context.Background(), client, address, expected)
+		if err != nil {
+			log.Printf("验证失败: %v", err)
+			mismatches++
+			continue
+		}
+
+		switch result.Status {
+		case verify.Match:
+			fmt.Printf("✅ %s: 完全匹配%s\n", address.Hex(), describeMetadata(result.Metadata))
+		case verify.Partial:
+			fmt.Printf("🟡 %s: 部分匹配，可执行代码一致但元数据不同%s\n", address.Hex(), describeMetadata(result.Metadata))
+		default:
+			mismatches++
+			fmt.Printf("❌ %s: 不匹配 (%s)%s\n", address.Hex(), result.Reason, describeMetadata(result.Metadata))
+		}
+		if len(result.Masked) > 0 {
+			fmt.Printf("   已忽略 %d 处 immutable/库地址\n", len(result.Masked))
+		}
+	}
+	if mismatches > 0 {
+		os.Exit(1)
+	}
+}
+
+// describeMetadata renders the compiler version and metadata hash embedded in runtime code
+func describeMetadata(meta *verify.Metadata) string {
+	if meta == nil {
+		return ""
+	}
+	var parts []string
+	if meta.Solc != "" {
+		parts = append(parts, "solc "+meta.Solc)
+	}
+	switch {
+	case meta.IPFS != "":
+		parts = append(parts, "ipfs "+meta.IPFS)
+	case meta.Bzzr1 != "":
+		parts = append(parts, "bzzr1 "+meta.Bzzr1)
+	case meta.Bzzr0 != "":
+		parts = append(parts, "bzzr0 "+meta.Bzzr0)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+// hasFlag reports whether a boolean flag appears in args
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
+
 func printUsage() {
 	fmt.Println("🚀 Ethereum Go 学习项目")
 	fmt.Println("========================")
@@ -78,7 +2032,26 @@ func printUsage() {
 	fmt.Println("使用方法:")
 	fmt.Println("  go run main.go task1    - 执行 ETH 转账测试")
 	fmt.Println("  go run main.go task2    - 执行 Abigen 智能合约交互")
+	fmt.Println("  (task1/task2 加 --dry-run 只模拟，不签名不发送；task2 加 --force 忽略部署记录重新部署)")
 	fmt.Println("  go run main.go setup    - 设置 Abigen 环境")
+	fmt.Println("  go run main.go resolve  - 解析 ENS 名称 / 反向解析地址")
+	fmt.Println("  go run main.go history  - 查询/导出本地交易历史，同步待确认交易")
+	fmt.Println("  go run main.go index    - 索引关注地址的交易、合约创建和 Counter 事件")
+	fmt.Println("  go run main.go watch    - 监控地址余额，低于阈值或异常变动时告警")
+	fmt.Println("  go run main.go follow   - 跟踪新区块 (ws/IPC 订阅，HTTP 轮询)，检测链重组")
+	fmt.Println("  go run main.go events   - 可断点续传的 Counter 事件流 (先补齐历史再实时订阅)")
+	fmt.Println("  go run main.go counter-history - 由事件重建 Counter 历史并与链上 getCount 核对")
+	fmt.Println("  go run main.go balance  - 查询任意区块 (号/哈希/标签) 的账户余额，多个地址合并为批量请求")
+	fmt.Println("  go run main.go count    - 查询任意区块的 Counter 计数，或比较某笔交易前后的值")
+	fmt.Println("  go run main.go counts   - 通过 Multicall3 一次读取多个 Counter 部署的计数")
+	fmt.Println("  go run main.go gen      - 用固定版本的 solc 编译 contracts/*.sol 并生成 Go 绑定，--check 检查绑定是否过期")
+	fmt.Println("  go run main.go deploy   - 从 Foundry/Hardhat 编译产物部署任意合约 (支持构造参数和库链接)，--create2 部署到确定性地址")
+	fmt.Println("  go run main.go proxy    - 部署 ERC-1967 transparent/UUPS 代理、读取代理槽，并在存储布局检查后升级")
+	fmt.Println("  go run main.go deployments - 列出/清理按链 ID 保存的部署记录 (deployments/<chainId>.json)")
+	fmt.Println("  go run main.go contract - 按 ABI 调用 (call) 或发送 (send) 任意合约方法，结果以 JSON 输出")
+	fmt.Println("  go run main.go verify   - 校验地址上的字节码是否与 Counter 或指定编译产物一致")
+	fmt.Println("  go run main.go block    - 查看区块详情 (号/哈希/标签)，--receipts 批量获取收据")
+	fmt.Println("  go run main.go blocks   - 汇总区块范围内的 gas、交易数和燃烧量 (默认最近 100 个区块)")
 	fmt.Println("")
 	fmt.Println("Task 1: ETH 转账")
 	fmt.Println("  - 演示基本的 ETH 转账功能")
@@ -0,0 +1,268 @@
+package abiargs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Parse converts command line values into arguments for abi.Pack. Scalars are written
+// as-is (numbers in decimal or 0x-hex, bytes in hex); arrays and tuples as JSON.
+func Parse(arguments abi.Arguments, values []string) ([]interface{}, error) {
+	if len(values) != len(arguments) {
+		return nil, fmt.Errorf("expected %d arguments (%s), got %d", len(arguments), signature(arguments), len(values))
+	}
+	parsed := make([]interface{}, len(values))
+	for i, arg := range arguments {
+		var value interface{} = values[i]
+		if composite(arg.Type) {
+			decoded, err := decodeJSON([]byte(values[i]))
+			if err != nil {
+				return nil, fmt.Errorf("argument %s: expected JSON for %s: %v", name(arg, i), arg.Type, err)
+			}
+			value = decoded
+		}
+		converted, err := Convert(arg.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", name(arg, i), err)
+		}
+		parsed[i] = converted
+	}
+	return parsed, nil
+}
+
+// ParseJSON converts a JSON array of values into arguments for abi.Pack
+func ParseJSON(arguments abi.Arguments, data []byte) ([]interface{}, error) {
+	decoded, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON arguments: %v", err)
+	}
+	values, ok := decoded.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON arguments must be an array")
+	}
+	if len(values) != len(arguments) {
+		return nil, fmt.Errorf("expected %d arguments (%s), got %d", len(arguments), signature(arguments), len(values))
+	}
+	parsed := make([]interface{}, len(values))
+	for i, arg := range arguments {
+		converted, err := Convert(arg.Type, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", name(arg, i), err)
+		}
+		parsed[i] = converted
+	}
+	return parsed, nil
+}
+
+// Convert turns a string or JSON-decoded value into the Go type abi.Pack expects for t
+func Convert(t abi.Type, value interface{}) (interface{}, error) {
+	v, err := convert(t, value)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+func convert(t abi.Type, value interface{}) (reflect.Value, error) {
+	goType := t.GetType()
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := toBig(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if t.T == abi.UintTy && n.Sign() < 0 {
+			return reflect.Value{}, fmt.Errorf("%s cannot be negative", t)
+		}
+		if n.BitLen() > t.Size || (t.T == abi.IntTy && n.BitLen() == t.Size && !isMinInt(n, t.Size)) {
+			return reflect.Value{}, fmt.Errorf("%s overflows %s", n, t)
+		}
+		if goType == reflect.TypeOf((*big.Int)(nil)) {
+			return reflect.ValueOf(n), nil
+		}
+		out := reflect.New(goType).Elem()
+		if t.T == abi.IntTy {
+			out.SetInt(n.Int64())
+		} else {
+			out.SetUint(n.Uint64())
+		}
+		return out, nil
+
+	case abi.BoolTy:
+		switch b := value.(type) {
+		case bool:
+			return reflect.ValueOf(b), nil
+		case string:
+			switch strings.ToLower(b) {
+			case "true", "1":
+				return reflect.ValueOf(true), nil
+			case "false", "0":
+				return reflect.ValueOf(false), nil
+			}
+		}
+		return reflect.Value{}, fmt.Errorf("invalid bool %v", value)
+
+	case abi.AddressTy:
+		s, ok := value.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("invalid address %v", value)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.StringTy:
+		s, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected a string, got %v", value)
+		}
+		return reflect.ValueOf(s), nil
+
+	case abi.BytesTy:
+		b, err := toBytes(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b, err := toBytes(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		size := goType.Len()
+		if len(b) != size {
+			return reflect.Value{}, fmt.Errorf("%s needs %d bytes, got %d", t, size, len(b))
+		}
+		out := reflect.New(goType).Elem()
+		reflect.Copy(out, reflect.ValueOf(b))
+		return out, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		items, ok := value.([]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected a JSON array for %s", t)
+		}
+		var out reflect.Value
+		if t.T == abi.SliceTy {
+			out = reflect.MakeSlice(goType, len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("%s needs %d elements, got %d", t, t.Size, len(items))
+			}
+			out = reflect.New(goType).Elem()
+		}
+		for i, item := range items {
+			elem, err := convert(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("[%d]: %v", i, err)
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+
+	case abi.TupleTy:
+		out := reflect.New(goType).Elem()
+		switch fields := value.(type) {
+		case []interface{}:
+			if len(fields) != len(t.TupleElems) {
+				return reflect.Value{}, fmt.Errorf("%s needs %d fields, got %d", t, len(t.TupleElems), len(fields))
+			}
+			for i, elem := range t.TupleElems {
+				field, err := convert(*elem, fields[i])
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("%s: %v", t.TupleRawNames[i], err)
+				}
+				out.Field(i).Set(field)
+			}
+		case map[string]interface{}:
+			for i, elem := range t.TupleElems {
+				raw, ok := fields[t.TupleRawNames[i]]
+				if !ok {
+					return reflect.Value{}, fmt.Errorf("missing field %s", t.TupleRawNames[i])
+				}
+				field, err := convert(*elem, raw)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("%s: %v", t.TupleRawNames[i], err)
+				}
+				out.Field(i).Set(field)
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("expected a JSON object or array for %s", t)
+		}
+		return out, nil
+	}
+	return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+}
+
+func composite(t abi.Type) bool {
+	return t.T == abi.SliceTy || t.T == abi.ArrayTy || t.T == abi.TupleTy
+}
+
+func toBig(value interface{}) (*big.Int, error) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = strings.TrimSpace(v)
+	case json.Number:
+		s = v.String()
+	default:
+		return nil, fmt.Errorf("invalid integer %v", value)
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
+
+// isMinInt reports whether n is the most negative intN, the only value using all N bits
+func isMinInt(n *big.Int, size int) bool {
+	return n.Sign() < 0 && new(big.Int).Neg(n).Cmp(new(big.Int).Lsh(big.NewInt(1), uint(size-1))) == 0
+}
+
+func toBytes(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected 0x-prefixed hex, got %v", value)
+	}
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %q: %v", s, err)
+	}
+	return b, nil
+}
+
+// decodeJSON keeps numbers as json.Number so large integers survive
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func name(arg abi.Argument, i int) string {
+	if arg.Name != "" {
+		return arg.Name
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+// signature renders arguments like "uint256 amount, address to"
+func signature(arguments abi.Arguments) string {
+	parts := make([]string, len(arguments))
+	for i, arg := range arguments {
+		parts[i] = strings.TrimSpace(arg.Type.String() + " " + arg.Name)
+	}
+	return strings.Join(parts, ", ")
+}
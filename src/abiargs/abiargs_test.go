@@ -0,0 +1,130 @@
+package abiargs
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+const to = "0x00000000000000000000000000000000000000aa"
+
+var (
+	twoTo255   = new(big.Int).Lsh(big.NewInt(1), 255)
+	twoTo256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	maxInt256  = new(big.Int).Sub(twoTo255, big.NewInt(1)).String()
+	minInt256  = new(big.Int).Neg(twoTo255).String()
+	maxUint256 = new(big.Int).Sub(twoTo256, big.NewInt(1)).String()
+)
+
+func TestParse(t *testing.T) {
+	transfer := []abi.ArgumentMarshaling{{Name: "amount", Type: "uint256"}, {Name: "to", Type: "address"}}
+	tests := []struct {
+		typ        string
+		components []abi.ArgumentMarshaling
+		value      string
+		want       string // JSON of Format, or the error when wantErr
+		wantErr    bool
+	}{
+		{typ: "int8", value: "127", want: `"127"`},
+		{typ: "int8", value: "-128", want: `"-128"`},
+		{typ: "int8", value: "128", want: "128 overflows int8", wantErr: true},
+		{typ: "int8", value: "-129", want: "-129 overflows int8", wantErr: true},
+		{typ: "int64", value: "-9223372036854775808", want: `"-9223372036854775808"`},
+		{typ: "int64", value: "9223372036854775808", want: "overflows int64", wantErr: true},
+		{typ: "int256", value: maxInt256, want: `"` + maxInt256 + `"`},
+		{typ: "int256", value: minInt256, want: `"` + minInt256 + `"`},
+		{typ: "int256", value: twoTo255.String(), want: "overflows int256", wantErr: true},
+		{typ: "int256", value: new(big.Int).Sub(new(big.Int).Neg(twoTo255), big.NewInt(1)).String(), want: "overflows int256", wantErr: true},
+		{typ: "int256", value: "-" + maxUint256, want: "overflows int256", wantErr: true},
+		{typ: "uint8", value: "255", want: `"255"`},
+		{typ: "uint8", value: "0xff", want: `"255"`},
+		{typ: "uint8", value: "256", want: "256 overflows uint8", wantErr: true},
+		{typ: "uint8", value: "-1", want: "uint8 cannot be negative", wantErr: true},
+		{typ: "uint256", value: maxUint256, want: `"` + maxUint256 + `"`},
+		{typ: "uint256", value: twoTo256.String(), want: "overflows uint256", wantErr: true},
+		{typ: "uint256", value: "1.5", want: `invalid integer "1.5"`, wantErr: true},
+
+		{typ: "uint8[3]", value: `[1, "2", "0x03"]`, want: `["1","2","3"]`},
+		{typ: "uint8[3]", value: `[1, 2]`, want: "uint8[3] needs 3 elements, got 2", wantErr: true},
+		{typ: "uint8[3]", value: `[1, 2, 256]`, want: "[2]: 256 overflows uint8", wantErr: true},
+		{typ: "address[2]", value: `["` + to + `", "` + to + `"]`, want: `["0x00000000000000000000000000000000000000AA","0x00000000000000000000000000000000000000AA"]`},
+		{typ: "bytes2[1]", value: `["0x0102"]`, want: `["0x0102"]`},
+		{typ: "uint256[2][]", value: `[[1, 2], [3, 4]]`, want: `[["1","2"],["3","4"]]`},
+		{typ: "uint256[]", value: `{"a": 1}`, want: "expected a JSON array for uint256[]", wantErr: true},
+
+		{typ: "tuple", components: transfer, value: `["5", "` + to + `"]`, want: `{"amount":"5","to":"0x00000000000000000000000000000000000000AA"}`},
+		{typ: "tuple", components: transfer, value: `{"to": "` + to + `", "amount": 5}`, want: `{"amount":"5","to":"0x00000000000000000000000000000000000000AA"}`},
+		{typ: "tuple", components: transfer, value: `["5"]`, want: "needs 2 fields, got 1", wantErr: true},
+		{typ: "tuple", components: transfer, value: `{"amount": 5}`, want: "missing field to", wantErr: true},
+		{typ: "tuple", components: transfer, value: `{"amount": -5, "to": "` + to + `"}`, want: "amount: uint256 cannot be negative", wantErr: true},
+		{typ: "tuple", components: transfer, value: `"5"`, want: "expected a JSON object or array", wantErr: true},
+		{typ: "tuple[]", components: transfer, value: `[["1", "` + to + `"], {"amount": 2, "to": "` + to + `"}]`,
+			want: `[{"amount":"1","to":"0x00000000000000000000000000000000000000AA"},{"amount":"2","to":"0x00000000000000000000000000000000000000AA"}]`},
+
+		{typ: "bool", value: "TRUE", want: `true`},
+		{typ: "bool", value: "yes", want: "invalid bool yes", wantErr: true},
+		{typ: "bytes", value: "0x", want: `"0x"`},
+		{typ: "bytes4", value: "0x010203", want: "bytes4 needs 4 bytes, got 3", wantErr: true},
+		{typ: "address", value: "0x1234", want: "invalid address 0x1234", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.value, func(t *testing.T) {
+			typ, err := abi.NewType(tt.typ, "", tt.components)
+			if err != nil {
+				t.Fatal(err)
+			}
+			arguments := abi.Arguments{{Name: "x", Type: typ}}
+			parsed, err := Parse(arguments, []string{tt.value})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Fatalf("got %v, want an error containing %q", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The value must be what abi.Pack expects, and format back to the input
+			if _, err := arguments.Pack(parsed...); err != nil {
+				t.Fatalf("Pack: %v", err)
+			}
+			formatted, err := json.Marshal(Format(typ, parsed[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(formatted) != tt.want {
+				t.Errorf("formatted %s, want %s", formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	uint8Type, _ := abi.NewType("uint8", "", nil)
+	addressType, _ := abi.NewType("address", "", nil)
+	arguments := abi.Arguments{{Name: "amount", Type: uint8Type}, {Type: addressType}}
+
+	parsed, err := ParseJSON(arguments, []byte(`[255, "`+to+`"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := arguments.Pack(parsed...); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]string{
+		`[256, "` + to + `"]`: "argument amount: 256 overflows uint8",
+		`[1, "0x12"]`:         "argument #1: invalid address 0x12",
+		`[1]`:                 "expected 2 arguments (uint8 amount, address), got 1",
+		`{"amount": 1}`:       "JSON arguments must be an array",
+		`[1,`:                 "invalid JSON arguments",
+	}
+	for input, want := range invalid {
+		if _, err := ParseJSON(arguments, []byte(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want an error containing %q", input, err, want)
+		}
+	}
+}
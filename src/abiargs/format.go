@@ -0,0 +1,128 @@
+package abiargs
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// FindMethod looks a method up by name ("transfer") or signature ("transfer(address,uint256)").
+// Overloaded names must be given by signature.
+func FindMethod(contractABI *abi.ABI, nameOrSig string) (abi.Method, error) {
+	if strings.Contains(nameOrSig, "(") {
+		sig := strings.ReplaceAll(nameOrSig, " ", "")
+		for _, method := range contractABI.Methods {
+			if method.Sig == sig {
+				return method, nil
+			}
+		}
+		return abi.Method{}, fmt.Errorf("no method with signature %s", sig)
+	}
+
+	var matches []abi.Method
+	for _, method := range contractABI.Methods {
+		if method.RawName == nameOrSig {
+			matches = append(matches, method)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return abi.Method{}, fmt.Errorf("method %q not found in ABI", nameOrSig)
+	case 1:
+		return matches[0], nil
+	}
+	sigs := make([]string, len(matches))
+	for i, method := range matches {
+		sigs[i] = method.Sig
+	}
+	sort.Strings(sigs)
+	return abi.Method{}, fmt.Errorf("method %q is overloaded, use one of: %s", nameOrSig, strings.Join(sigs, ", "))
+}
+
+// FormatValues renders decoded values as a JSON-friendly object keyed by argument name
+// (or position for unnamed arguments)
+func FormatValues(arguments abi.Arguments, values []interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		if i >= len(arguments) {
+			break
+		}
+		out[name(arguments[i], i)] = Format(arguments[i].Type, value)
+	}
+	return out
+}
+
+// FormatFields is FormatValues for values already keyed by name, as in decoded events
+func FormatFields(arguments abi.Arguments, fields map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for i, arg := range arguments {
+		if value, ok := fields[arg.Name]; ok {
+			out[name(arg, i)] = Format(arg.Type, value)
+		}
+	}
+	return out
+}
+
+// Format renders one decoded value for JSON: integers as decimal strings (they overflow
+// float64), bytes and addresses as hex, tuples as objects keyed by component name
+func Format(t abi.Type, value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch t.T {
+	case abi.TupleTy:
+		if v.Kind() != reflect.Struct || v.NumField() != len(t.TupleElems) {
+			break
+		}
+		out := make(map[string]interface{}, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			out[t.TupleRawNames[i]] = Format(*elem, v.Field(i).Interface())
+		}
+		return out
+	case abi.SliceTy, abi.ArrayTy:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			break
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = Format(*t.Elem, v.Index(i).Interface())
+		}
+		return out
+	}
+	return formatScalar(value)
+}
+
+func formatScalar(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		// Indexed dynamic event parameters arrive as their hash
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	case bool, string:
+		return v
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(rv.Uint())
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+	}
+	return value
+}
@@ -0,0 +1,229 @@
+package artifact
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Formats Load understands
+const (
+	FormatFoundry = "foundry"
+	FormatHardhat = "hardhat"
+)
+
+// LinkReference is one library placeholder in the bytecode, as a byte range
+type LinkReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// LinkReferences maps source file → library name → placeholder positions
+type LinkReferences map[string]map[string][]LinkReference
+
+// Artifact is a compiled contract loaded from a Foundry or Hardhat build
+type Artifact struct {
+	Name                   string
+	Format                 string
+	RawABI                 json.RawMessage
+	ABI                    abi.ABI
+	Bytecode               string // creation code hex without 0x, may contain link placeholders
+	DeployedBytecode       string // runtime code hex without 0x, may contain link placeholders
+	LinkReferences         LinkReferences
+	DeployedLinkReferences LinkReferences
+	ImmutableReferences    map[string][]LinkReference // runtime offsets of immutables (Foundry only)
+	StorageLayout          json.RawMessage            // solc storageLayout (Foundry with extra_output = ["storageLayout"])
+}
+
+// foundryArtifact is the out/<File>.sol/<Name>.json layout written by forge build
+type foundryArtifact struct {
+	ABI      json.RawMessage `json:"abi"`
+	Bytecode struct {
+		Object         string         `json:"object"`
+		LinkReferences LinkReferences `json:"linkReferences"`
+	} `json:"bytecode"`
+	DeployedBytecode struct {
+		Object              string                     `json:"object"`
+		LinkReferences      LinkReferences             `json:"linkReferences"`
+		ImmutableReferences map[string][]LinkReference `json:"immutableReferences"`
+	} `json:"deployedBytecode"`
+	StorageLayout json.RawMessage `json:"storageLayout"`
+}
+
+// hardhatArtifact is the artifacts/<path>/<Name>.json layout written by hardhat compile
+type hardhatArtifact struct {
+	Format                 string          `json:"_format"`
+	ContractName           string          `json:"contractName"`
+	ABI                    json.RawMessage `json:"abi"`
+	Bytecode               string          `json:"bytecode"`
+	DeployedBytecode       string          `json:"deployedBytecode"`
+	LinkReferences         LinkReferences  `json:"linkReferences"`
+	DeployedLinkReferences LinkReferences  `json:"deployedLinkReferences"`
+}
+
+// Load reads a Foundry or Hardhat artifact; the format is detected from its shape
+func Load(path string) (*Artifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %v", err)
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse artifact %s: %v", path, err)
+	}
+	if _, ok := probe["abi"]; !ok {
+		return nil, fmt.Errorf("%s is not a contract artifact: no abi", path)
+	}
+
+	art := &Artifact{}
+	bytecode := probe["bytecode"]
+	if len(bytecode) > 0 && bytecode[0] == '{' {
+		var f foundryArtifact
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse Foundry artifact %s: %v", path, err)
+		}
+		art.Format = FormatFoundry
+		// Foundry names the file after the contract
+		art.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		art.RawABI = f.ABI
+		art.Bytecode = f.Bytecode.Object
+		art.DeployedBytecode = f.DeployedBytecode.Object
+		art.LinkReferences = f.Bytecode.LinkReferences
+		art.DeployedLinkReferences = f.DeployedBytecode.LinkReferences
+		art.ImmutableReferences = f.DeployedBytecode.ImmutableReferences
+		art.StorageLayout = f.StorageLayout
+	} else {
+		var h hardhatArtifact
+		if err := json.Unmarshal(data, &h); err != nil {
+			return nil, fmt.Errorf("failed to parse Hardhat artifact %s: %v", path, err)
+		}
+		art.Format = FormatHardhat
+		art.Name = h.ContractName
+		if art.Name == "" {
+			art.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		art.RawABI = h.ABI
+		art.Bytecode = h.Bytecode
+		art.DeployedBytecode = h.DeployedBytecode
+		art.LinkReferences = h.LinkReferences
+		art.DeployedLinkReferences = h.DeployedLinkReferences
+	}
+	art.Bytecode = strings.TrimPrefix(art.Bytecode, "0x")
+	art.DeployedBytecode = strings.TrimPrefix(art.DeployedBytecode, "0x")
+
+	if art.ABI, err = abi.JSON(strings.NewReader(string(art.RawABI))); err != nil {
+		return nil, fmt.Errorf("failed to parse ABI of %s: %v", art.Name, err)
+	}
+	return art, nil
+}
+
+// LoadABI reads a contract ABI from a plain ABI JSON file or from a Foundry/Hardhat artifact
+func LoadABI(path string) (*abi.ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ABI: %v", err)
+	}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		parsed, err := abi.JSON(strings.NewReader(trimmed))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ABI %s: %v", path, err)
+		}
+		return &parsed, nil
+	}
+
+	art, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &art.ABI, nil
+}
+
+// Libraries lists the libraries the creation code must be linked against, as "file:Name"
+func (a *Artifact) Libraries() []string {
+	var libs []string
+	for file, names := range a.LinkReferences {
+		for name := range names {
+			libs = append(libs, file+":"+name)
+		}
+	}
+	sort.Strings(libs)
+	return libs
+}
+
+// Link returns the creation code with every library placeholder replaced.
+// libs is keyed by "file:Name" or, when unambiguous, just "Name".
+func (a *Artifact) Link(libs map[string]common.Address) ([]byte, error) {
+	return link(a.Bytecode, a.LinkReferences, libs)
+}
+
+// LinkDeployed returns the runtime code with every library placeholder replaced
+func (a *Artifact) LinkDeployed(libs map[string]common.Address) ([]byte, error) {
+	return link(a.DeployedBytecode, a.DeployedLinkReferences, libs)
+}
+
+func link(code string, refs LinkReferences, libs map[string]common.Address) ([]byte, error) {
+	if code == "" {
+		return nil, fmt.Errorf("artifact has no bytecode (abstract contract or interface?)")
+	}
+
+	// Bare names must identify a single library across all files
+	owners := make(map[string]int)
+	for _, names := range refs {
+		for name := range names {
+			owners[name]++
+		}
+	}
+	used := make(map[string]bool)
+
+	hexCode := []byte(code)
+	var missing []string
+	for file, names := range refs {
+		for name, positions := range names {
+			key := file + ":" + name
+			address, ok := libs[key]
+			if ok {
+				used[key] = true
+			} else if address, ok = libs[name]; ok {
+				if owners[name] > 1 {
+					return nil, fmt.Errorf("library name %s is ambiguous, use file:%s", name, name)
+				}
+				used[name] = true
+			} else {
+				missing = append(missing, key)
+				continue
+			}
+
+			replacement := strings.ToLower(strings.TrimPrefix(address.Hex(), "0x"))
+			for _, pos := range positions {
+				start, end := pos.Start*2, (pos.Start+pos.Length)*2
+				if pos.Length != common.AddressLength || end > len(hexCode) {
+					return nil, fmt.Errorf("invalid link reference for %s at %d", key, pos.Start)
+				}
+				copy(hexCode[start:end], replacement)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("unlinked libraries: %s", strings.Join(missing, ", "))
+	}
+	for key := range libs {
+		if !used[key] {
+			return nil, fmt.Errorf("library %s is not referenced by the bytecode", key)
+		}
+	}
+
+	bytecode, err := hexutil.Decode("0x" + string(hexCode))
+	if err != nil {
+		return nil, fmt.Errorf("bytecode still contains placeholders: %v", err)
+	}
+	return bytecode, nil
+}
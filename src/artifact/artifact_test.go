@@ -0,0 +1,192 @@
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	mathLib  = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	otherLib = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+// placeholder is what solc writes in place of the address of library file:Name
+func placeholder(key string) string {
+	return "__$" + crypto.Keccak256Hash([]byte(key)).Hex()[2:36] + "$__"
+}
+
+// linkedCode is 0x60 ++ <Math> ++ 0x61 ++ <Math> ++ 0x62 ++ <Other>, with placeholders at
+// bytes 1, 22 and 43
+var linkedCode = "60" + placeholder("src/Math.sol:Math") + "61" + placeholder("src/Math.sol:Math") + "62" + placeholder("src/Other.sol:Other")
+
+func TestLink(t *testing.T) {
+	refs := LinkReferences{
+		"src/Math.sol":  {"Math": {{Start: 1, Length: 20}, {Start: 22, Length: 20}}},
+		"src/Other.sol": {"Other": {{Start: 43, Length: 20}}},
+	}
+	ambiguous := LinkReferences{
+		"src/Math.sol":    {"Math": {{Start: 1, Length: 20}, {Start: 22, Length: 20}}},
+		"lib/v2/Math.sol": {"Math": {{Start: 43, Length: 20}}},
+	}
+	linked := "0x60" + mathLib.Hex()[2:] + "61" + mathLib.Hex()[2:] + "62" + otherLib.Hex()[2:]
+
+	tests := []struct {
+		name    string
+		code    string
+		refs    LinkReferences
+		libs    map[string]common.Address
+		want    string
+		wantErr string
+	}{
+		{
+			name: "bare names",
+			code: linkedCode, refs: refs,
+			libs: map[string]common.Address{"Math": mathLib, "Other": otherLib},
+			want: linked,
+		},
+		{
+			name: "file and name",
+			code: linkedCode, refs: refs,
+			libs: map[string]common.Address{"src/Math.sol:Math": mathLib, "Other": otherLib},
+			want: linked,
+		},
+		{
+			name: "no libraries",
+			code: "6001", refs: nil,
+			want: "0x6001",
+		},
+		{
+			name: "ambiguous bare name",
+			code: linkedCode, refs: ambiguous,
+			libs:    map[string]common.Address{"Math": mathLib},
+			wantErr: "library name Math is ambiguous, use file:Math",
+		},
+		{
+			name: "ambiguous name given with files",
+			code: linkedCode, refs: ambiguous,
+			libs: map[string]common.Address{"src/Math.sol:Math": mathLib, "lib/v2/Math.sol:Math": otherLib},
+			want: linked,
+		},
+		{
+			name: "missing libraries",
+			code: linkedCode, refs: refs,
+			libs:    map[string]common.Address{},
+			wantErr: "unlinked libraries: src/Math.sol:Math, src/Other.sol:Other",
+		},
+		{
+			name: "one library missing",
+			code: linkedCode, refs: refs,
+			libs:    map[string]common.Address{"Math": mathLib},
+			wantErr: "unlinked libraries: src/Other.sol:Other",
+		},
+		{
+			name: "unused library",
+			code: linkedCode, refs: refs,
+			libs:    map[string]common.Address{"Math": mathLib, "Other": otherLib, "Strings": otherLib},
+			wantErr: "library Strings is not referenced by the bytecode",
+		},
+		{
+			name: "library without link references",
+			code: "6001", refs: nil,
+			libs:    map[string]common.Address{"Math": mathLib},
+			wantErr: "library Math is not referenced by the bytecode",
+		},
+		{
+			name: "placeholder without a link reference",
+			code: linkedCode, refs: LinkReferences{"src/Math.sol": refs["src/Math.sol"]},
+			libs:    map[string]common.Address{"Math": mathLib},
+			wantErr: "bytecode still contains placeholders",
+		},
+		{
+			name: "reference past the end",
+			code: "60", refs: LinkReferences{"src/Math.sol": {"Math": {{Start: 1, Length: 20}}}},
+			libs:    map[string]common.Address{"Math": mathLib},
+			wantErr: "invalid link reference for src/Math.sol:Math at 1",
+		},
+		{
+			name:    "no bytecode",
+			wantErr: "artifact has no bytecode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			art := &Artifact{Bytecode: tt.code, LinkReferences: tt.refs}
+			code, err := art.Link(tt.libs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hexutil.Encode(code); got != strings.ToLower(tt.want) {
+				t.Errorf("linked %s\nwant   %s", got, strings.ToLower(tt.want))
+			}
+			if art.Bytecode != tt.code {
+				t.Error("linking modified the artifact")
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	const counterABI = `[{"type":"function","name":"getCount","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}]`
+	files := map[string]string{
+		"Counter.json": `{"abi":` + counterABI + `,
+			"bytecode":{"object":"0x` + linkedCode + `","linkReferences":{"src/Math.sol":{"Math":[{"start":1,"length":20},{"start":22,"length":20}]},"src/Other.sol":{"Other":[{"start":43,"length":20}]}}},
+			"deployedBytecode":{"object":"0x6001","linkReferences":{},"immutableReferences":{"7":[{"start":1,"length":32}]}}}`,
+		"Box.json": `{"_format":"hh-sol-artifact-1","contractName":"Box","abi":` + counterABI + `,
+			"bytecode":"0x6002","deployedBytecode":"0x6003","linkReferences":{},"deployedLinkReferences":{}}`,
+		"Counter.abi.json": counterABI,
+		"broken.json":      `{"bytecode":"0x00"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	foundry, err := Load(filepath.Join(dir, "Counter.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if foundry.Format != FormatFoundry || foundry.Name != "Counter" || foundry.DeployedBytecode != "6001" || len(foundry.ImmutableReferences["7"]) != 1 {
+		t.Errorf("foundry artifact %+v", foundry)
+	}
+	if libs := strings.Join(foundry.Libraries(), " "); libs != "src/Math.sol:Math src/Other.sol:Other" {
+		t.Errorf("libraries %s", libs)
+	}
+	if _, ok := foundry.ABI.Methods["getCount"]; !ok {
+		t.Error("foundry artifact ABI has no getCount")
+	}
+
+	hardhat, err := Load(filepath.Join(dir, "Box.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hardhat.Format != FormatHardhat || hardhat.Name != "Box" || hardhat.Bytecode != "6002" || len(hardhat.Libraries()) != 0 {
+		t.Errorf("hardhat artifact %+v", hardhat)
+	}
+
+	if _, err := Load(filepath.Join(dir, "broken.json")); err == nil || !strings.Contains(err.Error(), "no abi") {
+		t.Errorf("artifact without abi: got %v", err)
+	}
+	for _, name := range []string{"Counter.json", "Counter.abi.json"} {
+		parsed, err := LoadABI(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := parsed.Methods["getCount"]; !ok {
+			t.Errorf("%s: ABI has no getCount", name)
+		}
+	}
+}
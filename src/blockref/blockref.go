@@ -0,0 +1,212 @@
+package blockref
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Block tags understood by Parse
+const (
+	TagLatest    = "latest"
+	TagSafe      = "safe"
+	TagFinalized = "finalized"
+	TagPending   = "pending"
+	TagEarliest  = "earliest"
+)
+
+// ErrStateUnavailable is returned when the node no longer has the state for a block
+var ErrStateUnavailable = errors.New("state not available on this node")
+
+// Ref selects the block a read is evaluated at: a number, a hash or a tag
+type Ref struct {
+	tag    string
+	number *big.Int
+	hash   common.Hash
+}
+
+// Latest reads the latest block
+var Latest = Ref{tag: TagLatest}
+
+// Number selects a block by number
+func Number(n uint64) Ref {
+	return Ref{number: new(big.Int).SetUint64(n)}
+}
+
+// Hash selects a block by hash
+func Hash(h common.Hash) Ref {
+	return Ref{hash: h}
+}
+
+// Tag selects a block by tag
+func Tag(tag string) (Ref, error) {
+	switch tag {
+	case TagLatest, TagSafe, TagFinalized, TagPending, TagEarliest:
+		return Ref{tag: tag}, nil
+	}
+	return Ref{}, fmt.Errorf("unknown block tag %q (use latest, safe, finalized, pending or earliest)", tag)
+}
+
+// Parse accepts a decimal or 0x-hex block number, a 32-byte block hash or a tag; "" means latest
+func Parse(s string) (Ref, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" {
+		return Latest, nil
+	}
+	if strings.HasPrefix(s, "0x") {
+		if len(s) == 66 {
+			return Hash(common.HexToHash(s)), nil
+		}
+		n, err := hexutil.DecodeUint64(s)
+		if err != nil {
+			return Ref{}, fmt.Errorf("invalid block %q: %v", s, err)
+		}
+		return Number(n), nil
+	}
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return Number(n), nil
+	}
+	return Tag(s)
+}
+
+// IsLatest reports whether the ref selects the latest block
+func (r Ref) IsLatest() bool {
+	return r.tag == TagLatest || (r.tag == "" && r.number == nil && r.hash == common.Hash{})
+}
+
+// IsPending reports whether the ref selects the pending block
+func (r Ref) IsPending() bool {
+	return r.tag == TagPending
+}
+
+// BlockHash returns the selected hash, if the ref selects by hash
+func (r Ref) BlockHash() (common.Hash, bool) {
+	return r.hash, r.hash != (common.Hash{})
+}
+
+// BlockNumber returns the argument for *At(ctx, ..., blockNumber) methods: nil for latest,
+// the rpc tag constants for tags. It must not be used for refs that select by hash.
+func (r Ref) BlockNumber() *big.Int {
+	switch r.tag {
+	case TagSafe:
+		return big.NewInt(int64(rpc.SafeBlockNumber))
+	case TagFinalized:
+		return big.NewInt(int64(rpc.FinalizedBlockNumber))
+	case TagPending:
+		return big.NewInt(int64(rpc.PendingBlockNumber))
+	case TagEarliest:
+		return big.NewInt(int64(rpc.EarliestBlockNumber))
+	}
+	if r.number != nil {
+		return new(big.Int).Set(r.number)
+	}
+	return nil
+}
+
+// CallOpts maps the ref onto binding call options
+func (r Ref) CallOpts(ctx context.Context) *bind.CallOpts {
+	opts := &bind.CallOpts{Context: ctx}
+	switch {
+	case r.IsPending():
+		opts.Pending = true
+	case r.hash != (common.Hash{}):
+		opts.BlockHash = r.hash
+	default:
+		opts.BlockNumber = r.BlockNumber()
+	}
+	return opts
+}
+
+// RPCArg returns the ref as a raw JSON-RPC block parameter: a quantity or tag,
+// or an EIP-1898 object for hashes
+func (r Ref) RPCArg() interface{} {
+	if hash, ok := r.BlockHash(); ok {
+		return rpc.BlockNumberOrHashWithHash(hash, false)
+	}
+	number := r.BlockNumber()
+	if number == nil {
+		return rpc.LatestBlockNumber
+	}
+	return rpc.BlockNumber(number.Int64())
+}
+
+// String returns the ref as Parse accepts it
+func (r Ref) String() string {
+	switch {
+	case r.tag != "":
+		return r.tag
+	case r.hash != (common.Hash{}):
+		return r.hash.Hex()
+	case r.number != nil:
+		return r.number.String()
+	}
+	return TagLatest
+}
+
+// HeaderReader is implemented by ethclient.Client
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+}
+
+// Header resolves the ref to a concrete header
+func Header(ctx context.Context, reader HeaderReader, r Ref) (*types.Header, error) {
+	if hash, ok := r.BlockHash(); ok {
+		return reader.HeaderByHash(ctx, hash)
+	}
+	return reader.HeaderByNumber(ctx, r.BlockNumber())
+}
+
+// BalanceReader is implemented by ethclient.Client
+type BalanceReader interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error)
+}
+
+// BalanceAt reads a balance at the ref
+func BalanceAt(ctx context.Context, reader BalanceReader, account common.Address, r Ref) (*big.Int, error) {
+	var (
+		balance *big.Int
+		err     error
+	)
+	if hash, ok := r.BlockHash(); ok {
+		balance, err = reader.BalanceAtHash(ctx, account, hash)
+	} else {
+		balance, err = reader.BalanceAt(ctx, account, r.BlockNumber())
+	}
+	return balance, Wrap(err, r)
+}
+
+// prunedMessages are fragments nodes use when historical state has been pruned
+var prunedMessages = []string{
+	"missing trie node",
+	"historical state",
+	"state not available",
+	"state is not available",
+	"state histories haven't been fully indexed",
+	"pruned",
+	"archive",
+}
+
+// Wrap turns a "state pruned" node error into ErrStateUnavailable with a hint; other errors pass through
+func Wrap(err error, r Ref) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range prunedMessages {
+		if strings.Contains(msg, fragment) {
+			return fmt.Errorf("%w at block %s: the node has pruned it, use an archive node or a more recent block (%v)", ErrStateUnavailable, r, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package blockref
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const testHash = "0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		str     string
+		number  *big.Int // BlockNumber()
+		hash    bool
+		latest  bool
+		pending bool
+		wantErr bool
+	}{
+		{in: "", str: "latest", latest: true},
+		{in: "latest", str: "latest", latest: true},
+		{in: " Latest ", str: "latest", latest: true},
+		{in: "safe", str: "safe", number: big.NewInt(int64(rpc.SafeBlockNumber))},
+		{in: "finalized", str: "finalized", number: big.NewInt(int64(rpc.FinalizedBlockNumber))},
+		{in: "pending", str: "pending", number: big.NewInt(int64(rpc.PendingBlockNumber)), pending: true},
+		{in: "earliest", str: "earliest", number: big.NewInt(int64(rpc.EarliestBlockNumber))},
+		{in: "0", str: "0", number: big.NewInt(0)},
+		{in: "12345", str: "12345", number: big.NewInt(12345)},
+		{in: "0x3039", str: "12345", number: big.NewInt(12345)},
+		{in: "0X3039", str: "12345", number: big.NewInt(12345)},
+		{in: testHash, str: testHash, hash: true},
+		{in: "0x88E96D4537BEA4D9C05D12549907B32561D3BF31F45AAE734CDC119F13406CB6", str: testHash, hash: true},
+		{in: "0x", wantErr: true},
+		{in: "0x0123", wantErr: true}, // hexutil rejects leading zeros
+		{in: "0xzz", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "head", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			ref, err := Parse(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %s, want an error", tt.in, ref)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref.String() != tt.str {
+				t.Errorf("String() = %s, want %s", ref, tt.str)
+			}
+			if ref.IsLatest() != tt.latest || ref.IsPending() != tt.pending {
+				t.Errorf("IsLatest() = %v, IsPending() = %v", ref.IsLatest(), ref.IsPending())
+			}
+			hash, ok := ref.BlockHash()
+			if ok != tt.hash || (ok && hash != common.HexToHash(testHash)) {
+				t.Errorf("BlockHash() = %s, %v", hash.Hex(), ok)
+			}
+			if tt.hash {
+				return
+			}
+			if got := ref.BlockNumber(); fmt.Sprint(got) != fmt.Sprint(tt.number) {
+				t.Errorf("BlockNumber() = %v, want %v", got, tt.number)
+			}
+			// Parsing the string form selects the same block
+			if again, err := Parse(ref.String()); err != nil || fmt.Sprint(again.BlockNumber()) != fmt.Sprint(tt.number) {
+				t.Errorf("Parse(%s) = %s, %v", ref, again, err)
+			}
+		})
+	}
+}
+
+func TestCallOpts(t *testing.T) {
+	ctx := context.Background()
+	pending := Ref{tag: TagPending}.CallOpts(ctx)
+	if !pending.Pending || pending.BlockNumber != nil {
+		t.Errorf("pending: %+v", pending)
+	}
+	byHash := Hash(common.HexToHash(testHash)).CallOpts(ctx)
+	if byHash.BlockHash != common.HexToHash(testHash) || byHash.BlockNumber != nil {
+		t.Errorf("hash: %+v", byHash)
+	}
+	byNumber := Number(7).CallOpts(ctx)
+	if byNumber.BlockNumber.Uint64() != 7 || byNumber.Context != ctx {
+		t.Errorf("number: %+v", byNumber)
+	}
+	if latest := Latest.CallOpts(ctx); latest.BlockNumber != nil || latest.Pending {
+		t.Errorf("latest: %+v", latest)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		err    string
+		pruned bool
+	}{
+		{"missing trie node 4d9c05d1 (path ) state 0x4d9c05d1 is not available", true},
+		{"historical state 0x4d9c05d1 is not available", true},
+		{"Header not found: State not available", true},
+		{"project ID does not have access to archive state", true},
+		{"state histories haven't been fully indexed yet", true},
+		{"execution reverted", false},
+		{"connection refused", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err, func(t *testing.T) {
+			cause := errors.New(tt.err)
+			err := Wrap(cause, Number(100))
+			if errors.Is(err, ErrStateUnavailable) != tt.pruned {
+				t.Fatalf("Wrap(%q) = %v", tt.err, err)
+			}
+			if !tt.pruned && err != cause {
+				t.Errorf("Wrap(%q) = %v, want the error unchanged", tt.err, err)
+			}
+			if tt.pruned && err.Error() != fmt.Sprintf("%v at block 100: the node has pruned it, use an archive node or a more recent block (%s)", ErrStateUnavailable, tt.err) {
+				t.Errorf("Wrap(%q) = %v", tt.err, err)
+			}
+		})
+	}
+	if err := Wrap(nil, Latest); err != nil {
+		t.Errorf("Wrap(nil) = %v", err)
+	}
+}
+
+// prunedNode has no state before block 100
+type prunedNode struct{ byHash bool }
+
+func (n *prunedNode) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if blockNumber != nil && blockNumber.Sign() >= 0 && blockNumber.Uint64() < 100 {
+		return nil, fmt.Errorf("missing trie node %x (path ) state is not available", blockNumber)
+	}
+	return big.NewInt(1), nil
+}
+
+func (n *prunedNode) BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error) {
+	n.byHash = true
+	return nil, errors.New("historical state unavailable")
+}
+
+func TestBalanceAtPrunedState(t *testing.T) {
+	ctx := context.Background()
+	node := &prunedNode{}
+	for _, ref := range []Ref{Latest, Number(100)} {
+		if balance, err := BalanceAt(ctx, node, common.Address{}, ref); err != nil || balance.Int64() != 1 {
+			t.Errorf("%s: got %v, %v", ref, balance, err)
+		}
+	}
+	if _, err := BalanceAt(ctx, node, common.Address{}, Number(99)); !errors.Is(err, ErrStateUnavailable) {
+		t.Errorf("block 99: got %v, want ErrStateUnavailable", err)
+	}
+	if _, err := BalanceAt(ctx, node, common.Address{}, Hash(common.HexToHash(testHash))); !errors.Is(err, ErrStateUnavailable) || !node.byHash {
+		t.Errorf("by hash: got %v, want ErrStateUnavailable from BalanceAtHash", err)
+	}
+}
@@ -0,0 +1,82 @@
+package counterevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Checkpoint is the stream position: every log before (Block, LogIndex) has been processed.
+// It also remembers the recent delivered logs and processed heads, so a reorg that happened
+// while polling or while the stream was down can be detected on the next pass.
+type Checkpoint struct {
+	Address  common.Address `json:"address"`
+	Block    uint64         `json:"block"`
+	LogIndex uint           `json:"logIndex"`
+	Heads    []BlockRef     `json:"heads,omitempty"`
+	Logs     []types.Log    `json:"logs,omitempty"`
+}
+
+// BlockRef is a block number and the hash it had when it was processed
+type BlockRef struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// before reports whether a log position lies before the checkpoint
+func (c Checkpoint) before(block uint64, index uint) bool {
+	return block < c.Block || (block == c.Block && index < c.LogIndex)
+}
+
+// LastBlock returns the last fully processed block
+func (c Checkpoint) LastBlock() uint64 {
+	if c.Block == 0 {
+		return 0
+	}
+	return c.Block - 1
+}
+
+// CheckpointPath returns the default checkpoint file for a Counter deployment
+func CheckpointPath(address common.Address) string {
+	return filepath.Join("data", "counter_events_"+strings.ToLower(address.Hex())+".json")
+}
+
+// LoadCheckpoint reads a checkpoint; ok is false if the file does not exist yet
+func LoadCheckpoint(path string) (cp Checkpoint, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, false, nil
+	}
+	if err != nil {
+		return cp, false, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, false, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	return cp, true, nil
+}
+
+// SaveCheckpoint writes a checkpoint atomically
+func SaveCheckpoint(path string, cp Checkpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %v", err)
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	return nil
+}
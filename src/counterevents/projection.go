@@ -0,0 +1,222 @@
+package counterevents
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/blockref"
+)
+
+// ProjectionBackend is everything a projection needs from a node
+type ProjectionBackend interface {
+	bind.ContractCaller
+	bind.ContractFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error)
+}
+
+// Point is the counter value after one event
+type Point struct {
+	Block      uint64         `json:"block"`
+	Time       time.Time      `json:"time"`
+	TxHash     common.Hash    `json:"txHash"`
+	LogIndex   uint           `json:"logIndex"`
+	Sender     common.Address `json:"sender"`
+	Event      string         `json:"event"`
+	Value      *big.Int       `json:"value"`             // value rebuilt from the events so far
+	Emitted    *big.Int       `json:"emitted"`           // newCount carried by the event
+	OnChain    *big.Int       `json:"onChain,omitempty"` // getCount at this block, set on the block's last event
+	Divergence string         `json:"divergence,omitempty"`
+}
+
+// Projection is the Counter value history rebuilt from its events
+type Projection struct {
+	Address     common.Address `json:"address"`
+	From        uint64         `json:"from"`
+	To          uint64         `json:"to"`
+	Initial     *big.Int       `json:"initial"` // value before From
+	Points      []*Point       `json:"points"`
+	Divergences int            `json:"divergences"`
+}
+
+// Project rebuilds the Counter value over [from, to] from its events and cross-checks
+// each block against getCount at that block. batch bounds the blocks per log query.
+func Project(ctx context.Context, backend ProjectionBackend, address common.Address, from, to, batch uint64) (*Projection, error) {
+	caller, err := contracts.NewCounterCaller(address, backend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind Counter: %v", err)
+	}
+	if batch == 0 {
+		batch = 2000
+	}
+
+	initial, err := initialValue(ctx, backend, caller, address, from)
+	if err != nil {
+		return nil, err
+	}
+	projection := &Projection{Address: address, From: from, To: to, Initial: initial}
+
+	var events []Event
+	for start := from; start <= to; start += batch {
+		end := start + batch - 1
+		if end > to {
+			end = to
+		}
+		chunk, err := FilterEvents(ctx, backend, address, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter blocks %d-%d: %v", start, end, err)
+		}
+		events = append(events, chunk...)
+	}
+
+	value := new(big.Int).Set(initial)
+	senders := make(map[common.Hash]common.Address)
+	times := make(map[uint64]time.Time)
+
+	for i, ev := range events {
+		point := &Point{
+			Block:    ev.Raw.BlockNumber,
+			TxHash:   ev.Raw.TxHash,
+			LogIndex: ev.Raw.Index,
+			Event:    ev.Name,
+			Emitted:  ev.NewCount,
+		}
+
+		switch ev.Name {
+		case CountIncremented:
+			value = new(big.Int).Add(value, common.Big1)
+		case CountDecremented:
+			if value.Sign() == 0 {
+				point.Divergence = "decrement below zero"
+			}
+			value = new(big.Int).Sub(value, common.Big1)
+		case CountReset:
+			value = new(big.Int)
+		}
+		point.Value = value
+		if point.Divergence == "" && value.Cmp(ev.NewCount) != 0 {
+			point.Divergence = fmt.Sprintf("event reports %s", ev.NewCount)
+		}
+
+		if point.Sender, err = sender(ctx, backend, ev.Raw, senders); err != nil {
+			return nil, err
+		}
+		if point.Time, err = blockTime(ctx, backend, ev.Raw.BlockNumber, times); err != nil {
+			return nil, err
+		}
+
+		// The chain can only be compared at block boundaries
+		if i == len(events)-1 || events[i+1].Raw.BlockNumber != ev.Raw.BlockNumber {
+			at := blockref.Number(ev.Raw.BlockNumber)
+			onChain, err := caller.GetCount(at.CallOpts(ctx))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read getCount at block %d: %w", ev.Raw.BlockNumber, blockref.Wrap(err, at))
+			}
+			point.OnChain = onChain
+			if point.Divergence == "" && onChain.Cmp(value) != 0 {
+				point.Divergence = fmt.Sprintf("getCount returns %s", onChain)
+			}
+		}
+
+		if point.Divergence != "" {
+			projection.Divergences++
+		}
+		projection.Points = append(projection.Points, point)
+	}
+	return projection, nil
+}
+
+// initialValue is the count before from; before deployment it is zero
+func initialValue(ctx context.Context, backend ProjectionBackend, caller *contracts.CounterCaller, address common.Address, from uint64) (*big.Int, error) {
+	if from == 0 {
+		return new(big.Int), nil
+	}
+	at := blockref.Number(from - 1)
+
+	code, err := backend.CodeAt(ctx, address, at.BlockNumber())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code at block %d: %w", from-1, blockref.Wrap(err, at))
+	}
+	if len(code) == 0 {
+		return new(big.Int), nil
+	}
+
+	count, err := caller.GetCount(at.CallOpts(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read getCount at block %d: %w", from-1, blockref.Wrap(err, at))
+	}
+	return count, nil
+}
+
+func sender(ctx context.Context, backend ProjectionBackend, log types.Log, cache map[common.Hash]common.Address) (common.Address, error) {
+	if from, ok := cache[log.TxHash]; ok {
+		return from, nil
+	}
+	tx, _, err := backend.TransactionByHash(ctx, log.TxHash)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to get transaction %s: %v", log.TxHash.Hex(), err)
+	}
+	from, err := backend.TransactionSender(ctx, tx, log.BlockHash, log.TxIndex)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to get sender of %s: %v", log.TxHash.Hex(), err)
+	}
+	cache[log.TxHash] = from
+	return from, nil
+}
+
+func blockTime(ctx context.Context, backend ProjectionBackend, number uint64, cache map[uint64]time.Time) (time.Time, error) {
+	if t, ok := cache[number]; ok {
+		return t, nil
+	}
+	header, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get header %d: %v", number, err)
+	}
+	t := time.Unix(int64(header.Time), 0).UTC()
+	cache[number] = t
+	return t, nil
+}
+
+// WriteCSV exports the projection as a time series with a header row
+func (p *Projection) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"block", "time", "tx_hash", "log_index", "sender", "event", "value", "emitted", "on_chain", "divergence"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, point := range p.Points {
+		onChain := ""
+		if point.OnChain != nil {
+			onChain = point.OnChain.String()
+		}
+		row := []string{
+			strconv.FormatUint(point.Block, 10),
+			point.Time.Format(time.RFC3339),
+			point.TxHash.Hex(),
+			strconv.FormatUint(uint64(point.LogIndex), 10),
+			point.Sender.Hex(),
+			point.Event,
+			point.Value.String(),
+			point.Emitted.String(),
+			onChain,
+			point.Divergence,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
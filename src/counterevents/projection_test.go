@@ -0,0 +1,213 @@
+package counterevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+// projectionChain deploys a Counter in block 1, increments it from accounts 0 and 1 in
+// blocks 2 and 3, and decrements (account 0) and resets (account 1) it in block 4
+func projectionChain(t *testing.T) (*simchain.Chain, common.Address) {
+	t.Helper()
+	chain, err := simchain.New(2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	ctx := context.Background()
+
+	address, counter, _ := deployCounter(t, chain)
+	transactor := func(account int) *bind.TransactOpts {
+		auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(account), simchain.ChainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return auth
+	}
+	if _, err := counter.Increment(transactor(0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := counter.Increment(transactor(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both in one block, so only the reset is compared with getCount
+	manual, err := contracts.NewCounter(address, chain.Backend().Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for account, send := range []func(*bind.TransactOpts) (*types.Transaction, error){manual.Decrement, manual.Reset} {
+		opts := transactor(account)
+		nonce, err := chain.Client().NonceAt(ctx, opts.From, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		opts.GasLimit = 100000
+		if _, err := send(opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain.Backend().Commit()
+	return chain, address
+}
+
+// describePoints renders points as "block event value emitted onChain sender divergence"
+func describePoints(chain *simchain.Chain, points []*Point) []string {
+	out := make([]string, len(points))
+	for i, point := range points {
+		onChain := "-"
+		if point.OnChain != nil {
+			onChain = point.OnChain.String()
+		}
+		sender := "?"
+		for account := 0; account < chain.Accounts(); account++ {
+			if chain.Address(account) == point.Sender {
+				sender = fmt.Sprint(account)
+			}
+		}
+		out[i] = fmt.Sprintf("%d %s %s %s %s %s %q", point.Block, point.Event, point.Value, point.Emitted, onChain, sender, point.Divergence)
+	}
+	return out
+}
+
+func expectPoints(t *testing.T, chain *simchain.Chain, projection *Projection, want ...string) {
+	t.Helper()
+	got := describePoints(chain, projection.Points)
+	if len(got) != len(want) {
+		t.Fatalf("points\n%q\nwant\n%q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("points\n%q\nwant\n%q", got, want)
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	chain, address := projectionChain(t)
+	ctx := context.Background()
+	client := chain.Client()
+
+	// A batch of 2 blocks splits the range into several log queries
+	projection, err := Project(ctx, client, address, 0, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projection.Initial.Sign() != 0 || projection.Divergences != 0 {
+		t.Fatalf("initial %s with %d divergences, want 0 and none", projection.Initial, projection.Divergences)
+	}
+	expectPoints(t, chain, projection,
+		`2 CountIncremented 1 1 1 0 ""`,
+		`3 CountIncremented 2 2 2 1 ""`,
+		`4 CountDecremented 1 1 - 0 ""`,
+		`4 CountReset 0 0 0 1 ""`,
+	)
+	for _, point := range projection.Points {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(point.Block))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if point.Time.Unix() != int64(header.Time) {
+			t.Errorf("point in block %d at %s, want the block time %d", point.Block, point.Time, header.Time)
+		}
+	}
+	if last := projection.Points[3]; last.LogIndex != 1 || projection.Points[2].TxHash == last.TxHash {
+		t.Errorf("block 4 points are logs %d and %d of the same transaction", projection.Points[2].LogIndex, last.LogIndex)
+	}
+
+	var buf bytes.Buffer
+	if err := projection.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 || rows[3][8] != "" || rows[4][8] != "0" {
+		t.Errorf("csv rows %q", rows)
+	}
+}
+
+func TestProjectFromMidHistory(t *testing.T) {
+	chain, address := projectionChain(t)
+
+	// The value before block 3 comes from getCount at block 2
+	projection, err := Project(context.Background(), chain.Client(), address, 3, 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projection.Initial.Int64() != 1 || projection.Divergences != 0 {
+		t.Fatalf("initial %s with %d divergences, want 1 and none", projection.Initial, projection.Divergences)
+	}
+	expectPoints(t, chain, projection,
+		`3 CountIncremented 2 2 2 1 ""`,
+		`4 CountDecremented 1 1 - 0 ""`,
+		`4 CountReset 0 0 0 1 ""`,
+	)
+
+	// Before the deployment there is no code and the value is zero
+	projection, err = Project(context.Background(), chain.Client(), address, 1, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projection.Initial.Sign() != 0 || len(projection.Points) != 1 {
+		t.Fatalf("initial %s with %d points, want 0 and one", projection.Initial, len(projection.Points))
+	}
+}
+
+// lyingBackend reports a getCount of 7 at one block
+type lyingBackend struct {
+	ProjectionBackend
+	block uint64
+}
+
+func (b lyingBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber != nil && blockNumber.Uint64() == b.block {
+		return common.LeftPadBytes(big.NewInt(7).Bytes(), 32), nil
+	}
+	return b.ProjectionBackend.CallContract(ctx, call, blockNumber)
+}
+
+func TestProjectReportsDivergences(t *testing.T) {
+	chain, address := projectionChain(t)
+
+	projection, err := Project(context.Background(), lyingBackend{chain.Client(), 3}, address, 0, 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projection.Divergences != 1 {
+		t.Fatalf("%d divergences, want 1", projection.Divergences)
+	}
+	expectPoints(t, chain, projection,
+		`2 CountIncremented 1 1 1 0 ""`,
+		`3 CountIncremented 2 2 7 1 "getCount returns 7"`,
+		`4 CountDecremented 1 1 - 0 ""`,
+		`4 CountReset 0 0 0 1 ""`,
+	)
+
+	// Starting after a wrong value, the events no longer add up
+	projection, err = Project(context.Background(), lyingBackend{chain.Client(), 2}, address, 3, 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projection.Initial.Int64() != 7 || projection.Divergences != 2 {
+		t.Fatalf("initial %s with %d divergences, want 7 and 2", projection.Initial, projection.Divergences)
+	}
+	expectPoints(t, chain, projection,
+		`3 CountIncremented 8 2 2 1 "event reports 2"`,
+		`4 CountDecremented 7 1 - 0 "event reports 1"`,
+		`4 CountReset 0 0 0 1 ""`,
+	)
+}
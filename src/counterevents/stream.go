@@ -0,0 +1,580 @@
+package counterevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/fuckEthereum/contracts"
+)
+
+// Counter event names
+const (
+	CountIncremented = "CountIncremented"
+	CountDecremented = "CountDecremented"
+	CountReset       = "CountReset"
+)
+
+// Event is a Counter event; Removed is set when a reorg dropped a log delivered earlier
+type Event struct {
+	Name     string
+	NewCount *big.Int
+	Removed  bool
+	Raw      types.Log
+}
+
+// Backend is everything the stream needs from a node
+type Backend interface {
+	bind.ContractFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Dialer opens a connection; it is called again after every disconnect
+type Dialer func(ctx context.Context) (Backend, error)
+
+// DialURL returns a dialer for a ws, IPC or HTTP endpoint
+func DialURL(url string) Dialer {
+	return func(ctx context.Context) (Backend, error) {
+		return ethclient.DialContext(ctx, url)
+	}
+}
+
+// Config tunes the stream
+type Config struct {
+	Address        common.Address
+	CheckpointPath string        // default CheckpointPath(Address)
+	StartBlock     uint64        // first block when there is no checkpoint
+	BatchSize      uint64        // blocks per eth_getLogs call during backfill (default 2000)
+	PollInterval   time.Duration // backfill interval when subscriptions are unsupported (default 12s)
+	MaxBackoff     time.Duration // reconnect delay cap (default 1m)
+}
+
+// logKey identifies a log on a specific branch of the chain
+type logKey struct {
+	blockHash common.Hash
+	index     uint
+}
+
+// Stream delivers every Counter event exactly once, in order, across restarts and reconnects
+type Stream struct {
+	dial       Dialer
+	config     Config
+	checkpoint Checkpoint
+	delivered  map[logKey]uint64 // block number of every recently delivered log
+}
+
+// New creates a stream resuming from the stored checkpoint
+func New(dial Dialer, config Config) (*Stream, error) {
+	if config.CheckpointPath == "" {
+		config.CheckpointPath = CheckpointPath(config.Address)
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 2000
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 12 * time.Second
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = time.Minute
+	}
+
+	cp, ok, err := LoadCheckpoint(config.CheckpointPath)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		cp = Checkpoint{Address: config.Address, Block: config.StartBlock}
+	}
+	if cp.Address != config.Address {
+		return nil, fmt.Errorf("checkpoint %s belongs to %s", config.CheckpointPath, cp.Address.Hex())
+	}
+
+	delivered := make(map[logKey]uint64, len(cp.Logs))
+	for _, log := range cp.Logs {
+		delivered[logKey{blockHash: log.BlockHash, index: log.Index}] = log.BlockNumber
+	}
+
+	return &Stream{
+		dial:       dial,
+		config:     config,
+		checkpoint: cp,
+		delivered:  delivered,
+	}, nil
+}
+
+// Checkpoint returns the current stream position
+func (s *Stream) Checkpoint() Checkpoint {
+	return s.checkpoint
+}
+
+// Run streams events to handle until ctx is cancelled or handle returns an error.
+// Each session backfills from the checkpoint and then follows live subscriptions;
+// dropped connections start a new session after a backoff.
+func (s *Stream) Run(ctx context.Context, handle func(Event) error) error {
+	backoff := time.Second
+	for {
+		backend, err := s.dial(ctx)
+		if err == nil {
+			err = s.session(ctx, backend, handle)
+			if closer, ok := backend.(interface{ Close() }); ok {
+				closer.Close()
+			}
+		}
+
+		var handlerErr *handlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		fmt.Printf("⚠️  Event stream interrupted (%v), resuming from block %d in %s\n", err, s.checkpoint.Block, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.config.MaxBackoff {
+			backoff = s.config.MaxBackoff
+		}
+	}
+}
+
+// handlerError marks errors returned by the consumer, which stop the stream
+type handlerError struct{ err error }
+
+func (e *handlerError) Error() string { return e.err.Error() }
+
+func (s *Stream) session(ctx context.Context, backend Backend, handle func(Event) error) error {
+	filterer, err := contracts.NewCounterFilterer(s.config.Address, backend)
+	if err != nil {
+		return fmt.Errorf("failed to bind Counter: %v", err)
+	}
+
+	// Subscribe before backfilling so nothing between the two is missed
+	live, sub, err := s.watch(ctx, filterer)
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return s.poll(ctx, backend, handle)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	if err := s.backfill(ctx, backend, handle); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.Err():
+			return fmt.Errorf("subscription failed: %v", err)
+		case <-live:
+			if err := s.backfill(ctx, backend, handle); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// poll backfills repeatedly on endpoints without subscriptions (plain HTTP).
+// Without a subscription nobody reports removed logs, so every backfill starts with rewind.
+func (s *Stream) poll(ctx context.Context, backend Backend, handle func(Event) error) error {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.backfill(ctx, backend, handle); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// backfill rewinds past any reorg, then filters [checkpoint, head] in bounded ranges,
+// saving the checkpoint after each range
+func (s *Stream) backfill(ctx context.Context, backend Backend, handle func(Event) error) error {
+	if err := s.rewind(ctx, backend, handle); err != nil {
+		return err
+	}
+
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get head: %v", err)
+	}
+
+	for from := s.checkpoint.Block; from <= head.Number.Uint64(); {
+		to := from + s.config.BatchSize - 1
+		if to > head.Number.Uint64() {
+			to = head.Number.Uint64()
+		}
+
+		events, err := FilterEvents(ctx, backend, s.config.Address, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to filter blocks %d-%d: %v", from, to, err)
+		}
+		for _, ev := range events {
+			if err := s.deliver(ev, handle); err != nil {
+				return err
+			}
+		}
+
+		next := Checkpoint{Block: to + 1}
+		if next.before(s.checkpoint.Block, s.checkpoint.LogIndex) {
+			s.checkpoint.Block, s.checkpoint.LogIndex = next.Block, next.LogIndex
+		}
+		if heads := s.checkpoint.Heads; to == head.Number.Uint64() && (len(heads) == 0 || heads[len(heads)-1].Hash != head.Hash()) {
+			s.checkpoint.Heads = append(heads, BlockRef{Number: to, Hash: head.Hash()})
+		}
+		s.prune()
+		if err := SaveCheckpoint(s.config.CheckpointPath, s.checkpoint); err != nil {
+			return err
+		}
+		from = to + 1
+	}
+	return nil
+}
+
+// deliver hands an event to the consumer unless it was already delivered, and tracks removals
+func (s *Stream) deliver(ev Event, handle func(Event) error) error {
+	key := logKey{blockHash: ev.Raw.BlockHash, index: ev.Raw.Index}
+	_, seen := s.delivered[key]
+	earlier := s.checkpoint.before(ev.Raw.BlockNumber, ev.Raw.Index)
+
+	if ev.Removed {
+		if !seen && !earlier {
+			return nil // never delivered
+		}
+		delete(s.delivered, key)
+		s.forget(key)
+		if err := handle(ev); err != nil {
+			return &handlerError{err}
+		}
+		if earlier {
+			s.checkpoint.Block, s.checkpoint.LogIndex = ev.Raw.BlockNumber, ev.Raw.Index
+		}
+		return SaveCheckpoint(s.config.CheckpointPath, s.checkpoint)
+	}
+
+	if seen || earlier {
+		return nil // overlap between backfill and live subscription
+	}
+	if err := handle(ev); err != nil {
+		return &handlerError{err}
+	}
+	s.delivered[key] = ev.Raw.BlockNumber
+	s.checkpoint.Block, s.checkpoint.LogIndex = ev.Raw.BlockNumber, ev.Raw.Index+1
+	s.checkpoint.Logs = append(s.checkpoint.Logs, ev.Raw)
+	s.prune()
+	return SaveCheckpoint(s.config.CheckpointPath, s.checkpoint)
+}
+
+// rewind compares the recorded block hashes with the canonical chain, newest first, down to
+// the newest block that is still canonical. Delivered logs on dropped blocks are handed to the
+// consumer again as removed, newest first, and the checkpoint moves back to just after that
+// block so the next range picks up the logs of the new branch.
+func (s *Stream) rewind(ctx context.Context, backend Backend, handle func(Event) error) error {
+	recorded := make(map[uint64][]common.Hash)
+	for _, ref := range s.checkpoint.Heads {
+		recorded[ref.Number] = append(recorded[ref.Number], ref.Hash)
+	}
+	for _, log := range s.checkpoint.Logs {
+		recorded[log.BlockNumber] = append(recorded[log.BlockNumber], log.BlockHash)
+	}
+	numbers := make([]uint64, 0, len(recorded))
+	for number := range recorded {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] > numbers[j] })
+
+	canonical := make(map[uint64]common.Hash, len(numbers))
+	checked := 0
+	for _, number := range numbers {
+		header, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("failed to get block %d: %v", number, err)
+		}
+		if header != nil {
+			canonical[number] = header.Hash()
+		}
+		if matches(recorded[number], canonical[number]) {
+			break
+		}
+		checked++
+	}
+	if checked == 0 {
+		return nil
+	}
+
+	resume := numbers[len(numbers)-1]
+	if checked < len(numbers) {
+		resume = numbers[checked] + 1
+	} else {
+		fmt.Printf("⚠️  Reorg reaches below block %d, the oldest one still tracked\n", resume)
+	}
+	fmt.Printf("🔀 Reorg detected, rewinding the event stream to block %d\n", resume)
+
+	filterer, err := contracts.NewCounterFilterer(s.config.Address, backend)
+	if err != nil {
+		return fmt.Errorf("failed to bind Counter: %v", err)
+	}
+	delivered := append([]types.Log(nil), s.checkpoint.Logs...)
+	for i := len(delivered) - 1; i >= 0; i-- {
+		log := delivered[i]
+		if log.BlockNumber < resume || log.BlockHash == canonical[log.BlockNumber] {
+			continue
+		}
+		log.Removed = true
+		ev, err := parse(filterer, log)
+		if err != nil {
+			return err
+		}
+		if err := s.deliver(ev, handle); err != nil {
+			return err
+		}
+	}
+
+	if s.checkpoint.before(resume, 0) {
+		s.checkpoint.Block, s.checkpoint.LogIndex = resume, 0
+	}
+	var heads []BlockRef
+	for _, ref := range s.checkpoint.Heads {
+		if ref.Number < resume {
+			heads = append(heads, ref)
+		}
+	}
+	s.checkpoint.Heads = heads
+	return SaveCheckpoint(s.config.CheckpointPath, s.checkpoint)
+}
+
+// matches reports whether every recorded hash of a block is its canonical hash
+func matches(recorded []common.Hash, canonical common.Hash) bool {
+	for _, hash := range recorded {
+		if hash != canonical {
+			return false
+		}
+	}
+	return canonical != (common.Hash{})
+}
+
+// forget drops a removed log from the checkpoint
+func (s *Stream) forget(key logKey) {
+	var logs []types.Log
+	for _, log := range s.checkpoint.Logs {
+		if log.BlockHash != key.blockHash || log.Index != key.index {
+			logs = append(logs, log)
+		}
+	}
+	s.checkpoint.Logs = logs
+}
+
+// prune forgets delivered logs far behind the checkpoint; they can no longer overlap,
+// and a reorg that deep is not tracked
+func (s *Stream) prune() {
+	const keep = 256
+	if s.checkpoint.Block < keep {
+		return
+	}
+	for key, block := range s.delivered {
+		if block < s.checkpoint.Block-keep {
+			delete(s.delivered, key)
+		}
+	}
+	var logs []types.Log
+	for _, log := range s.checkpoint.Logs {
+		if log.BlockNumber >= s.checkpoint.Block-keep {
+			logs = append(logs, log)
+		}
+	}
+	s.checkpoint.Logs = logs
+	var heads []BlockRef
+	for _, ref := range s.checkpoint.Heads {
+		if ref.Number >= s.checkpoint.Block-keep {
+			heads = append(heads, ref)
+		}
+	}
+	s.checkpoint.Heads = heads
+}
+
+// parse decodes a log with the generated Parse* method for its event
+func parse(filterer *contracts.CounterFilterer, log types.Log) (Event, error) {
+	ev := Event{Removed: log.Removed, Raw: log}
+	if len(log.Topics) == 0 {
+		return ev, fmt.Errorf("log %d in %s has no topics", log.Index, log.TxHash.Hex())
+	}
+
+	counterABI, err := contracts.CounterMetaData.GetAbi()
+	if err != nil {
+		return ev, err
+	}
+	event, err := counterABI.EventByID(log.Topics[0])
+	if err != nil {
+		return ev, err
+	}
+	ev.Name = event.Name
+
+	switch event.Name {
+	case CountIncremented:
+		e, err := filterer.ParseCountIncremented(log)
+		if err != nil {
+			return ev, err
+		}
+		ev.NewCount = e.NewCount
+	case CountDecremented:
+		e, err := filterer.ParseCountDecremented(log)
+		if err != nil {
+			return ev, err
+		}
+		ev.NewCount = e.NewCount
+	case CountReset:
+		e, err := filterer.ParseCountReset(log)
+		if err != nil {
+			return ev, err
+		}
+		ev.NewCount = e.NewCount
+	}
+	return ev, nil
+}
+
+// FilterEvents returns every Counter event in [from, to] in chain order.
+// The generated Filter* iterators query each event separately, so the results are merged
+// by block and log index.
+func FilterEvents(ctx context.Context, backend bind.ContractFilterer, address common.Address, from, to uint64) ([]Event, error) {
+	filterer, err := contracts.NewCounterFilterer(address, backend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind Counter: %v", err)
+	}
+	opts := &bind.FilterOpts{Start: from, End: &to, Context: ctx}
+
+	var events []Event
+	incremented, err := filterer.FilterCountIncremented(opts)
+	if err != nil {
+		return nil, err
+	}
+	if events, err = drain(events, incremented, func() Event {
+		return Event{Name: CountIncremented, NewCount: incremented.Event.NewCount, Raw: incremented.Event.Raw}
+	}); err != nil {
+		return nil, err
+	}
+	decremented, err := filterer.FilterCountDecremented(opts)
+	if err != nil {
+		return nil, err
+	}
+	if events, err = drain(events, decremented, func() Event {
+		return Event{Name: CountDecremented, NewCount: decremented.Event.NewCount, Raw: decremented.Event.Raw}
+	}); err != nil {
+		return nil, err
+	}
+	reset, err := filterer.FilterCountReset(opts)
+	if err != nil {
+		return nil, err
+	}
+	if events, err = drain(events, reset, func() Event {
+		return Event{Name: CountReset, NewCount: reset.Event.NewCount, Raw: reset.Event.Raw}
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Raw.BlockNumber != events[j].Raw.BlockNumber {
+			return events[i].Raw.BlockNumber < events[j].Raw.BlockNumber
+		}
+		return events[i].Raw.Index < events[j].Raw.Index
+	})
+	return events, nil
+}
+
+// iterator is the part of a generated Filter* iterator that drain uses
+type iterator interface {
+	Next() bool
+	Error() error
+	Close() error
+}
+
+// drain appends every event of it, converted by current, to events and closes it
+func drain(events []Event, it iterator, current func() Event) ([]Event, error) {
+	defer it.Close()
+	for it.Next() {
+		events = append(events, current())
+	}
+	return events, it.Error()
+}
+
+// watch subscribes to the three events with the generated Watch* bindings. Separate
+// subscriptions interleave arbitrarily, so a live event only signals that there is something
+// new: the session then backfills, which delivers logs in chain order and rewinds removed ones.
+func (s *Stream) watch(ctx context.Context, filterer *contracts.CounterFilterer) (<-chan struct{}, event.Subscription, error) {
+	opts := &bind.WatchOpts{Context: ctx}
+	incremented := make(chan *contracts.CounterCountIncremented, 16)
+	decremented := make(chan *contracts.CounterCountDecremented, 16)
+	reset := make(chan *contracts.CounterCountReset, 16)
+
+	var subs []event.Subscription
+	unsubscribe := func() {
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+	}
+	for _, watch := range []func() (event.Subscription, error){
+		func() (event.Subscription, error) { return filterer.WatchCountIncremented(opts, incremented) },
+		func() (event.Subscription, error) { return filterer.WatchCountDecremented(opts, decremented) },
+		func() (event.Subscription, error) { return filterer.WatchCountReset(opts, reset) },
+	} {
+		sub, err := watch()
+		if err != nil {
+			unsubscribe()
+			return nil, nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	// Signals coalesce: one pending backfill covers any number of new logs
+	changed := make(chan struct{}, 1)
+	signal := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	sub := event.NewSubscription(func(quit <-chan struct{}) error {
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-incremented:
+				signal()
+			case <-decremented:
+				signal()
+			case <-reset:
+				signal()
+			case err := <-subs[0].Err():
+				return err
+			case err := <-subs[1].Err():
+				return err
+			case err := <-subs[2].Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+	return changed, sub, nil
+}
@@ -0,0 +1,303 @@
+package counterevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+// describe renders events compactly, e.g. "CountIncremented 1 @3" or "-CountIncremented 1 @3"
+func describe(events []Event) []string {
+	out := make([]string, len(events))
+	for i, ev := range events {
+		prefix := ""
+		if ev.Removed {
+			prefix = "-"
+		}
+		out[i] = fmt.Sprintf("%s%s %s @%d", prefix, ev.Name, ev.NewCount, ev.Raw.BlockNumber)
+	}
+	return out
+}
+
+func expectEvents(t *testing.T, got []Event, want ...string) {
+	t.Helper()
+	described := describe(got)
+	if len(described) != len(want) {
+		t.Fatalf("events %v, want %v", described, want)
+	}
+	for i := range want {
+		if described[i] != want[i] {
+			t.Fatalf("events %v, want %v", described, want)
+		}
+	}
+}
+
+func deployCounter(t *testing.T, chain *simchain.Chain) (common.Address, *contracts.Counter, *bind.TransactOpts) {
+	t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(0), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, counter, err := contracts.DeployCounter(auth, chain.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return address, counter, auth
+}
+
+func TestPollingRewindsReorgedLogs(t *testing.T) {
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	ctx := context.Background()
+	client := chain.Client()
+
+	address, counter, auth := deployCounter(t, chain)
+	fork, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+	stream, err := New(nil, Config{Address: address, CheckpointPath: checkpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	collect := func(ev Event) error {
+		events = append(events, ev)
+		return nil
+	}
+
+	// One poll at the deployment block, then two increments in blocks 2 and 3
+	if err := stream.backfill(ctx, client, collect); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := counter.Increment(auth); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stream.backfill(ctx, client, collect); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, events, "CountIncremented 1 @2", "CountIncremented 2 @3")
+
+	// Nothing changed: a second pass delivers nothing
+	if err := stream.backfill(ctx, client, collect); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, events, "CountIncremented 1 @2", "CountIncremented 2 @3")
+
+	// Replace blocks 2 and 3 with a longer branch; the dropped increments return to the
+	// pool and are mined together in the new block 2
+	if err := chain.Backend().Fork(fork.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		chain.Backend().Commit()
+	}
+
+	// A stream restarted from the checkpoint sees the reorg too
+	restarted, err := New(nil, Config{Address: address, CheckpointPath: checkpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replayed []Event
+	if err := restarted.backfill(ctx, client, func(ev Event) error {
+		replayed = append(replayed, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt := []string{"-CountIncremented 2 @3", "-CountIncremented 1 @2", "CountIncremented 1 @2", "CountIncremented 2 @2"}
+	expectEvents(t, replayed, rebuilt...)
+
+	events = events[:0]
+	if err := stream.backfill(ctx, client, collect); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, events, rebuilt...)
+
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := stream.Checkpoint()
+	last := cp.Heads[len(cp.Heads)-1]
+	if cp.Block != head.Number.Uint64()+1 || len(cp.Logs) != 2 || last.Hash != head.Hash() {
+		t.Fatalf("checkpoint %+v after the reorg, head %d %s", cp, head.Number, head.Hash().Hex())
+	}
+	for _, ref := range cp.Heads[:len(cp.Heads)-1] {
+		if ref.Number >= 2 {
+			t.Errorf("dropped head %d is still tracked", ref.Number)
+		}
+	}
+
+	// Events on the new branch are delivered once
+	if _, err := counter.Reset(auth); err != nil {
+		t.Fatal(err)
+	}
+	events = events[:0]
+	if err := stream.backfill(ctx, client, collect); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, events, fmt.Sprintf("CountReset 0 @%d", head.Number.Uint64()+1))
+}
+
+func TestPollingKeepsLogsOnAReorgAboveThem(t *testing.T) {
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	ctx := context.Background()
+	client := chain.Client()
+
+	address, counter, auth := deployCounter(t, chain)
+	if _, err := counter.Increment(auth); err != nil {
+		t.Fatal(err)
+	}
+	fork, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.Backend().Commit()
+
+	stream, err := New(nil, Config{Address: address, CheckpointPath: filepath.Join(t.TempDir(), "checkpoint.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	collect := func(ev Event) error {
+		events = append(events, ev)
+		return nil
+	}
+	if err := stream.backfill(ctx, client, collect); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the empty head block is replaced
+	if err := chain.Backend().Fork(fork.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	chain.Backend().Commit()
+	chain.Backend().Commit()
+	if err := stream.backfill(ctx, client, collect); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, events, "CountIncremented 1 @2")
+	if cp := stream.Checkpoint(); cp.Block != 5 || len(cp.Logs) != 1 {
+		t.Fatalf("checkpoint %+v, want block 5 with one log", cp)
+	}
+}
+
+func TestFilterEventsMergesInChainOrder(t *testing.T) {
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	ctx := context.Background()
+
+	address, _, auth := deployCounter(t, chain)
+	// Mine reset, increment, increment, decrement in one block without automining
+	manual, err := contracts.NewCounter(address, chain.Backend().Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := chain.Client().PendingNonceAt(ctx, auth.From)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, send := range []func(*bind.TransactOpts) (*types.Transaction, error){manual.Reset, manual.Increment, manual.Increment, manual.Decrement} {
+		opts := *auth
+		opts.Nonce = new(big.Int).SetUint64(nonce + uint64(i))
+		opts.GasLimit = 100000
+		if _, err := send(&opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain.Backend().Commit()
+
+	events, err := FilterEvents(ctx, chain.Client(), address, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, events, "CountReset 0 @2", "CountIncremented 1 @2", "CountIncremented 2 @2", "CountDecremented 1 @2")
+	for i, ev := range events {
+		if ev.Raw.Index != uint(i) || ev.Raw.Address != address {
+			t.Fatalf("event %d is log %d of %s", i, ev.Raw.Index, ev.Raw.Address.Hex())
+		}
+	}
+}
+
+func TestRunDeliversLiveEventsInChainOrder(t *testing.T) {
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	address, counter, auth := deployCounter(t, chain)
+	stream, err := New(func(ctx context.Context) (Backend, error) {
+		return chain.Client(), nil
+	}, Config{Address: address, CheckpointPath: filepath.Join(t.TempDir(), "checkpoint.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	delivered := make(chan Event, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- stream.Run(ctx, func(ev Event) error {
+			delivered <- ev
+			return nil
+		})
+	}()
+
+	// The three events arrive on separate subscriptions but are delivered in chain order
+	for _, send := range []func(*bind.TransactOpts) (*types.Transaction, error){
+		counter.Increment, counter.Increment, counter.Decrement, counter.Reset, counter.Increment,
+	} {
+		if _, err := send(auth); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var events []Event
+	timeout := time.After(10 * time.Second)
+	for len(events) < 5 {
+		select {
+		case ev := <-delivered:
+			events = append(events, ev)
+		case err := <-done:
+			t.Fatalf("stream stopped: %v", err)
+		case <-timeout:
+			t.Fatalf("timed out after %v", describe(events))
+		}
+	}
+	expectEvents(t, events, "CountIncremented 1 @2", "CountIncremented 2 @3", "CountDecremented 1 @4", "CountReset 0 @5", "CountIncremented 1 @6")
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run returned %v, want context.Canceled", err)
+	}
+	if cp := stream.Checkpoint(); cp.Block != 7 || len(cp.Logs) != 5 {
+		t.Fatalf("checkpoint at block %d with %d logs, want block 7 with 5", cp.Block, len(cp.Logs))
+	}
+}
@@ -0,0 +1,146 @@
+package create2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// FactoryAddress is the deterministic deployment proxy (github.com/Arachnid/deterministic-deployment-proxy).
+// It has the same address on every chain because it is created by a keyless, pre-EIP-155 transaction.
+var FactoryAddress = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+// FactoryDeployer is the one-time signer recovered from factoryTx
+var FactoryDeployer = common.HexToAddress("0x3fab184622dc19b6109349b94811493bf2a45362")
+
+// FactoryDeployCost is what FactoryDeployer must hold: 100000 gas at 100 gwei
+var FactoryDeployCost = big.NewInt(1e16)
+
+// factoryTx creates the factory; its signature was made up, so nobody holds the deployer key
+const factoryTx = "0xf8a58085174876e800830186a08080b853604580600e600039806000f350fe7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf31ba02222222222222222222222222222222222222222222222222222222222222222a02222222222222222222222222222222222222222222222222222222222222222"
+
+// ErrNoFactory is returned when the chain has no factory and it cannot be deployed here
+var ErrNoFactory = errors.New("CREATE2 factory is not deployed")
+
+// ParseSalt accepts hex of up to 32 bytes (left-padded), a decimal number or any other
+// label, which is hashed. An empty string is the zero salt.
+func ParseSalt(s string) (common.Hash, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return common.Hash{}, nil
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("invalid salt %q: %v", s, err)
+		}
+		if len(b) > common.HashLength {
+			return common.Hash{}, fmt.Errorf("invalid salt %q: longer than 32 bytes", s)
+		}
+		return common.BytesToHash(b), nil
+	}
+	if n, ok := new(big.Int).SetString(s, 10); ok && n.Sign() >= 0 {
+		if n.BitLen() > 256 {
+			return common.Hash{}, fmt.Errorf("invalid salt %q: larger than 256 bits", s)
+		}
+		return common.BigToHash(n), nil
+	}
+	return crypto.Keccak256Hash([]byte(s)), nil
+}
+
+// Address returns where the factory deploys initCode (creation code with constructor arguments) under salt
+func Address(factory common.Address, salt common.Hash, initCode []byte) common.Address {
+	return crypto.CreateAddress2(factory, salt, crypto.Keccak256(initCode))
+}
+
+// InitCode appends ABI-encoded constructor arguments to creation code
+func InitCode(contractABI *abi.ABI, bytecode []byte, args ...interface{}) ([]byte, error) {
+	packed, err := contractABI.Pack("", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack constructor arguments: %v", err)
+	}
+	return append(common.CopyBytes(bytecode), packed...), nil
+}
+
+// Calldata is the factory input: the salt followed by the creation code
+func Calldata(salt common.Hash, initCode []byte) []byte {
+	return append(salt.Bytes(), initCode...)
+}
+
+// FactoryTx decodes the signed transaction that creates the factory
+func FactoryTx() (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(common.FromHex(factoryTx)); err != nil {
+		return nil, fmt.Errorf("failed to decode factory deployment transaction: %v", err)
+	}
+	return tx, nil
+}
+
+// Backend is what deploying the factory needs from a node; ethclient.Client implements it
+type Backend interface {
+	bind.DeployBackend
+	ethereum.TransactionSender
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// Funder sends wei to an address and waits until it is mined
+type Funder func(ctx context.Context, to common.Address, amount *big.Int) error
+
+// EnsureFactory deploys the factory unless it already has code. fund is called only when
+// FactoryDeployer cannot pay for the deployment. It reports whether the factory was deployed.
+func EnsureFactory(ctx context.Context, backend Backend, fund Funder) (bool, error) {
+	code, err := backend.CodeAt(ctx, FactoryAddress, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get factory code: %v", err)
+	}
+	if len(code) > 0 {
+		return false, nil
+	}
+
+	nonce, err := backend.NonceAt(ctx, FactoryDeployer, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get factory deployer nonce: %v", err)
+	}
+	if nonce != 0 {
+		// The presigned transaction has nonce 0 and can never be mined here
+		return false, fmt.Errorf("%w: deployer %s already used nonce 0 on this chain", ErrNoFactory, FactoryDeployer.Hex())
+	}
+
+	balance, err := backend.BalanceAt(ctx, FactoryDeployer, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get factory deployer balance: %v", err)
+	}
+	if balance.Cmp(FactoryDeployCost) < 0 {
+		if err := fund(ctx, FactoryDeployer, new(big.Int).Sub(FactoryDeployCost, balance)); err != nil {
+			return false, fmt.Errorf("failed to fund factory deployer: %v", err)
+		}
+	}
+
+	tx, err := FactoryTx()
+	if err != nil {
+		return false, err
+	}
+	if err := backend.SendTransaction(ctx, tx); err != nil {
+		// The transaction has no chain ID; anvil and hardhat accept it, geth needs a flag
+		return false, fmt.Errorf("failed to send factory deployment (geth needs --rpc.allow-unprotected-txs): %v", err)
+	}
+	receipt, err := bind.WaitMined(ctx, backend, tx)
+	if err != nil {
+		return false, fmt.Errorf("failed to wait for factory deployment: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful || receipt.ContractAddress != FactoryAddress {
+		return false, fmt.Errorf("factory deployment %s failed", tx.Hash().Hex())
+	}
+	return true, nil
+}
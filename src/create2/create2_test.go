@@ -0,0 +1,208 @@
+package create2
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+func TestParseSalt(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    common.Hash
+		wantErr bool
+	}{
+		{in: "", want: common.Hash{}},
+		{in: "  ", want: common.Hash{}},
+		{in: "0x01", want: common.Hash{31: 1}},
+		{in: "0X0102", want: common.Hash{30: 1, 31: 2}},
+		{in: "0x" + strings.Repeat("ff", 32), want: common.HexToHash("0x" + strings.Repeat("ff", 32))},
+		{in: "0x" + strings.Repeat("ff", 33), wantErr: true},
+		{in: "0x1", wantErr: true}, // odd length
+		{in: "0xzz", wantErr: true},
+		{in: "0", want: common.Hash{}},
+		{in: "258", want: common.Hash{30: 1, 31: 2}},
+		{in: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)).String(), want: common.HexToHash("0x" + strings.Repeat("ff", 32))},
+		{in: new(big.Int).Lsh(big.NewInt(1), 256).String(), wantErr: true},
+		{in: "-1", want: crypto.Keccak256Hash([]byte("-1"))},
+		{in: "counter-v1", want: crypto.Keccak256Hash([]byte("counter-v1"))},
+		{in: " counter-v1 ", want: crypto.Keccak256Hash([]byte("counter-v1"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSalt(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSalt(%q) = %s, want an error", tt.in, got.Hex())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSalt(%q) = %s, want %s", tt.in, got.Hex(), tt.want.Hex())
+			}
+		})
+	}
+}
+
+// TestAddress checks the examples from EIP-1014
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		factory  string
+		salt     string
+		initCode string
+		want     string
+	}{
+		{"0x0000000000000000000000000000000000000000", "0x00", "0x00", "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"},
+		{"0xdeadbeef00000000000000000000000000000000", "0x00", "0x00", "0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3"},
+		{"0xdeadbeef00000000000000000000000000000000", "0x000000000000000000000000feed000000000000000000000000000000000000", "0x00", "0xD04116cDd17beBE565EB2422F2497E06cC1C9833"},
+		{"0x0000000000000000000000000000000000000000", "0x00", "0xdeadbeef", "0x70f2b2914A2a4b783FaEFb75f459A580616Fcb5e"},
+		{"0x00000000000000000000000000000000deadbeef", "0xcafebabe", "0xdeadbeef", "0x60f3f640a8508fC6a86d45DF051962668E1e8AC7"},
+		{"0x0000000000000000000000000000000000000000", "0x00", "0x", "0xE33C0C7F7df4809055C3ebA6c09CFe4BaF1BD9e0"},
+	}
+	for _, tt := range tests {
+		salt, err := ParseSalt(tt.salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := Address(common.HexToAddress(tt.factory), salt, common.FromHex(tt.initCode))
+		if got != common.HexToAddress(tt.want) {
+			t.Errorf("Address(%s, %s, %s) = %s, want %s", tt.factory, tt.salt, tt.initCode, got.Hex(), tt.want)
+		}
+	}
+}
+
+// transfer sends wei from account 0 and waits until it is mined
+func transfer(chain *simchain.Chain) Funder {
+	return func(ctx context.Context, to common.Address, amount *big.Int) error {
+		client := chain.Client()
+		nonce, err := client.PendingNonceAt(ctx, chain.Address(0))
+		if err != nil {
+			return err
+		}
+		tx, err := types.SignNewTx(chain.Key(0), types.LatestSignerForChainID(simchain.ChainID), &types.DynamicFeeTx{
+			ChainID:   simchain.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+			Gas:       21000,
+			To:        &to,
+			Value:     amount,
+		})
+		if err != nil {
+			return err
+		}
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			return err
+		}
+		// The pool validates against the previous head until the next block is sealed
+		chain.Backend().Commit()
+		return nil
+	}
+}
+
+func TestEnsureFactory(t *testing.T) {
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	ctx := context.Background()
+	client := chain.Client()
+
+	var funded []*big.Int
+	fund := func(ctx context.Context, to common.Address, amount *big.Int) error {
+		if to != FactoryDeployer {
+			t.Errorf("funded %s, want the factory deployer", to.Hex())
+		}
+		funded = append(funded, amount)
+		return transfer(chain)(ctx, to, amount)
+	}
+	deployed, err := EnsureFactory(ctx, client, fund)
+	if err != nil || !deployed {
+		t.Fatalf("got %v, %v, want the factory deployed", deployed, err)
+	}
+	if len(funded) != 1 || funded[0].Cmp(FactoryDeployCost) != 0 {
+		t.Errorf("funded %v, want FactoryDeployCost once", funded)
+	}
+	if deployed, err := EnsureFactory(ctx, client, fund); err != nil || deployed || len(funded) != 1 {
+		t.Fatalf("second call: got %v, %v after %d fundings, want no deployment", deployed, err, len(funded))
+	}
+
+	// The factory deploys to the address Address predicts
+	salt, err := ParseSalt("counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	initCode := common.FromHex(contracts.CounterMetaData.Bin)
+	nonce, err := client.PendingNonceAt(ctx, chain.Address(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignNewTx(chain.Key(0), types.LatestSignerForChainID(simchain.ChainID), &types.DynamicFeeTx{
+		ChainID:   simchain.ChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(10 * params.GWei),
+		Gas:       1_000_000,
+		To:        &FactoryAddress,
+		Data:      Calldata(salt, initCode),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	code, err := client.CodeAt(ctx, Address(FactoryAddress, salt, initCode), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) == 0 {
+		t.Error("no contract at the predicted address")
+	}
+}
+
+func TestEnsureFactoryWithoutFunding(t *testing.T) {
+	chain, err := simchain.NewWithAlloc(1, nil, types.GenesisAlloc{
+		FactoryDeployer: {Balance: FactoryDeployCost},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	fund := func(context.Context, common.Address, *big.Int) error {
+		t.Error("funded a deployer that can pay")
+		return nil
+	}
+	if deployed, err := EnsureFactory(context.Background(), chain.Client(), fund); err != nil || !deployed {
+		t.Fatalf("got %v, %v, want the factory deployed", deployed, err)
+	}
+}
+
+func TestEnsureFactoryAfterDeployerNonceIsUsed(t *testing.T) {
+	chain, err := simchain.NewWithAlloc(1, nil, types.GenesisAlloc{
+		FactoryDeployer: {Balance: FactoryDeployCost, Nonce: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	_, err = EnsureFactory(context.Background(), chain.Client(), transfer(chain))
+	if !errors.Is(err, ErrNoFactory) {
+		t.Fatalf("got %v, want ErrNoFactory", err)
+	}
+}
@@ -0,0 +1,113 @@
+package ens
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fuckEthereum/src/evmasm"
+)
+
+// A minimal ENS registry and resolver written in EVM assembly, so the tests deploy real
+// contracts without needing solc. They implement the EIP-137 functions the resolver uses
+// plus the setters needed to register names, with the same ownership checks as ENS.
+
+// testRegistryABI is the ABI of registryCode
+const testRegistryABI = `[
+	{"type":"function","name":"owner","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"resolver","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"setOwner","stateMutability":"nonpayable","inputs":[{"name":"node","type":"bytes32"},{"name":"owner","type":"address"}],"outputs":[]},
+	{"type":"function","name":"setSubnodeOwner","stateMutability":"nonpayable","inputs":[{"name":"node","type":"bytes32"},{"name":"label","type":"bytes32"},{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"function","name":"setResolver","stateMutability":"nonpayable","inputs":[{"name":"node","type":"bytes32"},{"name":"resolver","type":"address"}],"outputs":[]}
+]`
+
+// testResolverABI is the ABI of resolverCode; the constructor takes the registry
+const testResolverABI = `[
+	{"type":"constructor","stateMutability":"nonpayable","inputs":[{"name":"registry","type":"address"}]},
+	{"type":"function","name":"addr","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"setAddr","stateMutability":"nonpayable","inputs":[{"name":"node","type":"bytes32"},{"name":"addr","type":"address"}],"outputs":[]},
+	{"type":"function","name":"name","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"setName","stateMutability":"nonpayable","inputs":[{"name":"node","type":"bytes32"},{"name":"name","type":"string"}],"outputs":[]}
+]`
+
+// slot replaces the node on top of the stack with keccak256(node ++ field), a per-node record
+func slot(field uint64) []interface{} {
+	return []interface{}{vm.PUSH0, vm.MSTORE, evmasm.P(field), evmasm.P(0x20), vm.MSTORE, evmasm.P(0x40), vm.PUSH0, vm.KECCAK256}
+}
+
+// returnWord returns the word on top of the stack
+var returnWord = []interface{}{vm.PUSH0, vm.MSTORE, evmasm.P(0x20), vm.PUSH0, vm.RETURN}
+
+// Registry storage: keccak256(node ++ 0) holds the owner, keccak256(node ++ 1) the resolver.
+// The deployer owns the root node.
+var registryCode = func() []byte {
+	node := []interface{}{evmasm.P(4), vm.CALLDATALOAD}
+	// onlyOwner reverts unless the caller owns the node in the first argument
+	onlyOwner := func(ok evmasm.Label) []interface{} {
+		return []interface{}{node, slot(0), vm.SLOAD, vm.CALLER, vm.EQ, evmasm.Ref(ok), vm.JUMPI, vm.PUSH0, vm.DUP1, vm.REVERT, ok}
+	}
+	runtime := evmasm.Assemble(
+		evmasm.Dispatch(
+			"owner(bytes32)", "owner",
+			"resolver(bytes32)", "resolver",
+			"setOwner(bytes32,address)", "setOwner",
+			"setSubnodeOwner(bytes32,bytes32,address)", "setSubnodeOwner",
+			"setResolver(bytes32,address)", "setResolver",
+		),
+		evmasm.Label("owner"), node, slot(0), vm.SLOAD, returnWord,
+		evmasm.Label("resolver"), node, slot(1), vm.SLOAD, returnWord,
+		evmasm.Label("setOwner"), onlyOwner("setOwnerOK"),
+		evmasm.P(0x24), vm.CALLDATALOAD, node, slot(0), vm.SSTORE, vm.STOP,
+		evmasm.Label("setResolver"), onlyOwner("setResolverOK"),
+		evmasm.P(0x24), vm.CALLDATALOAD, node, slot(1), vm.SSTORE, vm.STOP,
+		// subnode = keccak256(node ++ label); owner[subnode] = owner
+		evmasm.Label("setSubnodeOwner"), onlyOwner("setSubnodeOwnerOK"),
+		node, vm.PUSH0, vm.MSTORE, evmasm.P(0x24), vm.CALLDATALOAD, evmasm.P(0x20), vm.MSTORE, evmasm.P(0x40), vm.PUSH0, vm.KECCAK256,
+		vm.DUP1, evmasm.P(0x44), vm.CALLDATALOAD, vm.SWAP1, slot(0), vm.SSTORE, returnWord,
+	)
+	return evmasm.Creation([]interface{}{vm.CALLER, vm.PUSH0, slot(0), vm.SSTORE}, runtime)
+}()
+
+// Resolver storage: slot 0 holds the registry, keccak256(node ++ 1) the address record and
+// keccak256(node ++ 2) the length of the name record, followed by its 32-byte words.
+// Records can only be set by the owner of the node in the registry.
+var resolverCode = func() []byte {
+	node := []interface{}{evmasm.P(4), vm.CALLDATALOAD}
+	// authorized reverts unless registry.owner(node) is the caller
+	authorized := func(ok evmasm.Label) []interface{} {
+		return []interface{}{
+			evmasm.Selector("owner(bytes32)"), evmasm.P(0xe0), vm.SHL, vm.PUSH0, vm.MSTORE, node, evmasm.P(4), vm.MSTORE,
+			evmasm.P(0x20), vm.PUSH0, evmasm.P(0x24), vm.PUSH0, vm.PUSH0, vm.SLOAD, vm.GAS, vm.STATICCALL,
+			vm.PUSH0, vm.MLOAD, vm.CALLER, vm.EQ, vm.AND, evmasm.Ref(ok), vm.JUMPI, vm.PUSH0, vm.DUP1, vm.REVERT, ok,
+		}
+	}
+	runtime := evmasm.Assemble(
+		evmasm.Dispatch(
+			"addr(bytes32)", "addr",
+			"setAddr(bytes32,address)", "setAddr",
+			"name(bytes32)", "name",
+			"setName(bytes32,string)", "setName",
+		),
+		evmasm.Label("addr"), node, slot(1), vm.SLOAD, returnWord,
+		evmasm.Label("setAddr"), authorized("setAddrOK"),
+		evmasm.P(0x24), vm.CALLDATALOAD, node, slot(1), vm.SSTORE, vm.STOP,
+
+		// [base lenPos len i]: store len at base and word i/32 at base+1+i/32
+		evmasm.Label("setName"), authorized("setNameOK"),
+		node, slot(2), evmasm.P(0x24), vm.CALLDATALOAD, evmasm.P(4), vm.ADD, vm.DUP1, vm.CALLDATALOAD,
+		vm.DUP1, vm.DUP4, vm.SSTORE, vm.PUSH0,
+		evmasm.Label("setNameLoop"), vm.DUP2, vm.DUP2, vm.LT, vm.ISZERO, evmasm.Ref("setNameDone"), vm.JUMPI,
+		vm.DUP1, vm.DUP4, vm.ADD, evmasm.P(0x20), vm.ADD, vm.CALLDATALOAD,
+		vm.DUP2, evmasm.P(5), vm.SHR, vm.DUP6, vm.ADD, evmasm.P(1), vm.ADD, vm.SSTORE,
+		evmasm.P(0x20), vm.ADD, evmasm.Ref("setNameLoop"), vm.JUMP,
+		evmasm.Label("setNameDone"), vm.STOP,
+
+		// [base len i]: return the ABI encoding (0x20, len, words...)
+		evmasm.Label("name"), node, slot(2), evmasm.P(0x20), vm.PUSH0, vm.MSTORE,
+		vm.DUP1, vm.SLOAD, vm.DUP1, evmasm.P(0x20), vm.MSTORE, vm.PUSH0,
+		evmasm.Label("nameLoop"), vm.DUP2, vm.DUP2, vm.LT, vm.ISZERO, evmasm.Ref("nameDone"), vm.JUMPI,
+		vm.DUP1, evmasm.P(5), vm.SHR, vm.DUP4, vm.ADD, evmasm.P(1), vm.ADD, vm.SLOAD,
+		vm.DUP2, evmasm.P(0x40), vm.ADD, vm.MSTORE,
+		evmasm.P(0x20), vm.ADD, evmasm.Ref("nameLoop"), vm.JUMP,
+		evmasm.Label("nameDone"), evmasm.P(0x40), vm.ADD, vm.PUSH0, vm.RETURN,
+	)
+	// The registry is the ABI-encoded constructor argument at the end of the creation code
+	return evmasm.Creation([]interface{}{evmasm.P(0x20), evmasm.P(0x20), vm.CODESIZE, vm.SUB, vm.PUSH0, vm.CODECOPY, vm.PUSH0, vm.MLOAD, vm.PUSH0, vm.SSTORE}, runtime)
+}()
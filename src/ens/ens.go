@@ -0,0 +1,163 @@
+package ens
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DefaultRegistry is the ENS registry address shared by mainnet, Sepolia and Holesky
+var DefaultRegistry = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// RegistryABI is the subset of the ENS registry interface used by the resolver
+const RegistryABI = `[{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"}]`
+
+// ResolverABI is the subset of the ENS public resolver interface used for forward and reverse lookups
+const ResolverABI = `[{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"}],"name":"addr","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"}],"name":"name","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"}]`
+
+var (
+	registryABI = mustParseABI(RegistryABI)
+	resolverABI = mustParseABI(ResolverABI)
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in ABI: %v", err))
+	}
+	return parsed
+}
+
+// Resolver resolves ENS names through a registry using plain eth_call
+type Resolver struct {
+	caller   bind.ContractCaller
+	registry common.Address
+}
+
+// NewResolver creates a resolver that queries the given registry
+func NewResolver(caller bind.ContractCaller, registry common.Address) *Resolver {
+	return &Resolver{
+		caller:   caller,
+		registry: registry,
+	}
+}
+
+// Normalize lowercases and trims a name; full UTS-46 normalisation is not applied
+func Normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// NameHash computes the EIP-137 namehash of a name
+func NameHash(name string) common.Hash {
+	var node common.Hash
+	name = Normalize(name)
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		labelHash := crypto.Keccak256([]byte(labels[i]))
+		node = crypto.Keccak256Hash(node.Bytes(), labelHash)
+	}
+	return node
+}
+
+// ReverseNode returns the name used for reverse records of an address
+func ReverseNode(address common.Address) string {
+	return strings.ToLower(address.Hex()[2:]) + ".addr.reverse"
+}
+
+// IsName reports whether the input looks like an ENS name rather than a hex address
+func IsName(input string) bool {
+	input = strings.TrimSpace(input)
+	if common.IsHexAddress(input) {
+		return false
+	}
+	return strings.Contains(input, ".") && !strings.HasPrefix(input, ".") && !strings.Contains(input, "..")
+}
+
+// ResolverOf returns the resolver contract registered for a name
+func (r *Resolver) ResolverOf(ctx context.Context, name string) (common.Address, error) {
+	registry := bind.NewBoundContract(r.registry, registryABI, r.caller, nil, nil)
+
+	var out []interface{}
+	if err := registry.Call(&bind.CallOpts{Context: ctx}, &out, "resolver", NameHash(name)); err != nil {
+		return common.Address{}, fmt.Errorf("failed to query registry for %s: %v", name, err)
+	}
+
+	resolver := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	if resolver == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("no resolver set for %s", name)
+	}
+	return resolver, nil
+}
+
+// Resolve returns the address a name points to via its resolver's addr() record
+func (r *Resolver) Resolve(ctx context.Context, name string) (common.Address, error) {
+	resolverAddr, err := r.ResolverOf(ctx, name)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	resolver := bind.NewBoundContract(resolverAddr, resolverABI, r.caller, nil, nil)
+
+	var out []interface{}
+	if err := resolver.Call(&bind.CallOpts{Context: ctx}, &out, "addr", NameHash(name)); err != nil {
+		return common.Address{}, fmt.Errorf("failed to resolve %s: %v", name, err)
+	}
+
+	address := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	if address == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%s has no address record", name)
+	}
+	return address, nil
+}
+
+// ReverseResolve returns the primary name of an address.
+// The name is only returned if it resolves forward to the same address.
+func (r *Resolver) ReverseResolve(ctx context.Context, address common.Address) (string, error) {
+	reverse := ReverseNode(address)
+	resolverAddr, err := r.ResolverOf(ctx, reverse)
+	if err != nil {
+		return "", err
+	}
+
+	resolver := bind.NewBoundContract(resolverAddr, resolverABI, r.caller, nil, nil)
+
+	var out []interface{}
+	if err := resolver.Call(&bind.CallOpts{Context: ctx}, &out, "name", NameHash(reverse)); err != nil {
+		return "", fmt.Errorf("failed to reverse resolve %s: %v", address.Hex(), err)
+	}
+
+	name := *abi.ConvertType(out[0], new(string)).(*string)
+	if name == "" {
+		return "", fmt.Errorf("%s has no reverse record", address.Hex())
+	}
+
+	forward, err := r.Resolve(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("reverse record %s does not resolve: %v", name, err)
+	}
+	if forward != address {
+		return "", fmt.Errorf("reverse record %s resolves to %s, not %s", name, forward.Hex(), address.Hex())
+	}
+	return name, nil
+}
+
+// ResolveAddress accepts either a hex address or an ENS name and returns the address
+func (r *Resolver) ResolveAddress(ctx context.Context, input string) (common.Address, error) {
+	input = strings.TrimSpace(input)
+	if common.IsHexAddress(input) {
+		return common.HexToAddress(input), nil
+	}
+	if !IsName(input) {
+		return common.Address{}, fmt.Errorf("invalid address or ENS name: %q", input)
+	}
+	return r.Resolve(ctx, input)
+}
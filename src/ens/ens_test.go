@@ -0,0 +1,184 @@
+package ens
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+func TestNameHash(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", "0x0000000000000000000000000000000000000000000000000000000000000000"},
+		{"eth", "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae"},
+		{"foo.eth", "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"},
+		{" Foo.ETH. ", "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"},
+	}
+	for _, tt := range tests {
+		if got := NameHash(tt.name).Hex(); got != tt.want {
+			t.Errorf("NameHash(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// ensEnv is a simulated chain with the test registry deployed by account 0,
+// which owns the root node
+type ensEnv struct {
+	t        *testing.T
+	chain    *simchain.Chain
+	registry *bind.BoundContract
+}
+
+func deployTestContract(t *testing.T, chain *simchain.Chain, definition string, code []byte, args ...interface{}) (common.Address, *bind.BoundContract) {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(0), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, contract, err := bind.DeployContract(auth, parsed, code, chain.Client(), args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return address, contract
+}
+
+// transact sends method from account and returns an error if it reverts
+func (e *ensEnv) transact(contract *bind.BoundContract, account int, method string, args ...interface{}) error {
+	e.t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(e.chain.Key(account), simchain.ChainID)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	tx, err := contract.Transact(auth, method, args...)
+	if err != nil {
+		return err
+	}
+	receipt, err := e.chain.Client().TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("%s reverted", method)
+	}
+	return nil
+}
+
+// must fails the test if the transaction fails
+func (e *ensEnv) must(contract *bind.BoundContract, account int, method string, args ...interface{}) {
+	e.t.Helper()
+	if err := e.transact(contract, account, method, args...); err != nil {
+		e.t.Fatalf("%s: %v", method, err)
+	}
+}
+
+// register gives account the name, creating parent nodes owned by account 0 as needed
+func (e *ensEnv) register(name string, account int) {
+	e.t.Helper()
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		owner := e.chain.Address(0)
+		if i == 0 {
+			owner = e.chain.Address(account)
+		}
+		parent := NameHash(strings.Join(labels[i+1:], "."))
+		e.must(e.registry, 0, "setSubnodeOwner", parent, crypto.Keccak256Hash([]byte(labels[i])), owner)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	chain, err := simchain.New(3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	registryAddress, registry := deployTestContract(t, chain, testRegistryABI, registryCode)
+	resolverAddress, resolver := deployTestContract(t, chain, testResolverABI, resolverCode, registryAddress)
+	e := &ensEnv{t: t, chain: chain, registry: registry}
+	alice, mallory := chain.Address(1), chain.Address(2)
+
+	// alice registers alice.eth and its reverse record
+	e.register("alice.eth", 1)
+	e.must(registry, 1, "setResolver", NameHash("alice.eth"), resolverAddress)
+	e.must(resolver, 1, "setAddr", NameHash("alice.eth"), alice)
+	e.register(ReverseNode(alice), 1)
+	e.must(registry, 1, "setResolver", NameHash(ReverseNode(alice)), resolverAddress)
+	e.must(resolver, 1, "setName", NameHash(ReverseNode(alice)), "alice.eth")
+
+	// mallory claims alice's name, which does not resolve back to mallory
+	e.register(ReverseNode(mallory), 2)
+	e.must(registry, 2, "setResolver", NameHash(ReverseNode(mallory)), resolverAddress)
+	e.must(resolver, 2, "setName", NameHash(ReverseNode(mallory)), "alice.eth")
+
+	// Records of a name can only be changed by its owner
+	if err := e.transact(resolver, 2, "setAddr", NameHash("alice.eth"), mallory); err == nil {
+		t.Fatal("mallory changed the address of alice.eth")
+	}
+	if err := e.transact(registry, 2, "setResolver", NameHash("alice.eth"), mallory); err == nil {
+		t.Fatal("mallory changed the resolver of alice.eth")
+	}
+
+	ctx := context.Background()
+	r := NewResolver(chain.Client(), registryAddress)
+
+	t.Run("forward", func(t *testing.T) {
+		got, err := r.ResolveAddress(ctx, "Alice.eth")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != alice {
+			t.Errorf("alice.eth resolved to %s, want %s", got.Hex(), alice.Hex())
+		}
+	})
+
+	t.Run("hex passthrough", func(t *testing.T) {
+		got, err := r.ResolveAddress(ctx, mallory.Hex())
+		if err != nil || got != mallory {
+			t.Errorf("ResolveAddress(%s) = %s, %v", mallory.Hex(), got.Hex(), err)
+		}
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "nobody.eth")
+		if err == nil || !strings.Contains(err.Error(), "no resolver set") {
+			t.Errorf("expected no resolver error, got %v", err)
+		}
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		got, err := r.ReverseResolve(ctx, alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "alice.eth" {
+			t.Errorf("reverse of alice = %q, want alice.eth", got)
+		}
+	})
+
+	t.Run("reverse mismatch", func(t *testing.T) {
+		_, err := r.ReverseResolve(ctx, mallory)
+		if err == nil || !strings.Contains(err.Error(), "resolves to") {
+			t.Errorf("expected forward mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("no reverse record", func(t *testing.T) {
+		if _, err := r.ReverseResolve(ctx, common.HexToAddress("0x1234")); err == nil {
+			t.Error("expected an error for an address without reverse record")
+		}
+	})
+}
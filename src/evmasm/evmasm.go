@@ -0,0 +1,106 @@
+package evmasm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Label marks a JUMPDEST; Ref pushes the offset of a label
+type (
+	Label string
+	Ref   string
+)
+
+// Push is a PUSHn of its bytes
+type Push []byte
+
+// P pushes v in as few bytes as possible
+func P(v uint64) Push {
+	b := binary.BigEndian.AppendUint64(nil, v)
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// Selector pushes the 4-byte selector of a function signature
+func Selector(signature string) Push {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// Assemble encodes opcodes, pushes, labels and label references; slices are flattened
+func Assemble(items ...interface{}) []byte {
+	var flat []interface{}
+	var flatten func(items []interface{})
+	flatten = func(items []interface{}) {
+		for _, item := range items {
+			if nested, ok := item.([]interface{}); ok {
+				flatten(nested)
+			} else {
+				flat = append(flat, item)
+			}
+		}
+	}
+	flatten(items)
+
+	labels := map[Label]int{}
+	offset := 0
+	for _, item := range flat {
+		switch item := item.(type) {
+		case vm.OpCode, Label:
+			offset++
+		case Push:
+			offset += 1 + len(item)
+		case Ref:
+			offset += 3
+		}
+		if l, ok := item.(Label); ok {
+			labels[l] = offset - 1
+		}
+	}
+
+	var code []byte
+	for _, item := range flat {
+		switch item := item.(type) {
+		case vm.OpCode:
+			code = append(code, byte(item))
+		case Label:
+			code = append(code, byte(vm.JUMPDEST))
+		case Push:
+			code = append(append(code, byte(vm.PUSH1)+byte(len(item))-1), item...)
+		case Ref:
+			dest, ok := labels[Label(item)]
+			if !ok {
+				panic(fmt.Sprintf("undefined label %s", item))
+			}
+			code = append(code, byte(vm.PUSH2), byte(dest>>8), byte(dest))
+		default:
+			panic(fmt.Sprintf("cannot assemble %T", item))
+		}
+	}
+	return code
+}
+
+// Creation wraps runtime in creation code that runs constructor and returns runtime
+func Creation(constructor []interface{}, runtime []byte) []byte {
+	init := Assemble(constructor...)
+	copyRuntime := func(at int) []byte {
+		return Assemble(Push{byte(len(runtime) >> 8), byte(len(runtime))}, vm.DUP1,
+			Push{byte(at >> 8), byte(at)}, vm.PUSH0, vm.CODECOPY, vm.PUSH0, vm.RETURN)
+	}
+	prefix := len(init) + len(copyRuntime(0))
+	return append(append(init, copyRuntime(prefix)...), runtime...)
+}
+
+// Dispatch jumps to the function whose selector is in the calldata and reverts for any
+// other; functions are pairs of signature and label
+func Dispatch(functions ...string) []interface{} {
+	code := []interface{}{vm.PUSH0, vm.CALLDATALOAD, P(0xe0), vm.SHR}
+	for i := 0; i < len(functions); i += 2 {
+		code = append(code, vm.DUP1, Selector(functions[i]), vm.EQ, Ref(functions[i+1]), vm.JUMPI)
+	}
+	return append(code, vm.PUSH0, vm.DUP1, vm.REVERT)
+}
@@ -0,0 +1,199 @@
+package explorer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/src/rpcbatch"
+)
+
+// Defaults for batched fetching
+const (
+	DefaultBatchSize = rpcbatch.DefaultBatchSize
+	DefaultWorkers   = rpcbatch.DefaultWorkers
+)
+
+// MaxSummaryBlocks is the largest range Summarize fetches in one call
+const MaxSummaryBlocks = 10000
+
+// Options tunes how much is fetched for a block
+type Options struct {
+	Receipts  bool // fetch receipts for status, gas used and fees
+	BatchSize int  // receipts or blocks per JSON-RPC batch (default DefaultBatchSize)
+	Workers   int  // batches in flight at once (default DefaultWorkers)
+}
+
+// Tx is one transaction of an inspected block
+type Tx struct {
+	Index             int
+	Hash              common.Hash
+	Type              uint8
+	From              common.Address
+	To                *common.Address // nil for contract creation
+	Value             *big.Int
+	Nonce             uint64
+	Gas               uint64
+	BlobGas           uint64
+	Receipt           *types.Receipt // set when Options.Receipts
+	EffectiveGasPrice *big.Int       // set when Options.Receipts
+	Fee               *big.Int       // set when Options.Receipts
+}
+
+// Block is an inspected block
+type Block struct {
+	Header      *types.Header
+	Hash        common.Hash
+	Time        time.Time
+	Burnt       *big.Int // base fee * gas used
+	Txs         []Tx
+	Withdrawals types.Withdrawals
+}
+
+// Inspect decodes senders and optionally fetches receipts for every transaction in a block.
+// Receipts are requested in concurrent JSON-RPC batches so large blocks stay fast.
+func Inspect(ctx context.Context, client rpcbatch.Caller, block *types.Block, opts Options) (*Block, error) {
+	header := block.Header()
+	result := &Block{
+		Header:      header,
+		Hash:        block.Hash(),
+		Time:        time.Unix(int64(header.Time), 0).UTC(),
+		Burnt:       Burnt(header),
+		Withdrawals: block.Withdrawals(),
+	}
+
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover sender of %s: %v", tx.Hash().Hex(), err)
+		}
+		result.Txs = append(result.Txs, Tx{
+			Index:   i,
+			Hash:    tx.Hash(),
+			Type:    tx.Type(),
+			From:    from,
+			To:      tx.To(),
+			Value:   tx.Value(),
+			Nonce:   tx.Nonce(),
+			Gas:     tx.Gas(),
+			BlobGas: tx.BlobGas(),
+		})
+	}
+
+	if !opts.Receipts || len(result.Txs) == 0 {
+		return result, nil
+	}
+
+	hashes := make([]common.Hash, len(result.Txs))
+	for i, tx := range result.Txs {
+		hashes[i] = tx.Hash
+	}
+	receipts, err := fetchReceipts(ctx, client, hashes, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, receipt := range receipts {
+		tx := &result.Txs[i]
+		tx.Receipt = receipt
+		if receipt.EffectiveGasPrice != nil {
+			tx.EffectiveGasPrice = receipt.EffectiveGasPrice
+			tx.Fee = new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		}
+	}
+	return result, nil
+}
+
+// Burnt returns the base fee burnt by a block (zero before London)
+func Burnt(header *types.Header) *big.Int {
+	if header.BaseFee == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(header.BaseFee, new(big.Int).SetUint64(header.GasUsed))
+}
+
+// fetchReceipts fetches every receipt through rpcbatch; any missing receipt fails the block
+func fetchReceipts(ctx context.Context, client rpcbatch.Caller, hashes []common.Hash, opts Options) ([]*types.Receipt, error) {
+	results := rpcbatch.Receipts(ctx, client, hashes, rpcbatch.Options{BatchSize: opts.BatchSize, Workers: opts.Workers})
+
+	receipts := make([]*types.Receipt, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			return nil, fmt.Errorf("failed to get receipt of %s: %w", hashes[i].Hex(), result.Err)
+		}
+		receipts[i] = result.Value
+	}
+	return receipts, nil
+}
+
+// Summary is one block of a range summary
+type Summary struct {
+	Number      uint64
+	Time        time.Time
+	TxCount     int
+	GasUsed     uint64
+	GasLimit    uint64
+	BaseFee     *big.Int // nil before London
+	Burnt       *big.Int
+	BlobGasUsed uint64
+}
+
+// Totals aggregates a range summary
+type Totals struct {
+	Blocks      int
+	TxCount     int
+	GasUsed     uint64
+	Burnt       *big.Int
+	BlobGasUsed uint64
+}
+
+// Summarize fetches blocks from..to (inclusive) in concurrent JSON-RPC batches and returns
+// per-block summaries in order plus totals. Ranges over MaxSummaryBlocks are rejected.
+func Summarize(ctx context.Context, client rpcbatch.Caller, from, to uint64, opts Options) ([]Summary, Totals, error) {
+	totals := Totals{Burnt: new(big.Int)}
+	if to < from {
+		return nil, totals, fmt.Errorf("invalid range %d-%d", from, to)
+	}
+	if to-from >= MaxSummaryBlocks {
+		return nil, totals, fmt.Errorf("range %d-%d has %d blocks, at most %d can be summarized at once", from, to, to-from+1, MaxSummaryBlocks)
+	}
+
+	numbers := make([]uint64, to-from+1)
+	for i := range numbers {
+		numbers[i] = from + uint64(i)
+	}
+	results := rpcbatch.Blocks(ctx, client, numbers, rpcbatch.Options{BatchSize: opts.BatchSize, Workers: opts.Workers})
+	if err := ctx.Err(); err != nil {
+		return nil, totals, err
+	}
+
+	summaries := make([]Summary, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			return nil, totals, fmt.Errorf("failed to fetch block %d: %w", numbers[i], result.Err)
+		}
+		header := result.Value.Header()
+		summary := Summary{
+			Number:   numbers[i],
+			Time:     time.Unix(int64(header.Time), 0).UTC(),
+			TxCount:  len(result.Value.Transactions()),
+			GasUsed:  header.GasUsed,
+			GasLimit: header.GasLimit,
+			BaseFee:  header.BaseFee,
+			Burnt:    Burnt(header),
+		}
+		if header.BlobGasUsed != nil {
+			summary.BlobGasUsed = *header.BlobGasUsed
+		}
+		summaries[i] = summary
+
+		totals.Blocks++
+		totals.TxCount += summary.TxCount
+		totals.GasUsed += summary.GasUsed
+		totals.BlobGasUsed += summary.BlobGasUsed
+		totals.Burnt.Add(totals.Burnt, summary.Burnt)
+	}
+	return summaries, totals, nil
+}
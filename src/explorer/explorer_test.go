@@ -0,0 +1,163 @@
+package explorer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/fuckEthereum/src/rpcbatch"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+// transfers mines one block per entry with that many transfers from account 0 to account 1
+func transfers(t *testing.T, chain *simchain.Chain, perBlock ...int) {
+	t.Helper()
+	ctx := context.Background()
+	client := chain.Backend().Client()
+	signer := types.LatestSignerForChainID(simchain.ChainID)
+	for _, n := range perBlock {
+		head, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce, err := client.PendingNonceAt(ctx, chain.Address(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		to := chain.Address(1)
+		for i := 0; i < n; i++ {
+			tx, err := types.SignNewTx(chain.Key(0), signer, &types.DynamicFeeTx{
+				ChainID:   simchain.ChainID,
+				Nonce:     nonce + uint64(i),
+				GasTipCap: big.NewInt(params.GWei),
+				GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), big.NewInt(params.GWei)),
+				Gas:       21000,
+				To:        &to,
+				Value:     big.NewInt(int64(i + 1)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := client.SendTransaction(ctx, tx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		chain.Backend().Commit()
+	}
+}
+
+func newTestChain(t *testing.T) *simchain.Chain {
+	t.Helper()
+	chain, err := simchain.New(2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	return chain
+}
+
+func TestSummarize(t *testing.T) {
+	chain := newTestChain(t)
+	transfers(t, chain, 2, 0, 3, 1)
+	ctx := context.Background()
+
+	// Batches of 2 blocks, so the range spans several batches
+	summaries, totals, err := Summarize(ctx, chain.Client().RPC(), 1, 4, Options{BatchSize: 2, Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTxs := []int{2, 0, 3, 1}
+	if len(summaries) != len(wantTxs) {
+		t.Fatalf("got %d summaries, want %d", len(summaries), len(wantTxs))
+	}
+	burnt := new(big.Int)
+	for i, s := range summaries {
+		header, err := chain.Client().HeaderByNumber(ctx, big.NewInt(int64(i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Number != uint64(i+1) || s.TxCount != wantTxs[i] || s.GasUsed != uint64(21000*wantTxs[i]) || s.GasLimit != header.GasLimit {
+			t.Errorf("block %d: %+v", i+1, s)
+		}
+		if s.BaseFee.Cmp(header.BaseFee) != 0 || s.Burnt.Cmp(new(big.Int).Mul(header.BaseFee, big.NewInt(int64(s.GasUsed)))) != 0 {
+			t.Errorf("block %d: base fee %s burnt %s", i+1, s.BaseFee, s.Burnt)
+		}
+		if s.Time.Unix() != int64(header.Time) {
+			t.Errorf("block %d: time %s, want %d", i+1, s.Time, header.Time)
+		}
+		burnt.Add(burnt, s.Burnt)
+	}
+	if totals.Blocks != 4 || totals.TxCount != 6 || totals.GasUsed != 6*21000 || totals.Burnt.Cmp(burnt) != 0 {
+		t.Errorf("totals %+v", totals)
+	}
+}
+
+func TestSummarizeRejectsRanges(t *testing.T) {
+	chain := newTestChain(t)
+	transfers(t, chain, 1)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		from, to uint64
+	}{
+		{"reversed", 5, 4},
+		{"above the cap", 0, MaxSummaryBlocks},
+		{"from genesis on a long chain", 0, 20_000_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Summarize(ctx, chain.Client().RPC(), tt.from, tt.to, Options{}); err == nil {
+				t.Fatalf("Summarize(%d, %d) succeeded", tt.from, tt.to)
+			}
+		})
+	}
+
+	if _, _, err := Summarize(ctx, chain.Client().RPC(), 0, 3, Options{}); !errors.Is(err, rpcbatch.ErrNotFound) {
+		t.Errorf("blocks past the head: got %v, want ErrNotFound", err)
+	}
+	// A range at the cap is fetched, and fails only because the chain is shorter
+	if _, _, err := Summarize(ctx, chain.Client().RPC(), 0, MaxSummaryBlocks-1, Options{}); !errors.Is(err, rpcbatch.ErrNotFound) {
+		t.Errorf("range at the cap: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	chain := newTestChain(t)
+	transfers(t, chain, 3)
+	ctx := context.Background()
+
+	block, err := chain.Client().BlockByNumber(ctx, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, receipts := range []bool{false, true} {
+		inspected, err := Inspect(ctx, chain.Client().RPC(), block, Options{Receipts: receipts, BatchSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inspected.Hash != block.Hash() || len(inspected.Txs) != 3 || inspected.Burnt.Cmp(Burnt(block.Header())) != 0 {
+			t.Fatalf("inspected %+v", inspected)
+		}
+		for i, tx := range inspected.Txs {
+			if tx.Index != i || tx.From != chain.Address(0) || *tx.To != chain.Address(1) || tx.Value.Int64() != int64(i+1) || tx.Type != types.DynamicFeeTxType {
+				t.Errorf("tx %d: %+v", i, tx)
+			}
+			if !receipts {
+				if tx.Receipt != nil || tx.Fee != nil {
+					t.Errorf("tx %d: receipt fetched without Options.Receipts", i)
+				}
+				continue
+			}
+			if tx.Receipt == nil || tx.Receipt.TxHash != tx.Hash || tx.Receipt.Status != types.ReceiptStatusSuccessful {
+				t.Fatalf("tx %d: receipt %+v", i, tx.Receipt)
+			}
+			if want := new(big.Int).Mul(tx.EffectiveGasPrice, big.NewInt(21000)); tx.Fee.Cmp(want) != 0 {
+				t.Errorf("tx %d: fee %s, want %s", i, tx.Fee, want)
+			}
+		}
+	}
+}
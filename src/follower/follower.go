@@ -0,0 +1,271 @@
+package follower
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// EventType tells consumers what happened
+type EventType int
+
+const (
+	EventBlock EventType = iota // a new canonical block
+	EventReorg                  // blocks delivered earlier are no longer canonical
+)
+
+func (t EventType) String() string {
+	if t == EventReorg {
+		return "reorg"
+	}
+	return "block"
+}
+
+// Event is delivered in chain order: a Reorg always precedes the Blocks of the new branch
+type Event struct {
+	Type     EventType
+	Header   *types.Header   // EventBlock: the new block
+	Ancestor *types.Header   // EventReorg: last block both branches share (nil if deeper than tracked)
+	Removed  []*types.Header // EventReorg: dropped blocks, newest first
+}
+
+// Backend is everything the follower needs from a node
+type Backend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// Dialer opens a connection; it is called again after every disconnect
+type Dialer func(ctx context.Context) (Backend, error)
+
+// DialURL returns a dialer for a ws, IPC or HTTP endpoint
+func DialURL(url string) Dialer {
+	return func(ctx context.Context) (Backend, error) {
+		return ethclient.DialContext(ctx, url)
+	}
+}
+
+// Config tunes polling, reconnects and reorg tracking
+type Config struct {
+	PollInterval time.Duration // head polling interval when subscriptions are unsupported (default 12s)
+	MinBackoff   time.Duration // first reconnect delay (default 1s)
+	MaxBackoff   time.Duration // reconnect delay cap (default 1m)
+	Depth        int           // headers kept for reorg detection (default 64)
+}
+
+// Follower tracks the canonical chain head
+type Follower struct {
+	dial   Dialer
+	config Config
+	chain  []*types.Header // ascending, contiguous by parent hash
+}
+
+// New creates a follower
+func New(dial Dialer, config Config) *Follower {
+	if config.PollInterval == 0 {
+		config.PollInterval = 12 * time.Second
+	}
+	if config.MinBackoff == 0 {
+		config.MinBackoff = time.Second
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = time.Minute
+	}
+	if config.Depth == 0 {
+		config.Depth = 64
+	}
+	return &Follower{dial: dial, config: config}
+}
+
+// Run delivers events until ctx is cancelled, reconnecting with exponential backoff
+func (f *Follower) Run(ctx context.Context, events chan<- Event) error {
+	backoff := f.config.MinBackoff
+	for {
+		backend, err := f.dial(ctx)
+		if err == nil {
+			var progressed bool
+			progressed, err = f.follow(ctx, backend, events)
+			if closer, ok := backend.(interface{ Close() }); ok {
+				closer.Close()
+			}
+			if progressed {
+				backoff = f.config.MinBackoff
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		fmt.Printf("⚠️  Connection lost (%v), reconnecting in %s\n", err, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > f.config.MaxBackoff {
+			backoff = f.config.MaxBackoff
+		}
+	}
+}
+
+// follow processes heads from one connection; progressed reports whether any head arrived
+func (f *Follower) follow(ctx context.Context, backend Backend, events chan<- Event) (progressed bool, err error) {
+	heads := make(chan *types.Header, 16)
+	sub, err := backend.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		if !errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			return false, fmt.Errorf("failed to subscribe to new heads: %v", err)
+		}
+		return f.poll(ctx, backend, events)
+	}
+	defer sub.Unsubscribe()
+
+	// Catch up with whatever happened while disconnected
+	if err := f.pollOnce(ctx, backend, events); err != nil {
+		return false, err
+	}
+	progressed = true
+
+	for {
+		select {
+		case <-ctx.Done():
+			return progressed, ctx.Err()
+		case err := <-sub.Err():
+			return progressed, fmt.Errorf("head subscription failed: %v", err)
+		case head := <-heads:
+			if err := f.process(ctx, backend, head, events); err != nil {
+				return progressed, err
+			}
+		}
+	}
+}
+
+func (f *Follower) poll(ctx context.Context, backend Backend, events chan<- Event) (progressed bool, err error) {
+	ticker := time.NewTicker(f.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.pollOnce(ctx, backend, events); err != nil {
+			return progressed, err
+		}
+		progressed = true
+
+		select {
+		case <-ctx.Done():
+			return progressed, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *Follower) pollOnce(ctx context.Context, backend Backend, events chan<- Event) error {
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get head: %v", err)
+	}
+	return f.process(ctx, backend, head, events)
+}
+
+// at returns the tracked header with the given number
+func (f *Follower) at(number uint64) *types.Header {
+	if len(f.chain) == 0 {
+		return nil
+	}
+	first := f.chain[0].Number.Uint64()
+	if number < first || number-first >= uint64(len(f.chain)) {
+		return nil
+	}
+	return f.chain[number-first]
+}
+
+// process links a new head to the tracked chain, emitting a Reorg and any missed Blocks
+func (f *Follower) process(ctx context.Context, backend Backend, head *types.Header, events chan<- Event) error {
+	if len(f.chain) == 0 {
+		f.chain = []*types.Header{head}
+		return emit(ctx, events, Event{Type: EventBlock, Header: head})
+	}
+	if known := f.at(head.Number.Uint64()); known != nil && known.Hash() == head.Hash() {
+		return nil
+	}
+
+	// A head that does not extend the tracked chain is either a reorg or stale, e.g. from a
+	// node that is behind or a notification queued before the catch-up. One below the tracked
+	// range cannot be linked to it, and one within it only counts if it is still canonical.
+	if head.Number.Cmp(f.chain[0].Number) < 0 {
+		return nil
+	}
+	if tip := f.chain[len(f.chain)-1]; head.Number.Cmp(tip.Number) <= 0 {
+		canonical, err := backend.HeaderByNumber(ctx, head.Number)
+		if err != nil {
+			return fmt.Errorf("failed to get header %s: %v", head.Number, err)
+		}
+		if canonical.Hash() != head.Hash() {
+			return nil
+		}
+	}
+
+	// Walk back by parent hash until the new branch meets the tracked chain
+	branch := []*types.Header{head}
+	var ancestor *types.Header
+	for cur := head; cur.Number.Sign() > 0; {
+		parentNumber := cur.Number.Uint64() - 1
+		if known := f.at(parentNumber); known != nil && known.Hash() == cur.ParentHash {
+			ancestor = known
+			break
+		}
+		if parentNumber < f.chain[0].Number.Uint64() {
+			break
+		}
+		parent, err := backend.HeaderByHash(ctx, cur.ParentHash)
+		if err != nil {
+			return fmt.Errorf("failed to get header %s: %v", cur.ParentHash.Hex(), err)
+		}
+		branch = append([]*types.Header{parent}, branch...)
+		cur = parent
+	}
+
+	// Everything above the ancestor is no longer canonical
+	keep := 0
+	if ancestor != nil {
+		keep = int(ancestor.Number.Uint64()-f.chain[0].Number.Uint64()) + 1
+	}
+	if keep < len(f.chain) {
+		removed := make([]*types.Header, 0, len(f.chain)-keep)
+		for i := len(f.chain) - 1; i >= keep; i-- {
+			removed = append(removed, f.chain[i])
+		}
+		if err := emit(ctx, events, Event{Type: EventReorg, Ancestor: ancestor, Removed: removed}); err != nil {
+			return err
+		}
+	}
+	f.chain = append(f.chain[:keep], branch...)
+
+	for _, header := range branch {
+		if err := emit(ctx, events, Event{Type: EventBlock, Header: header}); err != nil {
+			return err
+		}
+	}
+	if len(f.chain) > f.config.Depth {
+		f.chain = append([]*types.Header(nil), f.chain[len(f.chain)-f.config.Depth:]...)
+	}
+	return nil
+}
+
+func emit(ctx context.Context, events chan<- Event, event Event) error {
+	select {
+	case events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
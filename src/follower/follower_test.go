@@ -0,0 +1,282 @@
+package follower
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeBackend serves headers from memory; canonical maps heights to the node's current chain
+type fakeBackend struct {
+	headers   map[common.Hash]*types.Header
+	canonical map[uint64]*types.Header
+	names     map[common.Hash]string
+}
+
+func newFakeBackend() *fakeBackend {
+	b := &fakeBackend{
+		headers:   make(map[common.Hash]*types.Header),
+		canonical: make(map[uint64]*types.Header),
+		names:     make(map[common.Hash]string),
+	}
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: new(big.Int)}
+	b.add(genesis, "0", true)
+	return b
+}
+
+func (b *fakeBackend) add(header *types.Header, name string, canonical bool) *types.Header {
+	b.headers[header.Hash()] = header
+	b.names[header.Hash()] = name
+	if canonical {
+		b.canonical[header.Number.Uint64()] = header
+	}
+	return header
+}
+
+// child creates the block after parent on a branch; it becomes canonical unless side is set
+func (b *fakeBackend) child(parent *types.Header, branch string, side bool) *types.Header {
+	number := new(big.Int).Add(parent.Number, common.Big1)
+	header := &types.Header{
+		Number:     number,
+		ParentHash: parent.Hash(),
+		Difficulty: new(big.Int),
+		Extra:      []byte(branch),
+	}
+	return b.add(header, fmt.Sprintf("%s%s", number, branch), !side)
+}
+
+// branch extends parent by n canonical blocks and returns them
+func (b *fakeBackend) branch(parent *types.Header, name string, n int) []*types.Header {
+	var headers []*types.Header
+	for i := 0; i < n; i++ {
+		parent = b.child(parent, name, false)
+		headers = append(headers, parent)
+	}
+	return headers
+}
+
+func (b *fakeBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		var head *types.Header
+		for _, header := range b.canonical {
+			if head == nil || header.Number.Cmp(head.Number) > 0 {
+				head = header
+			}
+		}
+		return head, nil
+	}
+	header, ok := b.canonical[number.Uint64()]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return header, nil
+}
+
+func (b *fakeBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	header, ok := b.headers[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return header, nil
+}
+
+func (b *fakeBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+// describe renders an event as e.g. "block 3a" or "reorg 3a,2a @1a"
+func (b *fakeBackend) describe(event Event) string {
+	if event.Type == EventBlock {
+		return "block " + b.names[event.Header.Hash()]
+	}
+	removed := make([]string, len(event.Removed))
+	for i, header := range event.Removed {
+		removed[i] = b.names[header.Hash()]
+	}
+	ancestor := "-"
+	if event.Ancestor != nil {
+		ancestor = b.names[event.Ancestor.Hash()]
+	}
+	return fmt.Sprintf("reorg %s @%s", strings.Join(removed, ","), ancestor)
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth int
+		// heads returns the heads to deliver in order; events are only checked for the last one
+		heads  func(b *fakeBackend) (earlier []*types.Header, last *types.Header)
+		want   []string
+		tracks []string // tracked chain afterwards
+	}{
+		{
+			name: "extend",
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 3)
+				return a[:2], a[2]
+			},
+			want:   []string{"block 3a"},
+			tracks: []string{"1a", "2a", "3a"},
+		},
+		{
+			name: "gap fill",
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 5)
+				return a[:2], a[4]
+			},
+			want:   []string{"block 3a", "block 4a", "block 5a"},
+			tracks: []string{"1a", "2a", "3a", "4a", "5a"},
+		},
+		{
+			name: "repeated head",
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 3)
+				return a, a[2]
+			},
+			want:   nil,
+			tracks: []string{"1a", "2a", "3a"},
+		},
+		{
+			name: "one-block reorg at the same height",
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 3)
+				return a, b.child(a[1], "b", false)
+			},
+			want:   []string{"reorg 3a @2a", "block 3b"},
+			tracks: []string{"1a", "2a", "3b"},
+		},
+		{
+			name: "one-block reorg with a longer branch",
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 3)
+				fork := b.branch(a[1], "b", 2)
+				return a, fork[1]
+			},
+			want:   []string{"reorg 3a @2a", "block 3b", "block 4b"},
+			tracks: []string{"1a", "2a", "3b", "4b"},
+		},
+		{
+			name: "reorg to a shorter branch",
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 4)
+				delete(b.canonical, 4)
+				return a, b.child(a[1], "b", false)
+			},
+			want:   []string{"reorg 4a,3a @2a", "block 3b"},
+			tracks: []string{"1a", "2a", "3b"},
+		},
+		{
+			name:  "reorg deeper than tracked",
+			depth: 2,
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 3)
+				fork := b.branch(a[0], "b", 3)
+				return a, fork[2]
+			},
+			want:   []string{"reorg 3a,2a @-", "block 2b", "block 3b", "block 4b"},
+			tracks: []string{"3b", "4b"},
+		},
+		{
+			name:  "stale head below the tracked range",
+			depth: 2,
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 4)
+				return a, a[0]
+			},
+			want:   nil,
+			tracks: []string{"3a", "4a"},
+		},
+		{
+			name:  "stale head from another branch below the tracked range",
+			depth: 2,
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 4)
+				return a, b.child(a[0], "b", true)
+			},
+			want:   nil,
+			tracks: []string{"3a", "4a"},
+		},
+		{
+			name: "stale head within the tracked range",
+			heads: func(b *fakeBackend) ([]*types.Header, *types.Header) {
+				a := b.branch(b.canonical[0], "a", 3)
+				return a, b.child(a[0], "b", true)
+			},
+			want:   nil,
+			tracks: []string{"1a", "2a", "3a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeBackend()
+			earlier, last := tt.heads(backend)
+			f := New(nil, Config{Depth: tt.depth})
+			ctx := context.Background()
+
+			events := make(chan Event, 64)
+			for _, head := range earlier {
+				if err := f.process(ctx, backend, head, events); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for len(events) > 0 {
+				<-events
+			}
+			if err := f.process(ctx, backend, last, events); err != nil {
+				t.Fatal(err)
+			}
+			close(events)
+
+			var got []string
+			for event := range events {
+				got = append(got, backend.describe(event))
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("events %q, want %q", got, tt.want)
+			}
+
+			var tracked []string
+			for i, header := range f.chain {
+				tracked = append(tracked, backend.names[header.Hash()])
+				if i > 0 && header.ParentHash != f.chain[i-1].Hash() {
+					t.Errorf("tracked chain is not linked at %s", backend.names[header.Hash()])
+				}
+			}
+			if strings.Join(tracked, " ") != strings.Join(tt.tracks, " ") {
+				t.Errorf("tracked %v, want %v", tracked, tt.tracks)
+			}
+		})
+	}
+}
+
+func TestPollOnceFollowsTheHead(t *testing.T) {
+	backend := newFakeBackend()
+	a := backend.branch(backend.canonical[0], "a", 2)
+	f := New(nil, Config{})
+	ctx := context.Background()
+	events := make(chan Event, 16)
+
+	if err := f.pollOnce(ctx, backend, events); err != nil {
+		t.Fatal(err)
+	}
+	backend.branch(a[1], "a", 2)
+	if err := f.pollOnce(ctx, backend, events); err != nil {
+		t.Fatal(err)
+	}
+	close(events)
+
+	var got []string
+	for event := range events {
+		got = append(got, backend.describe(event))
+	}
+	want := []string{"block 2a", "block 3a", "block 4a"}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("events %q, want %q", got, want)
+	}
+}
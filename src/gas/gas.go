@@ -0,0 +1,163 @@
+package gas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Fallback gas limits used only when the node cannot estimate
+const (
+	DefaultTransferGas uint64 = 21000
+	DefaultCallGas     uint64 = 100000
+	DefaultDeployGas   uint64 = 300000
+)
+
+// DefaultMultiplier is the safety margin applied on top of the node's estimate
+const DefaultMultiplier = 1.2
+
+// RevertError is returned when the estimated call would revert
+type RevertError struct {
+	Reason string
+	Data   []byte
+	Err    error
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("execution reverted: %s", e.Reason)
+	}
+	return fmt.Sprintf("execution reverted: %v", e.Err)
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.Err
+}
+
+// Estimate is the result of a gas estimation
+type Estimate struct {
+	Estimated      uint64 // raw estimate from the node, 0 when falling back
+	Limit          uint64 // gas limit to put in the transaction
+	Fallback       bool   // true when Limit is the default because estimation failed
+	FallbackReason error  // why estimation failed, set when Fallback is true
+}
+
+// Estimator estimates gas limits with a safety multiplier
+type Estimator struct {
+	backend    ethereum.GasEstimator
+	multiplier float64
+}
+
+// NewEstimator creates an estimator; a multiplier below 1 is replaced by DefaultMultiplier
+func NewEstimator(backend ethereum.GasEstimator, multiplier float64) *Estimator {
+	if multiplier < 1 {
+		multiplier = DefaultMultiplier
+	}
+	return &Estimator{
+		backend:    backend,
+		multiplier: multiplier,
+	}
+}
+
+// MultiplierFromEnv reads GAS_MULTIPLIER, falling back to DefaultMultiplier
+func MultiplierFromEnv() float64 {
+	value := os.Getenv("GAS_MULTIPLIER")
+	if value == "" {
+		return DefaultMultiplier
+	}
+	multiplier, err := strconv.ParseFloat(value, 64)
+	if err != nil || multiplier < 1 {
+		return DefaultMultiplier
+	}
+	return multiplier
+}
+
+// Multiplier returns the configured safety multiplier
+func (e *Estimator) Multiplier() float64 {
+	return e.multiplier
+}
+
+// Estimate estimates the gas for msg and applies the safety multiplier.
+// A revert is returned as *RevertError; any other failure falls back to fallback.
+func (e *Estimator) Estimate(ctx context.Context, msg ethereum.CallMsg, fallback uint64) (*Estimate, error) {
+	estimated, err := e.backend.EstimateGas(ctx, msg)
+	if err != nil {
+		if revert := AsRevert(err); revert != nil {
+			return nil, revert
+		}
+		return &Estimate{
+			Limit:          fallback,
+			Fallback:       true,
+			FallbackReason: err,
+		}, nil
+	}
+
+	return &Estimate{
+		Estimated: estimated,
+		Limit:     e.apply(estimated),
+	}, nil
+}
+
+// apply scales an estimate by the multiplier using integer per-mille arithmetic
+func (e *Estimator) apply(estimated uint64) uint64 {
+	perMille := new(big.Int).SetUint64(uint64(e.multiplier*1000 + 0.5))
+	limit := new(big.Int).Mul(new(big.Int).SetUint64(estimated), perMille)
+	limit.Div(limit, big.NewInt(1000))
+	if !limit.IsUint64() {
+		return estimated
+	}
+	return limit.Uint64()
+}
+
+// AsRevert converts an RPC error into a *RevertError if it describes a revert
+func AsRevert(err error) *RevertError {
+	if err == nil {
+		return nil
+	}
+
+	var revert *RevertError
+	if errors.As(err, &revert) {
+		return revert
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data := revertData(dataErr.ErrorData()); len(data) > 0 {
+			reason, unpackErr := abi.UnpackRevert(data)
+			if unpackErr != nil {
+				reason = ""
+			}
+			return &RevertError{Reason: reason, Data: data, Err: err}
+		}
+	}
+
+	if strings.Contains(err.Error(), "execution reverted") {
+		return &RevertError{Err: err}
+	}
+	return nil
+}
+
+// revertData extracts the revert payload from JSON-RPC error data
+func revertData(data interface{}) []byte {
+	switch v := data.(type) {
+	case string:
+		decoded, err := hexutil.Decode(v)
+		if err != nil {
+			return nil
+		}
+		return decoded
+	case []byte:
+		return v
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,153 @@
+package gas
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+// fakeEstimator returns a fixed estimate or error
+type fakeEstimator struct {
+	gas uint64
+	err error
+}
+
+func (f fakeEstimator) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return f.gas, f.err
+}
+
+// dataError is an RPC error carrying revert data, like the one returned by a node
+type dataError struct {
+	data interface{}
+}
+
+func (e dataError) Error() string          { return "execution reverted" }
+func (e dataError) ErrorData() interface{} { return e.data }
+
+// revertCannotBeNegative is Error("Counter cannot be negative") ABI-encoded
+const revertCannotBeNegative = "0x08c379a0" +
+	"0000000000000000000000000000000000000000000000000000000000000020" +
+	"000000000000000000000000000000000000000000000000000000000000001a" +
+	"436f756e7465722063616e6e6f74206265206e65676174697665000000000000"
+
+func TestEstimate(t *testing.T) {
+	unavailable := errors.New("method not found")
+	tests := []struct {
+		name       string
+		backend    fakeEstimator
+		multiplier float64
+		want       Estimate
+		wantRevert string
+	}{
+		{name: "default multiplier", backend: fakeEstimator{gas: 21000}, multiplier: DefaultMultiplier,
+			want: Estimate{Estimated: 21000, Limit: 25200}},
+		{name: "custom multiplier", backend: fakeEstimator{gas: 100000}, multiplier: 1.5,
+			want: Estimate{Estimated: 100000, Limit: 150000}},
+		{name: "no margin", backend: fakeEstimator{gas: 43210}, multiplier: 1,
+			want: Estimate{Estimated: 43210, Limit: 43210}},
+		{name: "multiplier below one", backend: fakeEstimator{gas: 50000}, multiplier: 0.5,
+			want: Estimate{Estimated: 50000, Limit: 60000}},
+		{name: "rounds down", backend: fakeEstimator{gas: 21001}, multiplier: DefaultMultiplier,
+			want: Estimate{Estimated: 21001, Limit: 25201}},
+		{name: "estimation unavailable", backend: fakeEstimator{err: unavailable}, multiplier: DefaultMultiplier,
+			want: Estimate{Limit: DefaultCallGas, Fallback: true, FallbackReason: unavailable}},
+		{name: "revert with reason", backend: fakeEstimator{err: dataError{revertCannotBeNegative}}, multiplier: DefaultMultiplier,
+			wantRevert: "execution reverted: Counter cannot be negative"},
+		{name: "revert without data", backend: fakeEstimator{err: errors.New("execution reverted")}, multiplier: DefaultMultiplier,
+			wantRevert: "execution reverted: execution reverted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate, err := NewEstimator(tt.backend, tt.multiplier).Estimate(context.Background(), ethereum.CallMsg{}, DefaultCallGas)
+			if tt.wantRevert != "" {
+				var revert *RevertError
+				if !errors.As(err, &revert) {
+					t.Fatalf("got %+v, %v, want a revert", estimate, err)
+				}
+				if err.Error() != tt.wantRevert {
+					t.Fatalf("error %q, want %q", err, tt.wantRevert)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *estimate != tt.want {
+				t.Fatalf("estimate %+v, want %+v", *estimate, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiplierFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", DefaultMultiplier},
+		{"1.5", 1.5},
+		{"1", 1},
+		{"0.9", DefaultMultiplier},
+		{"fast", DefaultMultiplier},
+	}
+	for _, tt := range tests {
+		t.Setenv("GAS_MULTIPLIER", tt.value)
+		if got := MultiplierFromEnv(); got != tt.want {
+			t.Errorf("GAS_MULTIPLIER=%q gives %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateRevertOnChain(t *testing.T) {
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(0), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, _, err := contracts.DeployCounter(auth, chain.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := contracts.CounterMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimator := NewEstimator(chain.Client(), DefaultMultiplier)
+	ctx := context.Background()
+
+	increment, err := parsed.Pack("increment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimate, err := estimator.Estimate(ctx, ethereum.CallMsg{From: chain.Address(0), To: &address, Data: increment}, DefaultCallGas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Fallback || estimate.Estimated == 0 || estimate.Limit <= estimate.Estimated {
+		t.Fatalf("increment estimate %+v, want a node estimate with margin", *estimate)
+	}
+
+	// Decrementing at zero reverts; the error carries the reason instead of falling back
+	decrement, err := parsed.Pack("decrement")
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimate, err = estimator.Estimate(ctx, ethereum.CallMsg{From: chain.Address(0), To: &address, Data: decrement}, DefaultCallGas)
+	revert := AsRevert(err)
+	if revert == nil {
+		t.Fatalf("got %+v, %v, want a revert", estimate, err)
+	}
+	if revert.Reason != "Counter cannot be negative" {
+		t.Fatalf("revert reason %q, want %q", revert.Reason, "Counter cannot be negative")
+	}
+}
@@ -0,0 +1,299 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/txpipe"
+)
+
+// DefaultReorgDepth is how many block hashes are kept to find a common ancestor after a reorg
+const DefaultReorgDepth = 128
+
+// ErrReorgTooDeep is returned when no common ancestor is found within the kept block hashes
+var ErrReorgTooDeep = errors.New("reorg deeper than the tracked block history")
+
+// Backend is everything the indexer needs from a node besides fetching blocks
+type Backend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// BlockFetcher returns a full block by number; nil means the latest block
+type BlockFetcher func(number *uint64) (*types.Block, error)
+
+// Config selects what gets indexed
+type Config struct {
+	Watch         []common.Address // accounts whose transactions are recorded
+	Counters      []common.Address // Counter deployments whose events are recorded
+	StartBlock    uint64           // first block to follow when there is no checkpoint
+	Confirmations uint64           // blocks to stay behind the head
+	ReorgDepth    uint64           // block hashes kept for reorg detection (default DefaultReorgDepth)
+}
+
+// Indexer scans blocks for activity of watched accounts and Counter contracts
+type Indexer struct {
+	backend    Backend
+	fetch      BlockFetcher
+	store      *Store
+	config     Config
+	watch      map[common.Address]bool
+	counterABI *abi.ABI
+}
+
+// New creates an indexer writing to store
+func New(backend Backend, fetch BlockFetcher, store *Store, config Config) (*Indexer, error) {
+	counterABI, err := contracts.CounterMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Counter ABI: %v", err)
+	}
+	if config.ReorgDepth == 0 {
+		config.ReorgDepth = DefaultReorgDepth
+	}
+
+	watch := make(map[common.Address]bool)
+	for _, addr := range config.Watch {
+		watch[addr] = true
+	}
+
+	return &Indexer{
+		backend:    backend,
+		fetch:      fetch,
+		store:      store,
+		config:     config,
+		watch:      watch,
+		counterABI: counterABI,
+	}, nil
+}
+
+// Range indexes blocks from..to (inclusive) without moving the follow checkpoint.
+// Re-indexing a block overwrites its previous entries.
+func (ix *Indexer) Range(ctx context.Context, from, to uint64) (int, error) {
+	total := 0
+	for number := from; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		block, err := ix.fetch(&number)
+		if err != nil {
+			return total, fmt.Errorf("failed to fetch block %d: %v", number, err)
+		}
+		activities, err := ix.scan(ctx, block)
+		if err != nil {
+			return total, err
+		}
+		if err := ix.store.Commit(number, block.Hash(), activities, false, ix.config.ReorgDepth); err != nil {
+			return total, fmt.Errorf("failed to store block %d: %v", number, err)
+		}
+		total += len(activities)
+	}
+	return total, nil
+}
+
+// Sync indexes every block from the checkpoint up to the confirmed head, rolling back
+// first if the chain reorganised underneath the checkpoint. It returns the blocks processed.
+func (ix *Indexer) Sync(ctx context.Context) (uint64, error) {
+	head, err := ix.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get head: %v", err)
+	}
+	if head.Number.Uint64() < ix.config.Confirmations {
+		return 0, nil
+	}
+	target := head.Number.Uint64() - ix.config.Confirmations
+
+	next := ix.config.StartBlock
+	cp, ok, err := ix.store.Checkpoint()
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		next = cp.Number + 1
+		// A reorg that replaced the checkpoint block without growing the chain yet
+		// is only visible by re-checking the checkpoint itself
+		if cp.Number <= head.Number.Uint64() {
+			ancestor, err := ix.commonAncestor(ctx, cp.Number)
+			if err != nil {
+				return 0, err
+			}
+			if ancestor != cp.Number {
+				removed, err := ix.store.Rollback(ancestor)
+				if err != nil {
+					return 0, fmt.Errorf("failed to roll back to block %d: %v", ancestor, err)
+				}
+				fmt.Printf("🔀 Reorg detected at block %d, rolled back to %d (%d activities removed)\n", cp.Number, ancestor, removed)
+				next = ancestor + 1
+			}
+		}
+	}
+
+	processed := uint64(0)
+	for next <= target {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+
+		number := next
+		block, err := ix.fetch(&number)
+		if err != nil {
+			return processed, fmt.Errorf("failed to fetch block %d: %v", number, err)
+		}
+
+		if number > 0 {
+			parent, known, err := ix.store.BlockHash(number - 1)
+			if err != nil {
+				return processed, err
+			}
+			if known && parent != block.ParentHash() {
+				ancestor, err := ix.commonAncestor(ctx, number-1)
+				if err != nil {
+					return processed, err
+				}
+				removed, err := ix.store.Rollback(ancestor)
+				if err != nil {
+					return processed, fmt.Errorf("failed to roll back to block %d: %v", ancestor, err)
+				}
+				fmt.Printf("🔀 Reorg detected at block %d, rolled back to %d (%d activities removed)\n", number, ancestor, removed)
+				next = ancestor + 1
+				continue
+			}
+		}
+
+		activities, err := ix.scan(ctx, block)
+		if err != nil {
+			return processed, err
+		}
+		if err := ix.store.Commit(number, block.Hash(), activities, true, ix.config.ReorgDepth); err != nil {
+			return processed, fmt.Errorf("failed to store block %d: %v", number, err)
+		}
+		for _, activity := range activities {
+			printActivity(activity)
+		}
+		processed++
+		next = number + 1
+	}
+	return processed, nil
+}
+
+// commonAncestor walks back from number until the stored hash matches the canonical chain
+func (ix *Indexer) commonAncestor(ctx context.Context, number uint64) (uint64, error) {
+	for {
+		stored, known, err := ix.store.BlockHash(number)
+		if err != nil {
+			return 0, err
+		}
+		if !known {
+			return 0, ErrReorgTooDeep
+		}
+		header, err := ix.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return 0, fmt.Errorf("failed to get header %d: %v", number, err)
+		}
+		if header.Hash() == stored {
+			return number, nil
+		}
+		if number == 0 {
+			return 0, ErrReorgTooDeep
+		}
+		number--
+	}
+}
+
+// scan extracts watched transactions and Counter events from a block
+func (ix *Indexer) scan(ctx context.Context, block *types.Block) ([]*Activity, error) {
+	var activities []*Activity
+	blockTime := time.Unix(int64(block.Time()), 0).UTC()
+
+	if len(ix.watch) > 0 {
+		for i, tx := range block.Transactions() {
+			from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to recover sender of %s: %v", tx.Hash().Hex(), err)
+			}
+			toWatched := tx.To() != nil && ix.watch[*tx.To()]
+			if !ix.watch[from] && !toWatched {
+				continue
+			}
+
+			receipt, err := ix.backend.TransactionReceipt(ctx, tx.Hash())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get receipt of %s: %v", tx.Hash().Hex(), err)
+			}
+
+			activity := &Activity{
+				Kind:        KindTransaction,
+				BlockNumber: block.NumberU64(),
+				BlockHash:   block.Hash().Hex(),
+				Time:        blockTime,
+				TxHash:      tx.Hash().Hex(),
+				TxIndex:     uint(i),
+				From:        from.Hex(),
+				Value:       tx.Value().String(),
+				Status:      receipt.Status,
+			}
+			if tx.To() != nil {
+				activity.To = tx.To().Hex()
+			} else {
+				activity.Kind = KindCreation
+				activity.Contract = receipt.ContractAddress.Hex()
+			}
+			activities = append(activities, activity)
+		}
+	}
+
+	if len(ix.config.Counters) > 0 {
+		hash := block.Hash()
+		logs, err := ix.backend.FilterLogs(ctx, ethereum.FilterQuery{
+			BlockHash: &hash,
+			Addresses: ix.config.Counters,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get logs of block %d: %v", block.NumberU64(), err)
+		}
+
+		ptrs := make([]*types.Log, len(logs))
+		for i := range logs {
+			ptrs[i] = &logs[i]
+		}
+		for _, event := range txpipe.DecodeEvents(ix.counterABI, ptrs) {
+			fields := make(map[string]string, len(event.Fields))
+			for name, value := range event.Fields {
+				fields[name] = fmt.Sprint(value)
+			}
+			activities = append(activities, &Activity{
+				Kind:        KindEvent,
+				BlockNumber: event.Log.BlockNumber,
+				BlockHash:   event.Log.BlockHash.Hex(),
+				Time:        blockTime,
+				TxHash:      event.Log.TxHash.Hex(),
+				TxIndex:     event.Log.TxIndex,
+				LogIndex:    event.Log.Index,
+				Contract:    event.Log.Address.Hex(),
+				Status:      types.ReceiptStatusSuccessful,
+				Event:       event.Name,
+				Fields:      fields,
+			})
+		}
+	}
+	return activities, nil
+}
+
+func printActivity(a *Activity) {
+	switch a.Kind {
+	case KindCreation:
+		fmt.Printf("🏗️  #%d %s created %s (tx %s)\n", a.BlockNumber, a.From, a.Contract, a.TxHash)
+	case KindEvent:
+		fmt.Printf("📣 #%d %s %s %v (tx %s)\n", a.BlockNumber, a.Contract, a.Event, a.Fields, a.TxHash)
+	default:
+		fmt.Printf("💸 #%d %s → %s %s wei (tx %s)\n", a.BlockNumber, a.From, a.To, a.Value, a.TxHash)
+	}
+}
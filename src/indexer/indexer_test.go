@@ -0,0 +1,351 @@
+package indexer
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/simchain"
+	bolt "go.etcd.io/bbolt"
+)
+
+// indexEnv is a simulated chain with a Counter deployed by account 0 in block 1
+type indexEnv struct {
+	chain   *simchain.Chain
+	counter common.Address
+	store   *Store
+	ix      *Indexer
+}
+
+// newIndexEnv indexes transactions of account 1 and events of the Counter
+func newIndexEnv(t *testing.T) *indexEnv {
+	t.Helper()
+	chain, err := simchain.New(3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+
+	auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(0), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter, _, _, err := contracts.DeployCounter(auth, chain.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(filepath.Join(t.TempDir(), "indexer.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	client := chain.Client()
+	fetch := func(number *uint64) (*types.Block, error) {
+		if number == nil {
+			return client.BlockByNumber(context.Background(), nil)
+		}
+		return client.BlockByNumber(context.Background(), new(big.Int).SetUint64(*number))
+	}
+	ix, err := New(client, fetch, store, Config{
+		Watch:    []common.Address{chain.Address(1)},
+		Counters: []common.Address{counter},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &indexEnv{chain: chain, counter: counter, store: store, ix: ix}
+}
+
+// send transfers 1 gwei from account 1 to account 2 and mines it
+func (e *indexEnv) send(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+	client := e.chain.Client()
+	nonce, err := client.PendingNonceAt(ctx, e.chain.Address(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   simchain.ChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(10 * params.GWei),
+		Gas:       21000,
+		To:        &[]common.Address{e.chain.Address(2)}[0],
+		Value:     big.NewInt(params.GWei),
+	})
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(simchain.ChainID), e.chain.Key(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendTransaction(ctx, signed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// increment calls the Counter from account 0 and mines it
+func (e *indexEnv) increment(t *testing.T) {
+	t.Helper()
+	counter, err := contracts.NewCounter(e.counter, e.chain.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(e.chain.Key(0), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := counter.Increment(auth); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fork replaces every block after number with empty blocks up to head. Transactions of
+// the dropped blocks return to the pool, so they are all mined in block number+1.
+func (e *indexEnv) fork(t *testing.T, number, head uint64) {
+	t.Helper()
+	parent, err := e.chain.Client().HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.chain.Backend().Fork(parent.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	for i := number; i < head; i++ {
+		e.chain.Backend().Commit()
+	}
+}
+
+// expectCanonical checks that the activities are exactly those at blocks, in order, and
+// that each one is on the canonical chain
+func (e *indexEnv) expectCanonical(t *testing.T, activities []*Activity, blocks ...uint64) {
+	t.Helper()
+	if len(activities) != len(blocks) {
+		t.Fatalf("got %d activities, want %d", len(activities), len(blocks))
+	}
+	for i, activity := range activities {
+		header, err := e.chain.Client().HeaderByNumber(context.Background(), new(big.Int).SetUint64(activity.BlockNumber))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if activity.BlockNumber != blocks[i] || activity.BlockHash != header.Hash().Hex() {
+			t.Fatalf("activity %d is in block %d (%s), want canonical block %d (%s)",
+				i, activity.BlockNumber, activity.BlockHash, blocks[i], header.Hash().Hex())
+		}
+	}
+}
+
+// activityCount counts the raw entries of the activity and address buckets
+func activityCount(t *testing.T, s *Store) (activities, index int) {
+	t.Helper()
+	err := s.db.View(func(tx *bolt.Tx) error {
+		activities = tx.Bucket(activityBucket).Stats().KeyN
+		index = tx.Bucket(addressBucket).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return activities, index
+}
+
+func TestSyncRollsBackAfterFork(t *testing.T) {
+	e := newIndexEnv(t)
+	ctx := context.Background()
+
+	e.send(t)      // block 2
+	e.increment(t) // block 3
+	e.send(t)      // block 4
+	if processed, err := e.ix.Sync(ctx); err != nil || processed != 5 {
+		t.Fatalf("processed %d blocks, %v, want 5", processed, err)
+	}
+	sender, err := e.store.ByAddress(e.chain.Address(1), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.expectCanonical(t, sender, 2, 4)
+	events, err := e.store.ByAddress(e.counter, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.expectCanonical(t, events, 3)
+	if activities, index := activityCount(t, e.store); activities != 3 || index != 5 {
+		t.Fatalf("%d activities with %d index entries, want 3 with 5", activities, index)
+	}
+
+	// Blocks 2 to 4 are replaced by a longer branch that mines all three transactions in block 2
+	e.fork(t, 1, 5)
+	if processed, err := e.ix.Sync(ctx); err != nil || processed != 4 {
+		t.Fatalf("processed %d blocks after the fork, %v, want 4", processed, err)
+	}
+
+	sender, err = e.store.ByAddress(e.chain.Address(1), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.expectCanonical(t, sender, 2, 2)
+	recipient, err := e.store.ByAddress(e.chain.Address(2), 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipient) != 0 {
+		t.Fatalf("orphaned activities above block 2: %v", recipient)
+	}
+	events, err = e.store.ByAddress(e.counter, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.expectCanonical(t, events, 2)
+	if activities, index := activityCount(t, e.store); activities != 3 || index != 5 {
+		t.Fatalf("%d activities with %d index entries, want 3 with 5", activities, index)
+	}
+
+	head, err := e.chain.Client().HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, ok, err := e.store.Checkpoint()
+	if err != nil || !ok {
+		t.Fatalf("no checkpoint: %v", err)
+	}
+	if cp.Number != 5 || cp.Hash != head.Hash() {
+		t.Fatalf("checkpoint %d %s, want the head %d %s", cp.Number, cp.Hash.Hex(), head.Number, head.Hash().Hex())
+	}
+	for number := uint64(0); number <= 5; number++ {
+		header, err := e.chain.Client().HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash, known, err := e.store.BlockHash(number); err != nil || !known || hash != header.Hash() {
+			t.Fatalf("block %d hash %s (known %v), want %s", number, hash.Hex(), known, header.Hash().Hex())
+		}
+	}
+}
+
+func TestSyncReplacedCheckpoint(t *testing.T) {
+	e := newIndexEnv(t)
+	ctx := context.Background()
+
+	e.send(t) // block 2
+	if _, err := e.ix.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A different block 2 at the same height, mining the transfer again
+	if err := e.chain.Backend().Fork(mustHeader(t, e, 1).Hash()); err != nil {
+		t.Fatal(err)
+	}
+	e.chain.Backend().Commit()
+	if processed, err := e.ix.Sync(ctx); err != nil || processed != 1 {
+		t.Fatalf("processed %d blocks, %v, want 1", processed, err)
+	}
+	sender, err := e.store.ByAddress(e.chain.Address(1), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.expectCanonical(t, sender, 2)
+	if cp, _, err := e.store.Checkpoint(); err != nil || cp.Hash != mustHeader(t, e, 2).Hash() {
+		t.Fatalf("checkpoint %+v, %v, want the new block 2", cp, err)
+	}
+}
+
+func mustHeader(t *testing.T, e *indexEnv, number uint64) *types.Header {
+	t.Helper()
+	header, err := e.chain.Client().HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return header
+}
+
+func TestRangeReplacesBlocks(t *testing.T) {
+	e := newIndexEnv(t)
+	ctx := context.Background()
+
+	e.send(t)      // block 2
+	e.increment(t) // block 3
+	e.send(t)      // block 4
+	if total, err := e.ix.Range(ctx, 2, 4); err != nil || total != 3 {
+		t.Fatalf("indexed %d activities, %v, want 3", total, err)
+	}
+	// Range does not follow the chain
+	if _, ok, err := e.store.Checkpoint(); err != nil || ok {
+		t.Fatalf("range moved the checkpoint: %v", err)
+	}
+
+	// Re-indexing the replaced blocks drops what they held before
+	e.fork(t, 1, 4)
+	if total, err := e.ix.Range(ctx, 2, 4); err != nil || total != 3 {
+		t.Fatalf("re-indexed %d activities, %v, want 3", total, err)
+	}
+	sender, err := e.store.ByAddress(e.chain.Address(1), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.expectCanonical(t, sender, 2, 2)
+	events, err := e.store.ByAddress(e.counter, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.expectCanonical(t, events, 2)
+	if activities, index := activityCount(t, e.store); activities != 3 || index != 5 {
+		t.Fatalf("%d activities with %d index entries, want 3 with 5", activities, index)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "indexer.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	alice := common.HexToAddress("0x0a")
+	bob := common.HexToAddress("0x0b")
+
+	for number := uint64(1); number <= 4; number++ {
+		activities := []*Activity{{Kind: KindTransaction, BlockNumber: number, From: alice.Hex(), To: bob.Hex()}}
+		if err := store.Commit(number, common.Hash{byte(number)}, activities, true, DefaultReorgDepth); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := store.Rollback(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("removed %d activities, want 2", removed)
+	}
+	for _, addr := range []common.Address{alice, bob} {
+		activities, err := store.ByAddress(addr, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(activities) != 2 || activities[1].BlockNumber != 2 {
+			t.Errorf("%s has %d activities after the rollback, want blocks 1 and 2", addr.Hex(), len(activities))
+		}
+	}
+	if activities, index := activityCount(t, store); activities != 2 || index != 4 {
+		t.Errorf("%d activities with %d index entries, want 2 with 4", activities, index)
+	}
+	for number := uint64(1); number <= 4; number++ {
+		_, known, err := store.BlockHash(number)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if known != (number <= 2) {
+			t.Errorf("block %d hash known = %v after rolling back to 2", number, known)
+		}
+	}
+	cp, ok, err := store.Checkpoint()
+	if err != nil || !ok || cp.Number != 2 || cp.Hash != (common.Hash{2}) {
+		t.Errorf("checkpoint %+v, %v, want block 2", cp, err)
+	}
+}
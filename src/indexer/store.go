@@ -0,0 +1,306 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultPath is where the index lives unless INDEXER_DB is set
+const DefaultPath = "data/indexer.db"
+
+// Activity kinds
+const (
+	KindTransaction = "tx"
+	KindCreation    = "create"
+	KindEvent       = "event"
+)
+
+var (
+	activityBucket = []byte("activity")
+	addressBucket  = []byte("by_address")
+	blockBucket    = []byte("blocks")
+	metaBucket     = []byte("meta")
+	checkpointKey  = []byte("checkpoint")
+)
+
+// Activity is one indexed transaction, contract creation or event log
+type Activity struct {
+	Kind        string            `json:"kind"`
+	BlockNumber uint64            `json:"blockNumber"`
+	BlockHash   string            `json:"blockHash"`
+	Time        time.Time         `json:"time"`
+	TxHash      string            `json:"txHash"`
+	TxIndex     uint              `json:"txIndex"`
+	LogIndex    uint              `json:"logIndex,omitempty"` // events only
+	From        string            `json:"from,omitempty"`
+	To          string            `json:"to,omitempty"`
+	Value       string            `json:"value,omitempty"`    // wei
+	Contract    string            `json:"contract,omitempty"` // created contract or event emitter
+	Status      uint64            `json:"status"`
+	Event       string            `json:"event,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"`
+}
+
+// Addresses returns every address the activity is indexed under
+func (a *Activity) Addresses() []common.Address {
+	var addrs []common.Address
+	seen := make(map[common.Address]bool)
+	for _, s := range []string{a.From, a.To, a.Contract} {
+		if s == "" {
+			continue
+		}
+		addr := common.HexToAddress(s)
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// key orders activities by block, transaction, kind and log index
+func (a *Activity) key() []byte {
+	k := make([]byte, 17)
+	binary.BigEndian.PutUint64(k[0:8], a.BlockNumber)
+	binary.BigEndian.PutUint32(k[8:12], uint32(a.TxIndex))
+	if a.Kind == KindEvent {
+		k[12] = 1
+		binary.BigEndian.PutUint32(k[13:17], uint32(a.LogIndex)+1)
+	}
+	return k
+}
+
+// Checkpoint is the last block processed in follow mode
+type Checkpoint struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// Store is a BoltDB-backed activity index
+type Store struct {
+	db *bolt.DB
+}
+
+// PathFromEnv returns INDEXER_DB or DefaultPath
+func PathFromEnv() string {
+	if path := os.Getenv("INDEXER_DB"); path != "" {
+		return path
+	}
+	return DefaultPath
+}
+
+// Open opens (creating if needed) the index database
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index database: %v", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{activityBucket, addressBucket, blockBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialise index database: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func blockKey(number uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, number)
+	return k
+}
+
+// Checkpoint returns the last processed block; ok is false before the first sync
+func (s *Store) Checkpoint() (cp Checkpoint, ok bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(checkpointKey)
+		if data == nil {
+			return nil
+		}
+		ok = true
+		return json.Unmarshal(data, &cp)
+	})
+	return cp, ok, err
+}
+
+// BlockHash returns the hash recorded for a processed block
+func (s *Store) BlockHash(number uint64) (hash common.Hash, ok bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(blockBucket).Get(blockKey(number))
+		if data == nil {
+			return nil
+		}
+		ok = true
+		hash = common.BytesToHash(data)
+		return nil
+	})
+	return hash, ok, err
+}
+
+// Commit replaces the activities of a block. With advance set the block becomes the new
+// checkpoint and its hash is kept for reorg detection; hashes older than keep blocks are pruned.
+func (s *Store) Commit(number uint64, hash common.Hash, activities []*Activity, advance bool, keep uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, err := deleteActivities(tx, number, number); err != nil {
+			return err
+		}
+		for _, activity := range activities {
+			if err := putActivity(tx, activity); err != nil {
+				return err
+			}
+		}
+		if !advance {
+			return nil
+		}
+
+		blocks := tx.Bucket(blockBucket)
+		if err := blocks.Put(blockKey(number), hash.Bytes()); err != nil {
+			return err
+		}
+		if number > keep {
+			if err := blocks.Delete(blockKey(number - keep)); err != nil {
+				return err
+			}
+		}
+
+		encoded, err := json.Marshal(Checkpoint{Number: number, Hash: hash})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Put(checkpointKey, encoded)
+	})
+}
+
+func putActivity(tx *bolt.Tx, activity *Activity) error {
+	encoded, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity: %v", err)
+	}
+	k := activity.key()
+	if err := tx.Bucket(activityBucket).Put(k, encoded); err != nil {
+		return err
+	}
+	for _, addr := range activity.Addresses() {
+		if err := tx.Bucket(addressBucket).Put(append(addr.Bytes(), k...), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback removes everything indexed above block number and makes it the checkpoint again.
+// It returns how many activities were removed.
+func (s *Store) Rollback(number uint64) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		if removed, err = deleteActivities(tx, number+1, math.MaxUint64); err != nil {
+			return err
+		}
+
+		blocks := tx.Bucket(blockBucket)
+		var staleBlocks [][]byte
+		bc := blocks.Cursor()
+		for k, _ := bc.Seek(blockKey(number + 1)); k != nil; k, _ = bc.Next() {
+			staleBlocks = append(staleBlocks, common.CopyBytes(k))
+		}
+		for _, k := range staleBlocks {
+			if err := blocks.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		cp := Checkpoint{Number: number}
+		if hash := blocks.Get(blockKey(number)); hash != nil {
+			cp.Hash = common.BytesToHash(hash)
+		}
+		encoded, err := json.Marshal(cp)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Put(checkpointKey, encoded)
+	})
+	return removed, err
+}
+
+// deleteActivities removes the activities of blocks from..to (inclusive) and their
+// address index entries, returning how many were removed
+func deleteActivities(tx *bolt.Tx, from, to uint64) (int, error) {
+	activities := tx.Bucket(activityBucket)
+	index := tx.Bucket(addressBucket)
+
+	var stale [][]byte
+	c := activities.Cursor()
+	for k, v := c.Seek(blockKey(from)); k != nil && binary.BigEndian.Uint64(k[:8]) <= to; k, v = c.Next() {
+		var activity Activity
+		if err := json.Unmarshal(v, &activity); err != nil {
+			return 0, fmt.Errorf("corrupt activity %x: %v", k, err)
+		}
+		for _, addr := range activity.Addresses() {
+			if err := index.Delete(append(addr.Bytes(), k...)); err != nil {
+				return 0, err
+			}
+		}
+		stale = append(stale, common.CopyBytes(k))
+	}
+	for _, k := range stale {
+		if err := activities.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), nil
+}
+
+// ByAddress returns the activity of an address between two blocks (inclusive), oldest first.
+// to == 0 means no upper bound.
+func (s *Store) ByAddress(address common.Address, from, to uint64) ([]*Activity, error) {
+	var result []*Activity
+	err := s.db.View(func(tx *bolt.Tx) error {
+		activities := tx.Bucket(activityBucket)
+		prefix := address.Bytes()
+
+		c := tx.Bucket(addressBucket).Cursor()
+		for k, _ := c.Seek(append(common.CopyBytes(prefix), blockKey(from)...)); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			activityKey := k[len(prefix):]
+			if to != 0 && binary.BigEndian.Uint64(activityKey[:8]) > to {
+				break
+			}
+			data := activities.Get(activityKey)
+			if data == nil {
+				continue
+			}
+			activity := new(Activity)
+			if err := json.Unmarshal(data, activity); err != nil {
+				return fmt.Errorf("corrupt activity %x: %v", activityKey, err)
+			}
+			result = append(result, activity)
+		}
+		return nil
+	})
+	return result, err
+}
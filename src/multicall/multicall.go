@@ -0,0 +1,246 @@
+package multicall
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/gas"
+)
+
+// Address is the canonical Multicall3 deployment, the same on almost every EVM chain
+var Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// DefaultBatchSize bounds the calls packed into one aggregate3 so the eth_call stays under the node's gas cap
+const DefaultBatchSize = 500
+
+// multicall3ABI holds just aggregate3
+const multicall3ABI = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+var parsedABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// ErrCallFailed is the error of a call that reverted or returned nothing
+var ErrCallFailed = errors.New("call failed")
+
+// Call is one contract read to aggregate
+type Call struct {
+	Target       common.Address
+	AllowFailure bool // when false, a failing call fails the whole aggregate
+	method       abi.Method
+	data         []byte
+}
+
+// NewCall packs a call of method on target, described by a binding's MetaData (e.g. contracts.CounterMetaData)
+func NewCall(meta *bind.MetaData, target common.Address, method string, args ...interface{}) (Call, error) {
+	parsed, err := meta.GetAbi()
+	if err != nil {
+		return Call{}, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	m, ok := parsed.Methods[method]
+	if !ok {
+		return Call{}, fmt.Errorf("method %q not found in ABI", method)
+	}
+	data, err := parsed.Pack(method, args...)
+	if err != nil {
+		return Call{}, fmt.Errorf("failed to pack %s: %v", method, err)
+	}
+	return Call{Target: target, method: m, data: data}, nil
+}
+
+// CounterGetCount is CounterCaller.GetCount on one Counter deployment
+func CounterGetCount(target common.Address) (Call, error) {
+	return NewCall(contracts.CounterMetaData, target, "getCount")
+}
+
+// Optional returns the call with AllowFailure set
+func (c Call) Optional() Call {
+	c.AllowFailure = true
+	return c
+}
+
+// Result is the outcome of one call. Values are the decoded outputs when Success;
+// otherwise Err holds the revert reason.
+type Result struct {
+	Success bool
+	Values  []interface{}
+	Err     error
+}
+
+// Value converts the first output of a successful result to T, e.g. Value[*big.Int](r)
+func Value[T any](r Result) (T, error) {
+	var value T
+	if !r.Success {
+		return value, r.Err
+	}
+	if len(r.Values) == 0 {
+		return value, fmt.Errorf("call has no outputs")
+	}
+	if v, ok := r.Values[0].(T); ok {
+		return v, nil
+	}
+	// Tuples decode to anonymous structs, which convert to a struct T with the same fields
+	from, to := reflect.TypeOf(r.Values[0]), reflect.TypeOf(&value).Elem()
+	if from == nil || from.Kind() != reflect.Struct || !from.ConvertibleTo(to) {
+		return value, fmt.Errorf("cannot decode %T as %v", r.Values[0], to)
+	}
+	return reflect.ValueOf(r.Values[0]).Convert(to).Interface().(T), nil
+}
+
+// Client aggregates contract reads through Multicall3, falling back to one eth_call per
+// read when Multicall3 has no code on the chain
+type Client struct {
+	Address   common.Address
+	BatchSize int
+
+	backend   bind.ContractCaller
+	multicall *bind.BoundContract
+}
+
+// New returns a client for the canonical Multicall3 address
+func New(backend bind.ContractCaller) *Client {
+	return NewAt(Address, backend)
+}
+
+// NewAt returns a client for a Multicall3 deployed elsewhere
+func NewAt(address common.Address, backend bind.ContractCaller) *Client {
+	return &Client{
+		Address:   address,
+		BatchSize: DefaultBatchSize,
+		backend:   backend,
+		multicall: bind.NewBoundContract(address, parsedABI, backend, nil, nil),
+	}
+}
+
+// Aggregate runs the calls with aggregate3, in chunks of BatchSize, and decodes every result.
+// Results are in call order. A failing call without AllowFailure fails the whole aggregate.
+func (c *Client) Aggregate(opts *bind.CallOpts, calls []Call) ([]Result, error) {
+	batchSize := c.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	results := make([]Result, 0, len(calls))
+	for start := 0; start < len(calls); start += batchSize {
+		end := start + batchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		chunk, err := c.aggregate3(opts, calls[start:end])
+		if errors.Is(err, bind.ErrNoCode) {
+			// No Multicall3 on this chain (or at this block)
+			return c.Individually(opts, calls)
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, chunk...)
+	}
+	return results, nil
+}
+
+// Individually runs every call as its own eth_call, with the same semantics as Aggregate
+func (c *Client) Individually(opts *bind.CallOpts, calls []Call) ([]Result, error) {
+	results := make([]Result, len(calls))
+	for i, call := range calls {
+		contract := bind.NewBoundContract(call.Target, abi.ABI{}, c.backend, nil, nil)
+		output, err := contract.CallRaw(opts, call.data)
+		if err != nil {
+			if errors.Is(err, bind.ErrNoCode) {
+				err = fmt.Errorf("%w: no contract code at %s", ErrCallFailed, call.Target.Hex())
+			} else if revert := gas.AsRevert(err); revert == nil {
+				// Transport errors are not call failures
+				return nil, fmt.Errorf("call %d (%s on %s) failed: %v", i, call.method.Name, call.Target.Hex(), err)
+			} else if revert.Reason != "" {
+				err = fmt.Errorf("%w: %s", ErrCallFailed, revert.Reason)
+			} else {
+				err = fmt.Errorf("%w: %v", ErrCallFailed, err)
+			}
+			if !call.AllowFailure {
+				return nil, fmt.Errorf("call %d (%s on %s): %v", i, call.method.Name, call.Target.Hex(), err)
+			}
+			results[i] = Result{Err: err}
+			continue
+		}
+		results[i] = decode(call, true, output)
+		if results[i].Err != nil && !call.AllowFailure {
+			return nil, fmt.Errorf("call %d (%s on %s): %v", i, call.method.Name, call.Target.Hex(), results[i].Err)
+		}
+	}
+	return results, nil
+}
+
+// call3 mirrors Multicall3.Call3
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// result3 mirrors Multicall3.Result
+type result3 struct {
+	Success    bool
+	ReturnData []byte
+}
+
+func (c *Client) aggregate3(opts *bind.CallOpts, calls []Call) ([]Result, error) {
+	packed := make([]call3, len(calls))
+	for i, call := range calls {
+		packed[i] = call3{Target: call.Target, AllowFailure: call.AllowFailure, CallData: call.data}
+	}
+
+	var out []interface{}
+	if err := c.multicall.Call(opts, &out, "aggregate3", packed); err != nil {
+		if errors.Is(err, bind.ErrNoCode) {
+			return nil, err
+		}
+		if revert := gas.AsRevert(err); revert != nil && revert.Reason != "" {
+			return nil, fmt.Errorf("aggregate3 reverted: %s", revert.Reason)
+		}
+		return nil, fmt.Errorf("aggregate3 failed: %v", err)
+	}
+	returned := *abi.ConvertType(out[0], new([]result3)).(*[]result3)
+	if len(returned) != len(calls) {
+		return nil, fmt.Errorf("aggregate3 returned %d results for %d calls", len(returned), len(calls))
+	}
+
+	results := make([]Result, len(calls))
+	for i, call := range calls {
+		results[i] = decode(call, returned[i].Success, returned[i].ReturnData)
+		if results[i].Err != nil && !call.AllowFailure {
+			// Only reachable for decode errors; reverts already made aggregate3 revert
+			return nil, fmt.Errorf("call %d (%s on %s): %v", i, call.method.Name, call.Target.Hex(), results[i].Err)
+		}
+	}
+	return results, nil
+}
+
+// decode turns raw return data into a Result
+func decode(call Call, success bool, data []byte) Result {
+	if !success {
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			return Result{Err: fmt.Errorf("%w: %s", ErrCallFailed, reason)}
+		}
+		return Result{Err: fmt.Errorf("%w: reverted (%s)", ErrCallFailed, hexutil.Encode(data))}
+	}
+	if len(data) == 0 && len(call.method.Outputs) > 0 {
+		// Calls to accounts without code succeed with no data
+		return Result{Err: fmt.Errorf("%w: no data returned by %s", ErrCallFailed, call.Target.Hex())}
+	}
+	values, err := call.method.Outputs.Unpack(data)
+	if err != nil {
+		return Result{Err: fmt.Errorf("failed to decode %s: %v", call.method.Name, err)}
+	}
+	return Result{Success: true, Values: values}
+}
@@ -0,0 +1,56 @@
+package multicall
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fuckEthereum/src/evmasm"
+)
+
+// multicall3Code is aggregate3 from Multicall3 written in EVM assembly, so the tests can put
+// it in genesis without needing solc. Like Multicall3, it runs every call, reverts with
+// "Multicall3: call failed" when a call without allowFailure fails, and returns
+// (bool success, bytes returnData)[] in call order.
+//
+// Memory: 0x00 holds i, 0x20 the write pointer, 0x40 the number of calls and 0x60 the
+// calldata offset of the call heads. The result is encoded from 0x80: the array offset,
+// its length, one head per result and then the results themselves.
+var multicall3Code = func() []byte {
+	load := func(at uint64) []interface{} { return []interface{}{evmasm.P(at), vm.MLOAD} }
+	store := func(at uint64) []interface{} { return []interface{}{evmasm.P(at), vm.MSTORE} }
+	i, ptr, n, heads := load(0x00), load(0x20), load(0x40), load(0x60)
+	const results = 0xc0 // heads of the encoded result
+
+	failed := "Multicall3: call failed"
+	return evmasm.Assemble(
+		evmasm.Dispatch("aggregate3((address,bool,bytes)[])", "aggregate3"),
+		evmasm.Label("aggregate3"), vm.POP,
+		evmasm.P(4), vm.CALLDATALOAD, evmasm.P(4), vm.ADD, vm.DUP1, vm.CALLDATALOAD,
+		vm.DUP1, store(0x40), store(0xa0), evmasm.P(0x20), vm.ADD, store(0x60),
+		evmasm.P(0x20), store(0x80), vm.PUSH0, store(0x00),
+		n, evmasm.P(5), vm.SHL, evmasm.P(results), vm.ADD, store(0x20),
+
+		// [tuple bytesPos len]: copy callData to ptr+0x60 and call the target with it
+		evmasm.Label("loop"), n, i, vm.LT, vm.ISZERO, evmasm.Ref("done"), vm.JUMPI,
+		i, evmasm.P(5), vm.SHL, heads, vm.ADD, vm.CALLDATALOAD, heads, vm.ADD,
+		vm.DUP1, evmasm.P(0x40), vm.ADD, vm.CALLDATALOAD, vm.DUP2, vm.ADD, vm.DUP1, vm.CALLDATALOAD,
+		vm.DUP1, vm.DUP3, evmasm.P(0x20), vm.ADD, ptr, evmasm.P(0x60), vm.ADD, vm.CALLDATACOPY,
+		vm.PUSH0, vm.PUSH0, vm.DUP3, ptr, evmasm.P(0x60), vm.ADD, vm.PUSH0, vm.DUP8, vm.CALLDATALOAD, vm.GAS, vm.CALL,
+		vm.DUP1, evmasm.Ref("ok"), vm.JUMPI,
+		vm.DUP4, evmasm.P(0x20), vm.ADD, vm.CALLDATALOAD, evmasm.Ref("ok"), vm.JUMPI,
+		evmasm.P(0x08c379a0), evmasm.P(0xe0), vm.SHL, store(0x00), evmasm.P(0x20), store(0x04),
+		evmasm.P(uint64(len(failed))), store(0x24),
+		evmasm.Push(failed), evmasm.P(uint64(32-len(failed))*8), vm.SHL, store(0x44),
+		evmasm.P(0x64), vm.PUSH0, vm.REVERT,
+
+		// Encode (success, 0x40, returnData) at ptr, point head i at it and move ptr past it
+		evmasm.Label("ok"), ptr, vm.MSTORE, vm.POP, vm.POP, vm.POP,
+		evmasm.P(0x40), ptr, evmasm.P(0x20), vm.ADD, vm.MSTORE,
+		vm.RETURNDATASIZE, ptr, evmasm.P(0x40), vm.ADD, vm.MSTORE,
+		vm.RETURNDATASIZE, vm.PUSH0, ptr, evmasm.P(0x60), vm.ADD, vm.RETURNDATACOPY,
+		evmasm.P(results), ptr, vm.SUB, i, evmasm.P(5), vm.SHL, evmasm.P(results), vm.ADD, vm.MSTORE,
+		evmasm.P(0x1f), vm.RETURNDATASIZE, vm.ADD, evmasm.P(0x1f), vm.NOT, vm.AND, evmasm.P(0x60), vm.ADD, ptr, vm.ADD, store(0x20),
+		i, evmasm.P(1), vm.ADD, store(0x00),
+		evmasm.Ref("loop"), vm.JUMP,
+
+		evmasm.Label("done"), evmasm.P(0x80), ptr, vm.SUB, evmasm.P(0x80), vm.RETURN,
+	)
+}()
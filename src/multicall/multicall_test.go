@@ -0,0 +1,180 @@
+package multicall
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+func TestValue(t *testing.T) {
+	type pair struct {
+		Success    bool
+		ReturnData []byte
+	}
+	tuple := struct {
+		Success    bool   `json:"success"`
+		ReturnData []byte `json:"returnData"`
+	}{true, []byte{1}}
+
+	count, err := Value[*big.Int](Result{Success: true, Values: []interface{}{big.NewInt(7)}})
+	if err != nil || count.Int64() != 7 {
+		t.Errorf("Value[*big.Int] = %v, %v", count, err)
+	}
+	decoded, err := Value[pair](Result{Success: true, Values: []interface{}{tuple}})
+	if err != nil || !decoded.Success || len(decoded.ReturnData) != 1 {
+		t.Errorf("Value[pair] = %+v, %v", decoded, err)
+	}
+
+	mismatches := []func() error{
+		func() error {
+			_, err := Value[string](Result{Success: true, Values: []interface{}{uint8(65)}})
+			return err
+		},
+		func() error {
+			_, err := Value[*big.Int](Result{Success: true, Values: []interface{}{tuple}})
+			return err
+		},
+		func() error {
+			_, err := Value[struct{ Other bool }](Result{Success: true, Values: []interface{}{tuple}})
+			return err
+		},
+		func() error { _, err := Value[*big.Int](Result{Success: true}); return err },
+	}
+	for i, mismatch := range mismatches {
+		if mismatch() == nil {
+			t.Errorf("mismatch %d decoded without an error", i)
+		}
+	}
+
+	failed := errors.New("reverted")
+	if _, err := Value[*big.Int](Result{Err: failed}); err != failed {
+		t.Errorf("failed result: got %v, want its error", err)
+	}
+}
+
+func TestAggregateWithoutMulticall3(t *testing.T) {
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(0), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter, _, _, err := contracts.DeployCounter(auth, chain.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getCount, err := CounterGetCount(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noCode, err := CounterGetCount(common.HexToAddress("0x0000000000000000000000000000000000c0ffee"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// decrement is not a view, but eth_call runs it and it reverts on a fresh counter
+	decrement, err := NewCall(contracts.CounterMetaData, counter, "decrement")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := New(chain.Client())
+	results, err := client.Aggregate(nil, []Call{getCount, noCode.Optional(), decrement.Optional()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := Value[*big.Int](results[0]); err != nil || count.Sign() != 0 {
+		t.Errorf("getCount = %v, %v", count, err)
+	}
+	if !errors.Is(results[1].Err, ErrCallFailed) || !strings.Contains(results[1].Err.Error(), "no contract code") {
+		t.Errorf("call without code: %v", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, ErrCallFailed) || !strings.Contains(results[2].Err.Error(), "Counter cannot be negative") {
+		t.Errorf("reverting call: %v", results[2].Err)
+	}
+
+	if _, err := client.Aggregate(nil, []Call{getCount, decrement}); err == nil || !strings.Contains(err.Error(), "Counter cannot be negative") {
+		t.Errorf("required reverting call: got %v", err)
+	}
+}
+
+func TestAggregate3(t *testing.T) {
+	chain, err := simchain.NewWithAlloc(1, nil, types.GenesisAlloc{Address: {Code: multicall3Code}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(0), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Three counters at 0, 1 and 2
+	var getCounts []Call
+	for count := 0; count < 3; count++ {
+		address, _, counter, err := contracts.DeployCounter(auth, chain.Client())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < count; i++ {
+			if _, err := counter.Increment(auth); err != nil {
+				t.Fatal(err)
+			}
+		}
+		getCount, err := CounterGetCount(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		getCounts = append(getCounts, getCount)
+	}
+	decrement, err := NewCall(contracts.CounterMetaData, getCounts[0].Target, "decrement")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := []Call{getCounts[2], decrement.Optional(), getCounts[0], getCounts[1], getCounts[2]}
+	want := []int64{2, -1, 0, 1, 2} // -1 marks the failing call
+	for _, batchSize := range []int{DefaultBatchSize, 2} {
+		client := New(chain.Client())
+		client.BatchSize = batchSize
+		results, err := client.Aggregate(nil, calls)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != len(want) {
+			t.Fatalf("batch size %d: got %d results, want %d", batchSize, len(results), len(want))
+		}
+		for i, result := range results {
+			if want[i] < 0 {
+				if result.Success || !errors.Is(result.Err, ErrCallFailed) || !strings.Contains(result.Err.Error(), "Counter cannot be negative") {
+					t.Errorf("batch size %d: call %d = %+v, want the revert reason", batchSize, i, result)
+				}
+				continue
+			}
+			if count, err := Value[*big.Int](result); err != nil || count.Int64() != want[i] {
+				t.Errorf("batch size %d: call %d = %v, %v, want %d", batchSize, i, count, err, want[i])
+			}
+		}
+	}
+
+	// A failing call without allowFailure makes aggregate3 revert
+	if _, err := New(chain.Client()).Aggregate(nil, []Call{getCounts[1], decrement}); err == nil || !strings.Contains(err.Error(), "Multicall3: call failed") {
+		t.Errorf("required failing call: got %v", err)
+	}
+	// Before the counters existed, every call returns nothing
+	results, err := New(chain.Client()).Aggregate(&bind.CallOpts{BlockNumber: big.NewInt(0)}, []Call{getCounts[0].Optional()})
+	if err != nil || !errors.Is(results[0].Err, ErrCallFailed) {
+		t.Errorf("call at genesis: got %v, %v", results, err)
+	}
+}
@@ -0,0 +1,193 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrChainIDMismatch is returned when the node's chain ID differs from the profile
+var ErrChainIDMismatch = errors.New("chain ID mismatch")
+
+// Profile describes a network the tool is configured to talk to
+type Profile struct {
+	Name     string   // Short name used on the command line, e.g. "sepolia"
+	ChainID  *big.Int // Expected EIP-155 chain ID
+	RPCURL   string   // JSON-RPC endpoint
+	Explorer string   // Block explorer base URL, empty if none
+	Mainnet  bool     // True for networks holding real value
+}
+
+// Profiles are the built-in network profiles keyed by name
+var Profiles = map[string]*Profile{
+	"mainnet": {
+		Name:     "mainnet",
+		ChainID:  big.NewInt(1),
+		RPCURL:   "https://eth.llamarpc.com",
+		Explorer: "https://etherscan.io",
+		Mainnet:  true,
+	},
+	"sepolia": {
+		Name:     "sepolia",
+		ChainID:  big.NewInt(11155111),
+		RPCURL:   "https://sepolia.infura.io/v3/YOUR_INFURA_PROJECT_ID",
+		Explorer: "https://sepolia.etherscan.io",
+	},
+	"holesky": {
+		Name:     "holesky",
+		ChainID:  big.NewInt(17000),
+		RPCURL:   "https://ethereum-holesky-rpc.publicnode.com",
+		Explorer: "https://holesky.etherscan.io",
+	},
+	"local": {
+		Name:    "local",
+		ChainID: big.NewInt(1337),
+		RPCURL:  "http://127.0.0.1:8545",
+	},
+	"anvil": {
+		Name:    "anvil",
+		ChainID: big.NewInt(31337),
+		RPCURL:  "http://127.0.0.1:8545",
+	},
+}
+
+// Lookup returns a copy of the named profile
+func Lookup(name string) (*Profile, error) {
+	profile, ok := Profiles[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown network %q (known: %s)", name, strings.Join(Names(), ", "))
+	}
+	copied := *profile
+	return &copied, nil
+}
+
+// Names returns the sorted names of the built-in profiles
+func Names() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FromEnv selects the profile named by ETH_NETWORK (default sepolia).
+// ETH_RPC_URL overrides the endpoint; SEPOLIA_RPC_URL is honoured for sepolia.
+func FromEnv() (*Profile, error) {
+	name := os.Getenv("ETH_NETWORK")
+	if name == "" {
+		name = "sepolia"
+	}
+	profile, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.Name == "sepolia" && os.Getenv("SEPOLIA_RPC_URL") != "" {
+		profile.RPCURL = os.Getenv("SEPOLIA_RPC_URL")
+	}
+	if rpcURL := os.Getenv("ETH_RPC_URL"); rpcURL != "" {
+		profile.RPCURL = rpcURL
+	}
+	return profile, nil
+}
+
+// WithRPC returns a copy of the profile using another endpoint
+func (p *Profile) WithRPC(rpcURL string) *Profile {
+	copied := *p
+	copied.RPCURL = rpcURL
+	return &copied
+}
+
+// TxURL returns the explorer link for a transaction, or "" without an explorer
+func (p *Profile) TxURL(txHash string) string {
+	if p.Explorer == "" {
+		return ""
+	}
+	return p.Explorer + "/tx/" + txHash
+}
+
+// AddressURL returns the explorer link for an address, or "" without an explorer
+func (p *Profile) AddressURL(address string) string {
+	if p.Explorer == "" {
+		return ""
+	}
+	return p.Explorer + "/address/" + address
+}
+
+// ChainIDReader is implemented by clients that expose eth_chainId
+type ChainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// VerifyChainID reads eth_chainId and checks it against the profile.
+// The returned chain ID is the one to use for EIP-155 signing.
+func VerifyChainID(ctx context.Context, reader ChainIDReader, profile *Profile) (*big.Int, error) {
+	chainID, err := reader.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+	if profile != nil && profile.ChainID != nil && chainID.Cmp(profile.ChainID) != 0 {
+		return nil, fmt.Errorf("%w: node reports chain ID %s but profile %q expects %s; refusing to sign",
+			ErrChainIDMismatch, chainID, profile.Name, profile.ChainID)
+	}
+	return chainID, nil
+}
+
+// DisplayName converts a chain or network ID to a human-readable name
+func DisplayName(chainID *big.Int) string {
+	switch chainID.String() {
+	case "1":
+		return "Ethereum Mainnet"
+	case "3":
+		return "Ropsten Testnet (Deprecated)"
+	case "4":
+		return "Rinkeby Testnet (Deprecated)"
+	case "5":
+		return "Goerli Testnet (Deprecated)"
+	case "11155111":
+		return "Sepolia Testnet"
+	case "17000":
+		return "Holesky Testnet"
+	case "137":
+		return "Polygon Mainnet"
+	case "80001":
+		return "Polygon Mumbai Testnet"
+	case "56":
+		return "BSC Mainnet"
+	case "97":
+		return "BSC Testnet"
+	case "1337", "31337":
+		return "Local Devnet"
+	default:
+		return fmt.Sprintf("Unknown Network (ID: %s)", chainID.String())
+	}
+}
+
+// IsMainnet reports whether a chain ID belongs to a network holding real value
+func IsMainnet(chainID *big.Int) bool {
+	switch chainID.String() {
+	case "1", "137", "56":
+		return true
+	}
+	return false
+}
+
+// HoldsValue reports whether signing for profile on chainID moves real value: the profile
+// is marked Mainnet or the chain ID is a known mainnet. The profile may be nil.
+func HoldsValue(profile *Profile, chainID *big.Int) bool {
+	return (profile != nil && profile.Mainnet) || IsMainnet(chainID)
+}
+
+// IsLocal reports whether a chain ID belongs to a throwaway development chain
+func IsLocal(chainID *big.Int) bool {
+	switch chainID.String() {
+	case "1337", "31337":
+		return true
+	}
+	return false
+}
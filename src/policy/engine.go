@@ -0,0 +1,314 @@
+package policy
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/txpipe"
+	"github.com/fuckEthereum/src/units"
+)
+
+var (
+	// ErrDenied is returned when a transaction violates the policy
+	ErrDenied = errors.New("transaction denied by policy")
+
+	// ErrNotConfirmed is returned when the user did not type the confirmation phrase
+	ErrNotConfirmed = errors.New("transaction not confirmed")
+)
+
+// Audit decisions
+const (
+	DecisionApproved = "approved"
+	DecisionDenied   = "denied"
+	DecisionDeclined = "declined"
+	DecisionFailed   = "failed" // an approved transaction that never moved its value
+)
+
+// AuditEntry is one line of the audit log
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	ChainID    string    `json:"chainId"`
+	Network    string    `json:"network"`
+	Label      string    `json:"label,omitempty"`
+	From       string    `json:"from"`
+	To         string    `json:"to,omitempty"`
+	Value      string    `json:"value"`
+	GasLimit   uint64    `json:"gasLimit"`
+	FeePerGas  string    `json:"feePerGas,omitempty"`
+	Decision   string    `json:"decision"`
+	Violations []string  `json:"violations,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Confirmer asks the user to type phrase and reports whether they did
+type Confirmer func(prompt string, phrase string) (bool, error)
+
+// StdinConfirmer reads the confirmation phrase from standard input
+func StdinConfirmer(prompt string, phrase string) (bool, error) {
+	fmt.Print(prompt)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false, fmt.Errorf("failed to read confirmation: %v", err)
+	}
+	return strings.TrimSpace(line) == phrase, nil
+}
+
+// Engine evaluates transactions, asks for confirmation and writes the audit log
+type Engine struct {
+	policy  *Policy
+	confirm Confirmer
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// NewEngine creates an engine; confirm may be nil to reject anything needing confirmation
+func NewEngine(policy *Policy, confirm Confirmer) *Engine {
+	return &Engine{
+		policy:  policy,
+		confirm: confirm,
+		now:     time.Now,
+	}
+}
+
+// Authorize evaluates tx and returns nil only if it may be signed.
+// Every decision is appended to the audit log; approved values count toward the daily limit
+// until Release is called for them.
+func (e *Engine) Authorize(tx *Tx) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if tx.Time.IsZero() {
+		tx.Time = e.now()
+	}
+
+	spent, err := e.spentOn(tx.ChainID, tx.Time)
+	if err != nil {
+		return err
+	}
+
+	decision, err := e.policy.Evaluate(tx, spent)
+	if err != nil {
+		return err
+	}
+
+	if !decision.Allowed {
+		if err := e.audit(tx, DecisionDenied, decision.Violations); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrDenied, strings.Join(decision.Violations, "; "))
+	}
+
+	if decision.RequiresConfirmation {
+		confirmed := false
+		if e.confirm != nil {
+			phrase := ConfirmationPhrase(tx)
+			prompt := fmt.Sprintf("⚠️  You are about to sign on %s (chain %s): send %s to %s.\n   Type \"%s\" to confirm: ",
+				tx.NetworkName, tx.ChainID, units.FormatWei(tx.Value, units.Ether, -1), recipient(tx), phrase)
+			confirmed, err = e.confirm(prompt, phrase)
+			if err != nil {
+				return err
+			}
+		}
+		if !confirmed {
+			if err := e.audit(tx, DecisionDeclined, nil); err != nil {
+				return err
+			}
+			return ErrNotConfirmed
+		}
+	}
+
+	return e.audit(tx, DecisionApproved, nil)
+}
+
+// Release records that an approved tx failed to send or reverted, so its value stops
+// counting toward the daily limit. It is booked on the day tx was approved.
+func (e *Engine) Release(tx *Tx, cause error) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry := e.entry(tx, DecisionFailed, nil)
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+	return e.write(entry)
+}
+
+// SpentToday returns the value approved and not released on a chain during the current UTC day
+func (e *Engine) SpentToday(chainID *big.Int) (*big.Int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.spentOn(chainID, e.now())
+}
+
+// spentOn sums approved minus released values in the audit log for the UTC day containing at
+func (e *Engine) spentOn(chainID *big.Int, at time.Time) (*big.Int, error) {
+	total := new(big.Int)
+
+	file, err := os.Open(e.policy.AuditLog)
+	if os.IsNotExist(err) {
+		return total, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	day := at.UTC().Format("2006-01-02")
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.ChainID != chainID.String() || entry.Time.UTC().Format("2006-01-02") != day {
+			continue
+		}
+		value, ok := new(big.Int).SetString(entry.Value, 10)
+		if !ok {
+			continue
+		}
+		switch entry.Decision {
+		case DecisionApproved:
+			total.Add(total, value)
+		case DecisionFailed:
+			total.Sub(total, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	if total.Sign() < 0 {
+		total.SetInt64(0)
+	}
+	return total, nil
+}
+
+func (e *Engine) audit(tx *Tx, decision string, violations []string) error {
+	return e.write(e.entry(tx, decision, violations))
+}
+
+func (e *Engine) entry(tx *Tx, decision string, violations []string) AuditEntry {
+	entry := AuditEntry{
+		Time:       tx.Time.UTC(),
+		ChainID:    tx.ChainID.String(),
+		Network:    tx.NetworkName,
+		Label:      tx.Label,
+		From:       tx.From.Hex(),
+		Value:      new(big.Int).Set(valueOrZero(tx.Value)).String(),
+		GasLimit:   tx.GasLimit,
+		Decision:   decision,
+		Violations: violations,
+	}
+	if tx.To != nil {
+		entry.To = tx.To.Hex()
+	}
+	if tx.FeePerGas != nil {
+		entry.FeePerGas = tx.FeePerGas.String()
+	}
+	return entry
+}
+
+func (e *Engine) write(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.policy.AuditLog), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %v", err)
+	}
+	file, err := os.OpenFile(e.policy.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %v", err)
+	}
+	return nil
+}
+
+// Hooks returns pipeline hooks that authorize every request before it is signed.
+// An approved request that then fails to send or reverts is released again; one that
+// fails while waiting for its receipt keeps counting, since it may still be mined.
+func (e *Engine) Hooks(chainID *big.Int, profile *network.Profile) txpipe.Hooks {
+	var (
+		mu       sync.Mutex
+		approved = make(map[*txpipe.Request]*Tx)
+	)
+	take := func(req *txpipe.Request) *Tx {
+		mu.Lock()
+		defer mu.Unlock()
+		tx := approved[req]
+		delete(approved, req)
+		return tx
+	}
+
+	return txpipe.Hooks{
+		Prepared: func(req *txpipe.Request, opts *bind.TransactOpts, estimate *gas.Estimate) error {
+			feePerGas := opts.GasPrice
+			if opts.GasFeeCap != nil {
+				feePerGas = opts.GasFeeCap
+			}
+			tx := &Tx{
+				ChainID:     chainID,
+				NetworkName: network.DisplayName(chainID),
+				Mainnet:     network.HoldsValue(profile, chainID),
+				Label:       req.Label,
+				From:        opts.From,
+				To:          req.To,
+				Value:       valueOrZero(req.Value),
+				GasLimit:    opts.GasLimit,
+				FeePerGas:   feePerGas,
+			}
+			if err := e.Authorize(tx); err != nil {
+				return err
+			}
+			mu.Lock()
+			approved[req] = tx
+			mu.Unlock()
+			return nil
+		},
+		Mined: func(req *txpipe.Request, result *txpipe.Result) {
+			if result.Receipt != nil && result.Receipt.Status == types.ReceiptStatusSuccessful {
+				take(req)
+			}
+		},
+		Failed: func(req *txpipe.Request, stage txpipe.Stage, err error) {
+			tx := take(req)
+			if tx == nil || stage == txpipe.StageWait {
+				return
+			}
+			if err := e.Release(tx, err); err != nil {
+				fmt.Printf("⚠️  Failed to release policy allowance: %v\n", err)
+			}
+		},
+	}
+}
+
+func recipient(tx *Tx) string {
+	if tx.To == nil {
+		return "a new contract"
+	}
+	return tx.To.Hex()
+}
+
+func valueOrZero(value *big.Int) *big.Int {
+	if value == nil {
+		return new(big.Int)
+	}
+	return value
+}
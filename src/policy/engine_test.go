@@ -0,0 +1,233 @@
+package policy
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/txpipe"
+)
+
+// fakeConfirmer answers every prompt with answer and records the phrases it was asked for
+type fakeConfirmer struct {
+	answer  bool
+	phrases []string
+}
+
+func (f *fakeConfirmer) confirm(prompt, phrase string) (bool, error) {
+	f.phrases = append(f.phrases, phrase)
+	return f.answer, nil
+}
+
+func newTestEngine(t *testing.T, rules map[string]Rules, confirm Confirmer) *Engine {
+	t.Helper()
+	engine := NewEngine(&Policy{
+		Networks: rules,
+		AuditLog: filepath.Join(t.TempDir(), "audit", "policy_audit.jsonl"),
+	}, confirm)
+	now := time.Date(2025, 8, 20, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	return engine
+}
+
+func readAudit(t *testing.T, e *Engine) []AuditEntry {
+	t.Helper()
+	file, err := os.Open(e.policy.AuditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func transfer(chainID int64, value string) *Tx {
+	to := bob
+	return &Tx{
+		ChainID:     big.NewInt(chainID),
+		NetworkName: network.DisplayName(big.NewInt(chainID)),
+		Mainnet:     network.IsMainnet(big.NewInt(chainID)),
+		From:        alice,
+		To:          &to,
+		Value:       wei(value),
+		GasLimit:    21000,
+		FeePerGas:   wei("1 gwei"),
+	}
+}
+
+func TestAuthorizeDailyLimit(t *testing.T) {
+	fake := &fakeConfirmer{answer: true}
+	engine := newTestEngine(t, map[string]Rules{"1": {MaxValuePerDay: "1 ether"}}, fake.confirm)
+
+	for i, want := range []error{nil, nil, ErrDenied} {
+		err := engine.Authorize(transfer(1, "0.4 ether"))
+		if !errors.Is(err, want) {
+			t.Fatalf("transfer %d: got %v, want %v", i, err, want)
+		}
+	}
+	// Other chains have their own allowance
+	if err := engine.Authorize(transfer(11155111, "0.9 ether")); err != nil {
+		t.Fatal(err)
+	}
+
+	spent, err := engine.SpentToday(big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spent.Cmp(wei("0.8 ether")) != 0 {
+		t.Errorf("spent %s, want 0.8 ether", spent)
+	}
+
+	decisions := []string{}
+	for _, entry := range readAudit(t, engine) {
+		decisions = append(decisions, entry.Decision)
+	}
+	want := []string{DecisionApproved, DecisionApproved, DecisionDenied, DecisionApproved}
+	if len(decisions) != len(want) {
+		t.Fatalf("audit decisions %v, want %v", decisions, want)
+	}
+	for i := range want {
+		if decisions[i] != want[i] {
+			t.Fatalf("audit decisions %v, want %v", decisions, want)
+		}
+	}
+}
+
+func TestAuthorizeMainnetConfirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		answer   bool
+		confirm  bool // whether a confirmer is installed at all
+		want     error
+		decision string
+	}{
+		{"confirmed", true, true, nil, DecisionApproved},
+		{"declined", false, true, ErrNotConfirmed, DecisionDeclined},
+		{"no confirmer", false, false, ErrNotConfirmed, DecisionDeclined},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConfirmer{answer: tt.answer}
+			var confirm Confirmer
+			if tt.confirm {
+				confirm = fake.confirm
+			}
+			engine := newTestEngine(t, nil, confirm)
+
+			if err := engine.Authorize(transfer(1, "0.01 ether")); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if tt.confirm && (len(fake.phrases) != 1 || fake.phrases[0] != "Ethereum Mainnet") {
+				t.Errorf("confirmation phrases %v, want [Ethereum Mainnet]", fake.phrases)
+			}
+			entries := readAudit(t, engine)
+			if len(entries) != 1 || entries[0].Decision != tt.decision {
+				t.Errorf("audit %+v, want one %s entry", entries, tt.decision)
+			}
+		})
+	}
+
+	// Testnets are not prompted
+	fake := &fakeConfirmer{}
+	engine := newTestEngine(t, nil, fake.confirm)
+	if err := engine.Authorize(transfer(11155111, "1 ether")); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.phrases) != 0 {
+		t.Errorf("testnet transfer prompted for %v", fake.phrases)
+	}
+}
+
+func TestReleaseRestoresAllowance(t *testing.T) {
+	engine := newTestEngine(t, map[string]Rules{"*": {MaxValuePerDay: "1 ether"}}, nil)
+
+	tx := transfer(11155111, "0.8 ether")
+	if err := engine.Authorize(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Authorize(transfer(11155111, "0.8 ether")); !errors.Is(err, ErrDenied) {
+		t.Fatalf("second transfer: got %v, want ErrDenied", err)
+	}
+	if err := engine.Release(tx, errors.New("send failed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Authorize(transfer(11155111, "0.8 ether")); err != nil {
+		t.Fatalf("transfer after release: %v", err)
+	}
+}
+
+func TestHooksCountOnlyValueThatMoved(t *testing.T) {
+	chainID := big.NewInt(11155111)
+	engine := newTestEngine(t, nil, nil)
+	hooks := engine.Hooks(chainID, nil)
+
+	send := func(value string) *txpipe.Request {
+		to := bob
+		req := &txpipe.Request{Label: "transfer", To: &to, Value: wei(value)}
+		opts := &bind.TransactOpts{From: alice, GasPrice: wei("1 gwei"), GasLimit: 21000}
+		if err := hooks.Prepared(req, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	spent := func() *big.Int {
+		total, err := engine.SpentToday(chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+
+	mined := send("1 ether")
+	hooks.Mined(mined, &txpipe.Result{Receipt: &types.Receipt{Status: types.ReceiptStatusSuccessful}})
+
+	failedSend := send("2 ether")
+	hooks.Failed(failedSend, txpipe.StageSend, errors.New("nonce too low"))
+
+	reverted := send("4 ether")
+	hooks.Mined(reverted, &txpipe.Result{Receipt: &types.Receipt{Status: types.ReceiptStatusFailed}})
+	hooks.Failed(reverted, txpipe.StageReceipt, txpipe.ErrReceiptFailed)
+
+	timedOut := send("8 ether")
+	hooks.Failed(timedOut, txpipe.StageWait, errors.New("context deadline exceeded"))
+
+	// A failure of a request the policy never approved releases nothing
+	hooks.Failed(&txpipe.Request{Value: wei("16 ether")}, txpipe.StageEstimate, errors.New("reverted"))
+
+	if got, want := spent(), wei("9 ether"); got.Cmp(want) != 0 {
+		t.Errorf("spent %s, want %s (mined + possibly mined)", got, want)
+	}
+}
+
+func TestHooksUseProfileForMainnet(t *testing.T) {
+	fake := &fakeConfirmer{answer: true}
+	engine := newTestEngine(t, nil, fake.confirm)
+	profile := &network.Profile{Name: "fork", ChainID: big.NewInt(31337), Mainnet: true}
+
+	to := bob
+	req := &txpipe.Request{Label: "transfer", To: &to, Value: wei("1 wei")}
+	opts := &bind.TransactOpts{From: alice, GasPrice: wei("1 gwei"), GasLimit: 21000}
+	if err := engine.Hooks(profile.ChainID, profile).Prepared(req, opts, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.phrases) != 1 {
+		t.Errorf("a Mainnet profile on a local chain ID was not confirmed")
+	}
+}
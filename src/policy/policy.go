@@ -0,0 +1,280 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fuckEthereum/src/units"
+)
+
+// DefaultFile is where the policy is loaded from unless POLICY_FILE is set
+const DefaultFile = "policy.json"
+
+// DefaultAuditLog is where decisions are appended unless the policy names another file
+const DefaultAuditLog = "data/policy_audit.jsonl"
+
+// Rules are the limits for one network. Empty fields mean "no limit".
+// Amounts use the units syntax, e.g. "0.1 ether" or "50 gwei".
+// RequireConfirmation can add typed confirmation to other networks; mainnets always require it.
+type Rules struct {
+	MaxValuePerTx       string   `json:"maxValuePerTx,omitempty"`
+	MaxValuePerDay      string   `json:"maxValuePerDay,omitempty"`
+	MaxFeePerGas        string   `json:"maxFeePerGas,omitempty"`
+	MaxTxFee            string   `json:"maxTxFee,omitempty"`
+	Allow               []string `json:"allow,omitempty"`
+	Deny                []string `json:"deny,omitempty"`
+	RequireConfirmation *bool    `json:"requireConfirmation,omitempty"`
+}
+
+// Policy maps chain IDs (as decimal strings) to rules; "*" applies to every other chain
+type Policy struct {
+	Networks map[string]Rules `json:"networks"`
+	AuditLog string           `json:"auditLog,omitempty"`
+}
+
+// Default is used when no policy file exists: mainnets require confirmation and have conservative caps
+func Default() *Policy {
+	confirm := true
+	mainnet := Rules{
+		MaxValuePerTx:       "0.05 ether",
+		MaxValuePerDay:      "0.2 ether",
+		MaxFeePerGas:        "200 gwei",
+		RequireConfirmation: &confirm,
+	}
+	return &Policy{
+		Networks: map[string]Rules{
+			"1":   mainnet,
+			"137": mainnet,
+			"56":  mainnet,
+		},
+		AuditLog: DefaultAuditLog,
+	}
+}
+
+// Load reads a policy file; a missing file yields Default()
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Default(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %v", err)
+	}
+	return Parse(data)
+}
+
+// LoadFromEnv loads the file named by POLICY_FILE or DefaultFile
+func LoadFromEnv() (*Policy, error) {
+	path := os.Getenv("POLICY_FILE")
+	if path == "" {
+		path = DefaultFile
+	}
+	return Load(path)
+}
+
+// Parse decodes and validates a policy document. Its rules are merged over Default(),
+// so a file that only sets some fields for a mainnet keeps the default caps for the rest.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %v", err)
+	}
+	if p.AuditLog == "" {
+		p.AuditLog = DefaultAuditLog
+	}
+
+	networks := Default().Networks
+	for chain, rules := range p.Networks {
+		networks[chain] = networks[chain].merge(rules)
+	}
+	p.Networks = networks
+
+	for chain, rules := range p.Networks {
+		if _, err := rules.compile(); err != nil {
+			return nil, fmt.Errorf("invalid rules for chain %s: %v", chain, err)
+		}
+	}
+	return &p, nil
+}
+
+// merge returns r with every field that is set in over replaced
+func (r Rules) merge(over Rules) Rules {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&r.MaxValuePerTx, over.MaxValuePerTx},
+		{&r.MaxValuePerDay, over.MaxValuePerDay},
+		{&r.MaxFeePerGas, over.MaxFeePerGas},
+		{&r.MaxTxFee, over.MaxTxFee},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	if over.Allow != nil {
+		r.Allow = over.Allow
+	}
+	if over.Deny != nil {
+		r.Deny = over.Deny
+	}
+	if over.RequireConfirmation != nil {
+		r.RequireConfirmation = over.RequireConfirmation
+	}
+	return r
+}
+
+// RulesFor returns the rules for a chain, falling back to "*"
+func (p *Policy) RulesFor(chainID *big.Int) Rules {
+	if rules, ok := p.Networks[chainID.String()]; ok {
+		return rules
+	}
+	return p.Networks["*"]
+}
+
+// compiledRules are Rules with amounts and addresses parsed
+type compiledRules struct {
+	maxValuePerTx  *units.Amount
+	maxValuePerDay *units.Amount
+	maxFeePerGas   *units.Amount
+	maxTxFee       *units.Amount
+	allow          map[common.Address]bool
+	deny           map[common.Address]bool
+	confirm        *bool
+}
+
+func (r Rules) compile() (*compiledRules, error) {
+	c := &compiledRules{confirm: r.RequireConfirmation}
+
+	limits := []struct {
+		name  string
+		value string
+		dst   **units.Amount
+	}{
+		{"maxValuePerTx", r.MaxValuePerTx, &c.maxValuePerTx},
+		{"maxValuePerDay", r.MaxValuePerDay, &c.maxValuePerDay},
+		{"maxFeePerGas", r.MaxFeePerGas, &c.maxFeePerGas},
+		{"maxTxFee", r.MaxTxFee, &c.maxTxFee},
+	}
+	for _, limit := range limits {
+		if limit.value == "" {
+			continue
+		}
+		amount, err := units.Parse(limit.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", limit.name, err)
+		}
+		*limit.dst = &amount
+	}
+
+	var err error
+	if c.allow, err = addressSet("allow", r.Allow); err != nil {
+		return nil, err
+	}
+	if c.deny, err = addressSet("deny", r.Deny); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func addressSet(name string, list []string) (map[common.Address]bool, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	set := make(map[common.Address]bool, len(list))
+	for _, entry := range list {
+		if !common.IsHexAddress(entry) {
+			return nil, fmt.Errorf("%s: invalid address %q", name, entry)
+		}
+		set[common.HexToAddress(entry)] = true
+	}
+	return set, nil
+}
+
+// Tx is everything the policy needs to know about a transaction before it is signed
+type Tx struct {
+	ChainID     *big.Int
+	NetworkName string
+	Mainnet     bool
+	Label       string
+	From        common.Address
+	To          *common.Address // nil for contract creation
+	Value       *big.Int
+	GasLimit    uint64
+	FeePerGas   *big.Int // gas price, or max fee per gas for EIP-1559
+	Time        time.Time
+}
+
+// MaxFee returns the worst-case fee GasLimit * FeePerGas
+func (tx *Tx) MaxFee() *big.Int {
+	if tx.FeePerGas == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(tx.FeePerGas, new(big.Int).SetUint64(tx.GasLimit))
+}
+
+// Decision is the outcome of evaluating a transaction
+type Decision struct {
+	Allowed              bool
+	Violations           []string
+	RequiresConfirmation bool
+}
+
+func (d *Decision) deny(format string, args ...interface{}) {
+	d.Allowed = false
+	d.Violations = append(d.Violations, fmt.Sprintf(format, args...))
+}
+
+// Evaluate applies the rules for tx.ChainID. spentToday is the value already
+// approved on that chain today. Evaluate has no side effects.
+func (p *Policy) Evaluate(tx *Tx, spentToday *big.Int) (*Decision, error) {
+	rules, err := p.RulesFor(tx.ChainID).compile()
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &Decision{Allowed: true}
+	value := units.NewAmount(tx.Value)
+
+	if tx.To != nil {
+		if rules.deny[*tx.To] {
+			decision.deny("recipient %s is on the deny list", tx.To.Hex())
+		}
+		if rules.allow != nil && !rules.allow[*tx.To] {
+			decision.deny("recipient %s is not on the allow list", tx.To.Hex())
+		}
+	}
+
+	if rules.maxValuePerTx != nil && value.Cmp(*rules.maxValuePerTx) > 0 {
+		decision.deny("value %s exceeds per-transaction limit %s", value.Format(units.Ether, -1), rules.maxValuePerTx.Format(units.Ether, -1))
+	}
+
+	if rules.maxValuePerDay != nil {
+		total := units.NewAmount(spentToday).Add(value)
+		if total.Cmp(*rules.maxValuePerDay) > 0 {
+			decision.deny("daily total %s would exceed daily limit %s", total.Format(units.Ether, -1), rules.maxValuePerDay.Format(units.Ether, -1))
+		}
+	}
+
+	if rules.maxFeePerGas != nil && tx.FeePerGas != nil && units.NewAmount(tx.FeePerGas).Cmp(*rules.maxFeePerGas) > 0 {
+		decision.deny("fee per gas %s exceeds cap %s", units.FormatWei(tx.FeePerGas, units.Gwei, -1), rules.maxFeePerGas.Format(units.Gwei, -1))
+	}
+
+	if rules.maxTxFee != nil && units.NewAmount(tx.MaxFee()).Cmp(*rules.maxTxFee) > 0 {
+		decision.deny("max transaction fee %s exceeds cap %s", units.FormatWei(tx.MaxFee(), units.Ether, -1), rules.maxTxFee.Format(units.Ether, -1))
+	}
+
+	// Mainnets always require typed confirmation; the rules can only add it elsewhere
+	decision.RequiresConfirmation = tx.Mainnet || (rules.confirm != nil && *rules.confirm)
+	return decision, nil
+}
+
+// ConfirmationPhrase is what the user must type to confirm a transaction
+func ConfirmationPhrase(tx *Tx) string {
+	return strings.TrimSpace(tx.NetworkName)
+}
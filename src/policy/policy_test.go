@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fuckEthereum/src/units"
+)
+
+var (
+	alice = common.HexToAddress("0x00000000000000000000000000000000000a11ce")
+	bob   = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+)
+
+func wei(s string) *big.Int {
+	return units.MustParse(s).Wei()
+}
+
+func TestEvaluate(t *testing.T) {
+	yes, no := true, false
+	policy := &Policy{Networks: map[string]Rules{
+		"1": {
+			MaxValuePerTx:  "1 ether",
+			MaxValuePerDay: "2 ether",
+			MaxFeePerGas:   "100 gwei",
+			MaxTxFee:       "0.01 ether",
+		},
+		"11155111": {Allow: []string{alice.Hex()}},
+		"137":      {RequireConfirmation: &no},
+		"*":        {Deny: []string{bob.Hex()}},
+		"5":        {RequireConfirmation: &yes},
+	}}
+
+	tests := []struct {
+		name       string
+		chainID    int64
+		mainnet    bool
+		to         common.Address
+		value      string
+		feePerGas  string
+		gasLimit   uint64
+		spent      string
+		allowed    bool
+		violation  string
+		confirming bool
+	}{
+		{"within limits", 1, true, alice, "0.5 ether", "50 gwei", 21000, "0 wei", true, "", true},
+		{"per-tx limit", 1, true, alice, "1.5 ether", "50 gwei", 21000, "0 wei", false, "per-transaction limit", true},
+		{"exactly the per-tx limit", 1, true, alice, "1 ether", "50 gwei", 21000, "0 wei", true, "", true},
+		{"daily limit", 1, true, alice, "1 ether", "50 gwei", 21000, "1.5 ether", false, "daily limit", true},
+		{"daily limit reached exactly", 1, true, alice, "0.5 ether", "50 gwei", 21000, "1.5 ether", true, "", true},
+		{"fee per gas cap", 1, true, alice, "0.1 ether", "150 gwei", 21000, "0 wei", false, "fee per gas", true},
+		{"tx fee cap", 1, true, alice, "0.1 ether", "90 gwei", 200000, "0 wei", false, "max transaction fee", true},
+		{"allow list hit", 11155111, false, alice, "5 ether", "1 gwei", 21000, "0 wei", true, "", false},
+		{"allow list miss", 11155111, false, bob, "5 ether", "1 gwei", 21000, "0 wei", false, "not on the allow list", false},
+		{"deny list via wildcard", 42, false, bob, "1 wei", "1 gwei", 21000, "0 wei", false, "deny list", false},
+		{"wildcard allows others", 42, false, alice, "100 ether", "1 gwei", 21000, "0 wei", true, "", false},
+		{"mainnet cannot opt out of confirmation", 137, true, alice, "1 ether", "1 gwei", 21000, "0 wei", true, "", true},
+		{"testnet opted into confirmation", 5, false, alice, "1 ether", "1 gwei", 21000, "0 wei", true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := tt.to
+			tx := &Tx{
+				ChainID:   big.NewInt(tt.chainID),
+				Mainnet:   tt.mainnet,
+				To:        &to,
+				Value:     wei(tt.value),
+				GasLimit:  tt.gasLimit,
+				FeePerGas: wei(tt.feePerGas),
+			}
+			decision, err := policy.Evaluate(tx, wei(tt.spent))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision.Allowed != tt.allowed {
+				t.Fatalf("allowed = %v, want %v (violations %v)", decision.Allowed, tt.allowed, decision.Violations)
+			}
+			if tt.violation != "" && !strings.Contains(strings.Join(decision.Violations, "; "), tt.violation) {
+				t.Errorf("violations %v do not mention %q", decision.Violations, tt.violation)
+			}
+			if decision.RequiresConfirmation != tt.confirming {
+				t.Errorf("requires confirmation = %v, want %v", decision.RequiresConfirmation, tt.confirming)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	for _, doc := range []string{
+		`{"networks":{"1":{"maxValuePerTx":"1"}}}`,
+		`{"networks":{"1":{"allow":["not-an-address"]}}}`,
+		`{"networks":{"1":{"maxValuePerDay":"-1 ether"}}}`,
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want error", doc)
+		}
+	}
+}
+
+func TestParseMergesDefaults(t *testing.T) {
+	policy, err := Parse([]byte(`{"networks":{
+		"1":{"maxValuePerTx":"0.01 ether","allow":["` + alice.Hex() + `"],"requireConfirmation":false},
+		"5":{"requireConfirmation":true}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.AuditLog != DefaultAuditLog {
+		t.Errorf("audit log %q, want %q", policy.AuditLog, DefaultAuditLog)
+	}
+
+	mainnet := policy.RulesFor(big.NewInt(1))
+	if mainnet.MaxValuePerTx != "0.01 ether" || len(mainnet.Allow) != 1 {
+		t.Errorf("file rules for chain 1 were not applied: %+v", mainnet)
+	}
+	if mainnet.MaxValuePerDay != "0.2 ether" || mainnet.MaxFeePerGas != "200 gwei" {
+		t.Errorf("default caps for chain 1 were lost: %+v", mainnet)
+	}
+	if polygon := policy.RulesFor(big.NewInt(137)); polygon.MaxValuePerTx != "0.05 ether" {
+		t.Errorf("default rules for chain 137 were lost: %+v", polygon)
+	}
+
+	to := alice
+	tests := []struct {
+		chainID    int64
+		mainnet    bool
+		value      string
+		spent      string
+		allowed    bool
+		confirming bool
+	}{
+		{1, true, "0.01 ether", "0 wei", true, true},
+		{1, true, "0.02 ether", "0 wei", false, true},
+		{1, true, "0.01 ether", "0.195 ether", false, true},
+		{5, false, "1 ether", "0 wei", true, true},
+		{11155111, false, "1 ether", "0 wei", true, false},
+	}
+	for _, tt := range tests {
+		decision, err := policy.Evaluate(&Tx{
+			ChainID:   big.NewInt(tt.chainID),
+			Mainnet:   tt.mainnet,
+			To:        &to,
+			Value:     wei(tt.value),
+			GasLimit:  21000,
+			FeePerGas: wei("1 gwei"),
+		}, wei(tt.spent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision.Allowed != tt.allowed || decision.RequiresConfirmation != tt.confirming {
+			t.Errorf("chain %d, %s after %s: allowed %v, confirming %v, want %v, %v (violations %v)",
+				tt.chainID, tt.value, tt.spent, decision.Allowed, decision.RequiresConfirmation, tt.allowed, tt.confirming, decision.Violations)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The proxies are small enough to write in EVM assembly, which keeps them independent of a
+// solc install. Both take ABI-encoded constructor arguments appended to the creation code.
+
+var (
+	upgradedTopic     = crypto.Keccak256Hash([]byte("Upgraded(address)"))
+	adminChangedTopic = crypto.Keccak256Hash([]byte("AdminChanged(address,address)"))
+
+	selectorUpgradeToAndCall = crypto.Keccak256([]byte("upgradeToAndCall(address,bytes)"))[:4]
+	selectorChangeAdmin      = crypto.Keccak256([]byte("changeAdmin(address)"))[:4]
+)
+
+// instr is one assembly item: an opcode with its immediate, a jump label, or a push of a label
+type instr struct {
+	op    vm.OpCode
+	data  []byte
+	label string // JUMPDEST defining label when op is JUMPDEST, target when op is PUSH2
+}
+
+func op(o vm.OpCode) instr { return instr{op: o} }
+
+func push(b ...byte) instr {
+	return instr{op: vm.PUSH1 + vm.OpCode(len(b)-1), data: b}
+}
+
+func push32(h common.Hash) instr { return push(h.Bytes()...) }
+
+func dest(name string) instr { return instr{op: vm.JUMPDEST, label: name} }
+
+func pushLabel(name string) instr { return instr{op: vm.PUSH2, label: name} }
+
+// pushConst pushes a value resolved at assembly time, such as a section offset
+func pushConst(name string) instr { return instr{op: vm.PUSH2, label: "$" + name} }
+
+// assemble resolves labels and constants; every label push is a PUSH2
+func assemble(program []instr, consts map[string]int) []byte {
+	labels := make(map[string]int)
+	pc := 0
+	for _, in := range program {
+		if in.op == vm.JUMPDEST && in.label != "" {
+			labels[in.label] = pc
+		}
+		pc++
+		if in.op == vm.PUSH2 && in.label != "" {
+			pc += 2
+		} else {
+			pc += len(in.data)
+		}
+	}
+
+	code := make([]byte, 0, pc)
+	for _, in := range program {
+		code = append(code, byte(in.op))
+		switch {
+		case in.op == vm.PUSH2 && in.label != "":
+			value, ok := labels[in.label]
+			if in.label[0] == '$' {
+				value, ok = consts[in.label[1:]]
+			}
+			if !ok {
+				panic(fmt.Sprintf("proxy assembly: undefined label %s", in.label))
+			}
+			code = binary.BigEndian.AppendUint16(code, uint16(value))
+		default:
+			code = append(code, in.data...)
+		}
+	}
+	return code
+}
+
+// delegateRuntime forwards the call to the implementation in the ERC-1967 slot and
+// returns or reverts with its result
+func delegateRuntime() []instr {
+	return []instr{
+		op(vm.CALLDATASIZE), push(0), push(0), op(vm.CALLDATACOPY),
+		push(0), push(0), op(vm.CALLDATASIZE), push(0), push32(ImplementationSlot), op(vm.SLOAD), op(vm.GAS), op(vm.DELEGATECALL),
+		op(vm.RETURNDATASIZE), push(0), push(0), op(vm.RETURNDATACOPY),
+		pushLabel("delegated"), op(vm.JUMPI),
+		op(vm.RETURNDATASIZE), push(0), op(vm.REVERT),
+		dest("delegated"), op(vm.RETURNDATASIZE), push(0), op(vm.RETURN),
+	}
+}
+
+// transparentRuntime intercepts calls from the admin: upgradeToAndCall(address,bytes) and
+// changeAdmin(address) are handled by the proxy, anything else from the admin reverts.
+// Everyone else is delegated.
+func transparentRuntime() []instr {
+	program := []instr{
+		push32(AdminSlot), op(vm.SLOAD), op(vm.CALLER), op(vm.EQ), pushLabel("admin"), op(vm.JUMPI),
+	}
+	program = append(program, delegateRuntime()...)
+	return append(program,
+		dest("admin"),
+		push(0), op(vm.CALLDATALOAD), push(0xe0), op(vm.SHR),
+		op(vm.DUP1), push(selectorUpgradeToAndCall...), op(vm.EQ), pushLabel("upgrade"), op(vm.JUMPI),
+		op(vm.DUP1), push(selectorChangeAdmin...), op(vm.EQ), pushLabel("change"), op(vm.JUMPI),
+		pushLabel("fail"), op(vm.JUMP),
+
+		// upgradeToAndCall(implementation, data)
+		dest("upgrade"), op(vm.POP),
+		push(4), op(vm.CALLDATALOAD),
+		op(vm.DUP1), op(vm.EXTCODESIZE), op(vm.ISZERO), pushLabel("fail"), op(vm.JUMPI),
+		op(vm.DUP1), push32(ImplementationSlot), op(vm.SSTORE),
+		op(vm.DUP1), push32(upgradedTopic), push(0), push(0), op(vm.LOG2),
+		push(0x24), op(vm.CALLDATALOAD), push(4), op(vm.ADD), // position of len(data)
+		op(vm.DUP1), op(vm.CALLDATALOAD),
+		op(vm.DUP1), op(vm.ISZERO), pushLabel("stop"), op(vm.JUMPI),
+		op(vm.DUP1), op(vm.DUP3), push(32), op(vm.ADD), push(0), op(vm.CALLDATACOPY),
+		push(0), push(0), op(vm.DUP3), push(0), op(vm.DUP7), op(vm.GAS), op(vm.DELEGATECALL),
+		op(vm.ISZERO), pushLabel("bubble"), op(vm.JUMPI),
+		dest("stop"), op(vm.STOP),
+
+		// changeAdmin(newAdmin)
+		dest("change"), op(vm.POP),
+		push(4), op(vm.CALLDATALOAD),
+		op(vm.DUP1), op(vm.ISZERO), pushLabel("fail"), op(vm.JUMPI),
+		push32(AdminSlot), op(vm.SLOAD), push(0), op(vm.MSTORE),
+		op(vm.DUP1), push(32), op(vm.MSTORE),
+		push32(adminChangedTopic), push(64), push(0), op(vm.LOG1),
+		push32(AdminSlot), op(vm.SSTORE),
+		op(vm.STOP),
+
+		dest("bubble"),
+		op(vm.RETURNDATASIZE), push(0), push(0), op(vm.RETURNDATACOPY),
+		op(vm.RETURNDATASIZE), push(0), op(vm.REVERT),
+		dest("fail"), push(0), op(vm.DUP1), op(vm.REVERT),
+	)
+}
+
+// constructor copies the ABI-encoded arguments (implementation[, admin], data) into memory,
+// stores the slots, runs data against the implementation and returns the runtime
+func constructor(withAdmin bool) []instr {
+	dataWord := byte(32)
+	program := []instr{
+		pushConst("argsStart"), op(vm.CODESIZE), op(vm.SUB), pushConst("argsStart"), push(0), op(vm.CODECOPY),
+		push(0), op(vm.MLOAD),
+		op(vm.DUP1), op(vm.EXTCODESIZE), op(vm.ISZERO), pushLabel("fail"), op(vm.JUMPI),
+		op(vm.DUP1), push32(ImplementationSlot), op(vm.SSTORE),
+		op(vm.DUP1), push32(upgradedTopic), push(0), push(0), op(vm.LOG2),
+	}
+	if withAdmin {
+		dataWord = 64
+		program = append(program,
+			push(32), op(vm.MLOAD),
+			op(vm.DUP1), op(vm.ISZERO), pushLabel("fail"), op(vm.JUMPI),
+			op(vm.DUP1), push32(AdminSlot), op(vm.SSTORE),
+			// AdminChanged(address(0), admin), written past the arguments
+			push(0), op(vm.MSIZE), op(vm.MSTORE), op(vm.MSIZE), op(vm.MSTORE),
+			push32(adminChangedTopic), push(64), push(64), op(vm.MSIZE), op(vm.SUB), op(vm.LOG1),
+		)
+	}
+	return append(program,
+		push(dataWord), op(vm.MLOAD), // offset of data within the arguments
+		op(vm.DUP1), op(vm.MLOAD),
+		op(vm.DUP1), op(vm.ISZERO), pushLabel("done"), op(vm.JUMPI),
+		push(0), push(0), op(vm.DUP3), op(vm.DUP5), push(32), op(vm.ADD), op(vm.DUP7), op(vm.GAS), op(vm.DELEGATECALL),
+		op(vm.ISZERO), pushLabel("bubble"), op(vm.JUMPI),
+		dest("done"), op(vm.POP), op(vm.POP), op(vm.POP),
+		pushConst("runtimeLength"), op(vm.DUP1), pushConst("runtimeStart"), push(0), op(vm.CODECOPY),
+		push(0), op(vm.RETURN),
+
+		dest("bubble"),
+		op(vm.RETURNDATASIZE), push(0), push(0), op(vm.RETURNDATACOPY),
+		op(vm.RETURNDATASIZE), push(0), op(vm.REVERT),
+		dest("fail"), push(0), op(vm.DUP1), op(vm.REVERT),
+	)
+}
+
+// creationCode assembles constructor and runtime into creation code
+func creationCode(kind Kind) []byte {
+	var runtime []byte
+	if kind == Transparent {
+		runtime = assemble(transparentRuntime(), nil)
+	} else {
+		runtime = assemble(delegateRuntime(), nil)
+	}
+
+	program := constructor(kind == Transparent)
+	// The constructor length does not depend on the constants, all label pushes are PUSH2
+	initLength := len(assemble(program, map[string]int{"argsStart": 0, "runtimeLength": 0, "runtimeStart": 0}))
+	init := assemble(program, map[string]int{
+		"argsStart":     initLength + len(runtime),
+		"runtimeLength": len(runtime),
+		"runtimeStart":  initLength,
+	})
+	return append(init, runtime...)
+}
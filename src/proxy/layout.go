@@ -0,0 +1,260 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// ErrIncompatibleLayout is returned when an upgrade would corrupt proxy storage
+var ErrIncompatibleLayout = errors.New("incompatible storage layout")
+
+// StorageLayout is solc's storageLayout output for one contract
+type StorageLayout struct {
+	Storage []StorageItem          `json:"storage"`
+	Types   map[string]StorageType `json:"types"`
+}
+
+// StorageItem is a state variable or struct member
+type StorageItem struct {
+	Contract string `json:"contract,omitempty"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"`
+	Slot     string `json:"slot"` // decimal
+	Type     string `json:"type"` // key into StorageLayout.Types
+}
+
+// StorageType describes a type referenced by StorageItem.Type
+type StorageType struct {
+	Encoding      string        `json:"encoding"` // inplace, mapping, dynamic_array or bytes
+	Label         string        `json:"label"`
+	NumberOfBytes string        `json:"numberOfBytes"`
+	Base          string        `json:"base,omitempty"`
+	Key           string        `json:"key,omitempty"`
+	Value         string        `json:"value,omitempty"`
+	Members       []StorageItem `json:"members,omitempty"`
+}
+
+// ParseLayout decodes a storage layout
+func ParseLayout(data []byte) (*StorageLayout, error) {
+	var layout StorageLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("failed to parse storage layout: %v", err)
+	}
+	if layout.Storage == nil {
+		return nil, errors.New("failed to parse storage layout: no storage entries")
+	}
+	return &layout, nil
+}
+
+// LoadLayout reads a storage layout file, such as build/<Name>.storage-layout.json
+func LoadLayout(path string) (*StorageLayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage layout: %v", err)
+	}
+	return ParseLayout(data)
+}
+
+// Severity of a layout issue
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Issue is one difference between two storage layouts
+type Issue struct {
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	return string(i.Severity) + ": " + i.Message
+}
+
+// HasErrors reports whether any issue makes the upgrade unsafe
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckLayout compares the layout of the current implementation with its replacement.
+// Every existing variable must stay at its slot and offset with a compatible type; new
+// variables may only be appended or carved out of a trailing __gap array. A variable whose
+// label disappeared but whose slot and type are unchanged is reported as a rename warning.
+func CheckLayout(current, next *StorageLayout) []Issue {
+	var issues []Issue
+	newLabels := make(map[string]StorageItem)
+	for _, item := range next.Storage {
+		newLabels[item.Label] = item
+	}
+
+	for _, item := range current.Storage {
+		if isGap(item) {
+			issues = append(issues, checkGap(current, next, item)...)
+			continue
+		}
+
+		replacement, ok := itemAt(next.Storage, item.Slot, item.Offset)
+		if !ok || isGap(replacement) {
+			if moved, ok := newLabels[item.Label]; ok {
+				issues = append(issues, Issue{Error, fmt.Sprintf("%s moved from slot %s offset %d to slot %s offset %d",
+					item.Label, item.Slot, item.Offset, moved.Slot, moved.Offset)})
+			} else {
+				issues = append(issues, Issue{Error, fmt.Sprintf("%s (slot %s offset %d) was removed", item.Label, item.Slot, item.Offset)})
+			}
+			continue
+		}
+
+		if moved, ok := newLabels[item.Label]; ok && replacement.Label != item.Label {
+			issues = append(issues, Issue{Error, fmt.Sprintf("%s moved from slot %s to slot %s; %s was inserted in its place",
+				item.Label, item.Slot, moved.Slot, replacement.Label)})
+			continue
+		}
+		if reason := compareTypes(current, item.Type, next, replacement.Type, false); reason != "" {
+			issues = append(issues, Issue{Error, fmt.Sprintf("%s (slot %s) changed type from %s to %s: %s",
+				item.Label, item.Slot, typeLabel(current, item.Type), typeLabel(next, replacement.Type), reason)})
+			continue
+		}
+		if replacement.Label != item.Label {
+			issues = append(issues, Issue{Warning, fmt.Sprintf("%s (slot %s) was renamed to %s", item.Label, item.Slot, replacement.Label)})
+		}
+	}
+	return issues
+}
+
+// checkGap allows new variables inside an old __gap as long as nothing extends past its end
+func checkGap(current, next *StorageLayout, gap StorageItem) []Issue {
+	start := slotOf(gap)
+	end := new(big.Int).Add(start, slotsOf(current, gap.Type))
+
+	var issues []Issue
+	for _, item := range next.Storage {
+		itemStart := slotOf(item)
+		if itemStart.Cmp(start) < 0 || itemStart.Cmp(end) >= 0 {
+			continue
+		}
+		itemEnd := new(big.Int).Add(itemStart, slotsOf(next, item.Type))
+		if itemEnd.Cmp(end) > 0 {
+			issues = append(issues, Issue{Error, fmt.Sprintf("%s (slot %s) extends past the end of %s at slot %s; shrink the gap",
+				item.Label, item.Slot, gap.Label, end)})
+		}
+	}
+	return issues
+}
+
+// compareTypes returns why two types are incompatible, or "" if they are compatible.
+// grow allows structs to gain members, which is safe when they live behind a hash
+// (mapping values) rather than inline.
+func compareTypes(current *StorageLayout, oldID string, next *StorageLayout, newID string, grow bool) string {
+	o, ok := current.Types[oldID]
+	if !ok {
+		return "unknown type " + oldID
+	}
+	n, ok := next.Types[newID]
+	if !ok {
+		return "unknown type " + newID
+	}
+	if o.Encoding != n.Encoding {
+		return fmt.Sprintf("encoding %s became %s", o.Encoding, n.Encoding)
+	}
+
+	switch o.Encoding {
+	case "mapping":
+		if typeLabel(current, o.Key) != typeLabel(next, n.Key) {
+			return "mapping key changed"
+		}
+		return compareTypes(current, o.Value, next, n.Value, true)
+	case "dynamic_array":
+		// Elements are packed one after another, so the element size must not change
+		return compareTypes(current, o.Base, next, n.Base, false)
+	case "bytes":
+		return ""
+	}
+
+	if len(o.Members) > 0 || len(n.Members) > 0 {
+		if len(n.Members) < len(o.Members) {
+			return "struct members were removed"
+		}
+		for i, member := range o.Members {
+			replacement := n.Members[i]
+			if replacement.Slot != member.Slot || replacement.Offset != member.Offset {
+				return fmt.Sprintf("struct member %s moved", member.Label)
+			}
+			if reason := compareTypes(current, member.Type, next, replacement.Type, false); reason != "" {
+				return fmt.Sprintf("struct member %s: %s", member.Label, reason)
+			}
+		}
+		if !grow && o.NumberOfBytes != n.NumberOfBytes {
+			return fmt.Sprintf("size changed from %s to %s bytes", o.NumberOfBytes, n.NumberOfBytes)
+		}
+		return ""
+	}
+
+	if o.Base != "" {
+		if reason := compareTypes(current, o.Base, next, n.Base, false); reason != "" {
+			return reason
+		}
+	} else if normalizeLabel(o.Label) != normalizeLabel(n.Label) {
+		return "different type"
+	}
+	if o.NumberOfBytes != n.NumberOfBytes {
+		return fmt.Sprintf("size changed from %s to %s bytes", o.NumberOfBytes, n.NumberOfBytes)
+	}
+	return ""
+}
+
+// normalizeLabel treats contract and interface references as the addresses they are stored as
+func normalizeLabel(label string) string {
+	if strings.HasPrefix(label, "contract ") || strings.HasPrefix(label, "interface ") {
+		return "address"
+	}
+	return label
+}
+
+func typeLabel(layout *StorageLayout, id string) string {
+	if t, ok := layout.Types[id]; ok {
+		return t.Label
+	}
+	return id
+}
+
+func isGap(item StorageItem) bool {
+	return strings.HasPrefix(item.Label, "__gap")
+}
+
+func itemAt(items []StorageItem, slot string, offset int) (StorageItem, bool) {
+	for _, item := range items {
+		if item.Slot == slot && item.Offset == offset {
+			return item, true
+		}
+	}
+	return StorageItem{}, false
+}
+
+func slotOf(item StorageItem) *big.Int {
+	slot, ok := new(big.Int).SetString(item.Slot, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return slot
+}
+
+// slotsOf returns how many slots a type occupies inline
+func slotsOf(layout *StorageLayout, id string) *big.Int {
+	size, ok := new(big.Int).SetString(layout.Types[id].NumberOfBytes, 10)
+	if !ok || size.Sign() == 0 {
+		return big.NewInt(1)
+	}
+	slots := new(big.Int).Add(size, big.NewInt(31))
+	return slots.Div(slots, big.NewInt(32))
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// types shared by the layouts below, keyed like solc's storageLayout output
+var layoutTypes = map[string]StorageType{
+	"t_uint256":                     {Encoding: "inplace", Label: "uint256", NumberOfBytes: "32"},
+	"t_uint128":                     {Encoding: "inplace", Label: "uint128", NumberOfBytes: "16"},
+	"t_address":                     {Encoding: "inplace", Label: "address", NumberOfBytes: "20"},
+	"t_contract(IERC20)10":          {Encoding: "inplace", Label: "contract IERC20", NumberOfBytes: "20"},
+	"t_string_storage":              {Encoding: "bytes", Label: "string", NumberOfBytes: "32"},
+	"t_array(t_uint256)50_storage":  {Encoding: "inplace", Label: "uint256[50]", NumberOfBytes: "1600", Base: "t_uint256"},
+	"t_array(t_uint256)49_storage":  {Encoding: "inplace", Label: "uint256[49]", NumberOfBytes: "1568", Base: "t_uint256"},
+	"t_array(t_uint256)48_storage":  {Encoding: "inplace", Label: "uint256[48]", NumberOfBytes: "1536", Base: "t_uint256"},
+	"t_array(t_uint128)dyn_storage": {Encoding: "dynamic_array", Label: "uint128[]", NumberOfBytes: "32", Base: "t_uint128"},
+	"t_array(t_uint256)dyn_storage": {Encoding: "dynamic_array", Label: "uint256[]", NumberOfBytes: "32", Base: "t_uint256"},
+	"t_struct(Position)1_storage": {Encoding: "inplace", Label: "struct Position", NumberOfBytes: "64", Members: []StorageItem{
+		{Label: "amount", Slot: "0", Type: "t_uint256"},
+		{Label: "owner", Slot: "1", Type: "t_address"},
+	}},
+	"t_struct(Position)2_storage": {Encoding: "inplace", Label: "struct Position", NumberOfBytes: "96", Members: []StorageItem{
+		{Label: "amount", Slot: "0", Type: "t_uint256"},
+		{Label: "owner", Slot: "1", Type: "t_address"},
+		{Label: "since", Slot: "2", Type: "t_uint256"},
+	}},
+	"t_struct(Position)3_storage": {Encoding: "inplace", Label: "struct Position", NumberOfBytes: "32", Members: []StorageItem{
+		{Label: "amount", Slot: "0", Type: "t_uint256"},
+	}},
+	"t_mapping(t_address,t_struct(Position)1_storage)": {Encoding: "mapping", Label: "mapping(address => struct Position)",
+		NumberOfBytes: "32", Key: "t_address", Value: "t_struct(Position)1_storage"},
+	"t_mapping(t_address,t_struct(Position)2_storage)": {Encoding: "mapping", Label: "mapping(address => struct Position)",
+		NumberOfBytes: "32", Key: "t_address", Value: "t_struct(Position)2_storage"},
+	"t_mapping(t_address,t_struct(Position)3_storage)": {Encoding: "mapping", Label: "mapping(address => struct Position)",
+		NumberOfBytes: "32", Key: "t_address", Value: "t_struct(Position)3_storage"},
+	"t_mapping(t_uint256,t_struct(Position)1_storage)": {Encoding: "mapping", Label: "mapping(uint256 => struct Position)",
+		NumberOfBytes: "32", Key: "t_uint256", Value: "t_struct(Position)1_storage"},
+}
+
+// layout builds a layout from "label:slot:offset:type" entries
+func layout(entries ...string) *StorageLayout {
+	l := &StorageLayout{Types: layoutTypes}
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 4)
+		offset, _ := strconv.Atoi(parts[2])
+		l.Storage = append(l.Storage, StorageItem{Label: parts[0], Slot: parts[1], Offset: offset, Type: parts[3]})
+	}
+	return l
+}
+
+func TestCheckLayout(t *testing.T) {
+	base := layout("owner:0:0:t_address", "total:1:0:t_uint256", "__gap:2:0:t_array(t_uint256)50_storage")
+
+	tests := []struct {
+		name     string
+		current  *StorageLayout
+		next     *StorageLayout
+		errors   []string // substrings of the expected errors, in order
+		warnings []string
+	}{
+		{"identical", base, base, nil, nil},
+		{"appended after the gap", base,
+			layout("owner:0:0:t_address", "total:1:0:t_uint256", "__gap:2:0:t_array(t_uint256)50_storage", "fee:52:0:t_uint256"),
+			nil, nil},
+		{"carved out of the gap", base,
+			layout("owner:0:0:t_address", "total:1:0:t_uint256", "fee:2:0:t_uint256", "__gap:3:0:t_array(t_uint256)49_storage"),
+			nil, nil},
+		{"struct carved out of the gap", base,
+			layout("owner:0:0:t_address", "total:1:0:t_uint256", "position:2:0:t_struct(Position)1_storage", "__gap:4:0:t_array(t_uint256)48_storage"),
+			nil, nil},
+		{"extends past the gap", base,
+			layout("owner:0:0:t_address", "total:1:0:t_uint256", "history:2:0:t_array(t_uint256)50_storage", "position:51:0:t_struct(Position)1_storage"),
+			[]string{"position (slot 51) extends past the end of __gap at slot 52"}, nil},
+		{"inserted before", base,
+			layout("paused:0:0:t_uint256", "owner:1:0:t_address", "total:2:0:t_uint256", "__gap:3:0:t_array(t_uint256)49_storage"),
+			[]string{"owner moved from slot 0 to slot 1; paused was inserted", "total moved from slot 1 to slot 2; owner was inserted"}, nil},
+		{"swapped", base,
+			layout("total:0:0:t_uint256", "owner:1:0:t_address", "__gap:2:0:t_array(t_uint256)50_storage"),
+			[]string{"owner moved from slot 0 to slot 1", "total moved from slot 1 to slot 0"}, nil},
+		{"moved into a new offset", layout("owner:0:0:t_address", "flag:1:0:t_uint128"),
+			layout("owner:0:0:t_address", "flag:0:20:t_uint128"),
+			[]string{"flag moved from slot 1 offset 0 to slot 0 offset 20"}, nil},
+		{"removed", base,
+			layout("owner:0:0:t_address", "__gap:2:0:t_array(t_uint256)50_storage"),
+			[]string{"total (slot 1 offset 0) was removed"}, nil},
+		{"type changed", base,
+			layout("owner:0:0:t_uint256", "total:1:0:t_uint256", "__gap:2:0:t_array(t_uint256)50_storage"),
+			[]string{"owner (slot 0) changed type from address to uint256"}, nil},
+		{"contract stored as address", base,
+			layout("owner:0:0:t_contract(IERC20)10", "total:1:0:t_uint256", "__gap:2:0:t_array(t_uint256)50_storage"),
+			nil, nil},
+		{"renamed", base,
+			layout("admin:0:0:t_address", "total:1:0:t_uint256", "__gap:2:0:t_array(t_uint256)50_storage"),
+			nil, []string{"owner (slot 0) was renamed to admin"}},
+		{"inline struct grew", layout("position:0:0:t_struct(Position)1_storage", "total:2:0:t_uint256"),
+			layout("position:0:0:t_struct(Position)2_storage", "total:3:0:t_uint256"),
+			[]string{"size changed from 64 to 96 bytes", "total moved"}, nil},
+		{"mapped struct grew", layout("positions:0:0:t_mapping(t_address,t_struct(Position)1_storage)"),
+			layout("positions:0:0:t_mapping(t_address,t_struct(Position)2_storage)"),
+			nil, nil},
+		{"struct member removed", layout("positions:0:0:t_mapping(t_address,t_struct(Position)1_storage)"),
+			layout("positions:0:0:t_mapping(t_address,t_struct(Position)3_storage)"),
+			[]string{"struct members were removed"}, nil},
+		{"mapping key changed", layout("positions:0:0:t_mapping(t_address,t_struct(Position)1_storage)"),
+			layout("positions:0:0:t_mapping(t_uint256,t_struct(Position)1_storage)"),
+			[]string{"mapping key changed"}, nil},
+		{"array element grew", layout("history:0:0:t_array(t_uint128)dyn_storage"),
+			layout("history:0:0:t_array(t_uint256)dyn_storage"),
+			[]string{"different type"}, nil},
+		{"value became a string", layout("name:0:0:t_uint256"),
+			layout("name:0:0:t_string_storage"),
+			[]string{"encoding inplace became bytes"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs, warnings []string
+			for _, issue := range CheckLayout(tt.current, tt.next) {
+				if issue.Severity == Error {
+					errs = append(errs, issue.Message)
+				} else {
+					warnings = append(warnings, issue.Message)
+				}
+			}
+			expectIssues(t, "errors", errs, tt.errors)
+			expectIssues(t, "warnings", warnings, tt.warnings)
+			if HasErrors(CheckLayout(tt.current, tt.next)) != (len(tt.errors) > 0) {
+				t.Errorf("HasErrors disagrees with %v", errs)
+			}
+		})
+	}
+}
+
+func expectIssues(t *testing.T, kind string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s %q, want %q", kind, got, want)
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Fatalf("%s %q, want %q", kind, got, want)
+		}
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	parsed, err := ParseLayout([]byte(`{
+		"storage": [{"astId": 3, "contract": "src/Vault.sol:Vault", "label": "owner", "offset": 0, "slot": "0", "type": "t_address"}],
+		"types": {"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Storage) != 1 || parsed.Storage[0].Contract != "src/Vault.sol:Vault" || parsed.Types["t_address"].NumberOfBytes != "20" {
+		t.Errorf("unexpected layout %+v", parsed)
+	}
+
+	for _, doc := range []string{`{}`, `{"storage": "x"}`, `not json`} {
+		if _, err := ParseLayout([]byte(doc)); err == nil {
+			t.Errorf("ParseLayout(%s) succeeded, want error", doc)
+		}
+	}
+}
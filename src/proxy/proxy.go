@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Kind is a proxy pattern
+type Kind string
+
+const (
+	Transparent Kind = "transparent" // upgrades are admin calls intercepted by the proxy
+	UUPS        Kind = "uups"        // upgrades are implemented by the implementation (EIP-1822)
+)
+
+// ParseKind accepts "transparent" or "uups"
+func ParseKind(s string) (Kind, error) {
+	switch kind := Kind(strings.ToLower(s)); kind {
+	case Transparent, UUPS:
+		return kind, nil
+	}
+	return "", fmt.Errorf("unknown proxy kind %q (transparent or uups)", s)
+}
+
+// EIP-1967 storage slots: keccak256("eip1967.proxy.<name>") - 1
+var (
+	ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	AdminSlot          = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	BeaconSlot         = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+)
+
+// ErrNotProxy is returned when an address has no implementation in the EIP-1967 slot
+var ErrNotProxy = errors.New("not an EIP-1967 proxy")
+
+// ErrNotUUPS is returned when an implementation cannot upgrade a UUPS proxy
+var ErrNotUUPS = errors.New("implementation is not UUPS-compatible")
+
+const proxyABI = `[
+	{"type":"constructor","stateMutability":"payable","inputs":[
+		{"name":"implementation","type":"address"},{"name":"data","type":"bytes"}]}
+]`
+
+const transparentABI = `[
+	{"type":"constructor","stateMutability":"payable","inputs":[
+		{"name":"implementation","type":"address"},{"name":"admin","type":"address"},{"name":"data","type":"bytes"}]},
+	{"type":"function","name":"upgradeToAndCall","stateMutability":"payable","inputs":[
+		{"name":"newImplementation","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"changeAdmin","stateMutability":"nonpayable","inputs":[
+		{"name":"newAdmin","type":"address"}],"outputs":[]},
+	{"type":"event","name":"Upgraded","inputs":[{"name":"implementation","type":"address","indexed":true}]},
+	{"type":"event","name":"AdminChanged","inputs":[
+		{"name":"previousAdmin","type":"address","indexed":false},{"name":"newAdmin","type":"address","indexed":false}]}
+]`
+
+// uupsABI is the part of a UUPS implementation (OpenZeppelin UUPSUpgradeable) the proxy tooling calls
+const uupsABI = `[
+	{"type":"function","name":"upgradeToAndCall","stateMutability":"payable","inputs":[
+		{"name":"newImplementation","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"proxiableUUID","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"event","name":"Upgraded","inputs":[{"name":"implementation","type":"address","indexed":true}]}
+]`
+
+// proxyAdminABI is OpenZeppelin's ProxyAdmin, the admin of v5 transparent proxies
+const proxyAdminABI = `[
+	{"type":"function","name":"upgradeAndCall","stateMutability":"payable","inputs":[
+		{"name":"proxy","type":"address"},{"name":"implementation","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"owner","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]}
+]`
+
+var (
+	// ProxyABI is the ERC-1967 proxy deployed for UUPS implementations
+	ProxyABI = mustABI(proxyABI)
+	// TransparentABI is the transparent proxy, including its admin functions
+	TransparentABI = mustABI(transparentABI)
+	// UUPSABI upgrades a UUPS proxy through its implementation
+	UUPSABI = mustABI(uupsABI)
+	// ProxyAdminABI upgrades OpenZeppelin v5 transparent proxies
+	ProxyAdminABI = mustABI(proxyAdminABI)
+)
+
+func mustABI(definition string) *abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return &parsed
+}
+
+// ABI returns the ABI of a proxy kind; its constructor takes the implementation,
+// the admin for transparent proxies, and the initializer calldata
+func ABI(kind Kind) *abi.ABI {
+	if kind == Transparent {
+		return TransparentABI
+	}
+	return ProxyABI
+}
+
+// CreationCode returns the creation code of a proxy kind, without constructor arguments
+func CreationCode(kind Kind) []byte {
+	return creationCode(kind)
+}
+
+// StorageReader is implemented by ethclient.Client
+type StorageReader interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// Info is what the EIP-1967 slots of a proxy hold; zero addresses for empty slots
+type Info struct {
+	Address        common.Address
+	Implementation common.Address
+	Admin          common.Address
+	Beacon         common.Address
+}
+
+// Kind guesses the proxy pattern: an admin means transparent, otherwise UUPS
+func (i *Info) Kind() Kind {
+	if i.Admin != (common.Address{}) {
+		return Transparent
+	}
+	return UUPS
+}
+
+// Inspect reads the EIP-1967 slots of a proxy
+func Inspect(ctx context.Context, reader StorageReader, address common.Address) (*Info, error) {
+	info := &Info{Address: address}
+	for _, slot := range []struct {
+		key common.Hash
+		out *common.Address
+	}{
+		{ImplementationSlot, &info.Implementation},
+		{AdminSlot, &info.Admin},
+		{BeaconSlot, &info.Beacon},
+	} {
+		value, err := reader.StorageAt(ctx, address, slot.key, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read slot %s of %s: %v", slot.key.Hex(), address.Hex(), err)
+		}
+		*slot.out = common.BytesToAddress(value)
+	}
+	if info.Implementation == (common.Address{}) && info.Beacon == (common.Address{}) {
+		return info, fmt.Errorf("%w: %s has an empty implementation slot", ErrNotProxy, address.Hex())
+	}
+	return info, nil
+}
+
+// Implementation reads the implementation slot of a proxy
+func Implementation(ctx context.Context, reader StorageReader, address common.Address) (common.Address, error) {
+	info, err := Inspect(ctx, reader, address)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return info.Implementation, nil
+}
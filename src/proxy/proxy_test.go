@@ -0,0 +1,318 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+// proxyEnv is a simulated chain with a Counter implementation; account 0 is the admin
+type proxyEnv struct {
+	t     *testing.T
+	ctx   context.Context
+	chain *simchain.Chain
+	impl  common.Address
+}
+
+func newProxyEnv(t *testing.T) *proxyEnv {
+	t.Helper()
+	chain, err := simchain.New(2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+
+	e := &proxyEnv{t: t, ctx: context.Background(), chain: chain}
+	e.impl = e.deployCounter()
+	return e
+}
+
+func (e *proxyEnv) auth(account int) *bind.TransactOpts {
+	e.t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(e.chain.Key(account), simchain.ChainID)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return auth
+}
+
+func (e *proxyEnv) deployCounter() common.Address {
+	e.t.Helper()
+	address, _, _, err := contracts.DeployCounter(e.auth(0), e.chain.Client())
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return address
+}
+
+func (e *proxyEnv) calldata(method string) []byte {
+	e.t.Helper()
+	parsed, err := contracts.CounterMetaData.GetAbi()
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	data, err := parsed.Pack(method)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return data
+}
+
+// deploy deploys a proxy of kind in front of implementation and returns its address and receipt
+func (e *proxyEnv) deploy(kind Kind, implementation common.Address, init []byte) (common.Address, *types.Receipt, error) {
+	args := []interface{}{implementation}
+	if kind == Transparent {
+		args = append(args, e.chain.Address(0))
+	}
+	args = append(args, init)
+
+	address, tx, _, err := bind.DeployContract(e.auth(0), *ABI(kind), CreationCode(kind), e.chain.Client(), args...)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return address, e.receipt(tx), nil
+}
+
+func (e *proxyEnv) receipt(tx *types.Transaction) *types.Receipt {
+	e.t.Helper()
+	receipt, err := e.chain.Client().TransactionReceipt(e.ctx, tx.Hash())
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		e.t.Fatalf("transaction %s reverted", tx.Hash().Hex())
+	}
+	return receipt
+}
+
+// admin transacts a proxy admin function from account
+func (e *proxyEnv) admin(account int, proxy common.Address, method string, args ...interface{}) (*types.Receipt, error) {
+	contract := bind.NewBoundContract(proxy, *TransparentABI, e.chain.Client(), e.chain.Client(), e.chain.Client())
+	tx, err := contract.Transact(e.auth(account), method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return e.receipt(tx), nil
+}
+
+// count reads getCount through the proxy as account
+func (e *proxyEnv) count(proxy common.Address, account int) (int64, error) {
+	counter, err := contracts.NewCounterCaller(proxy, e.chain.Client())
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	count, err := counter.GetCount(&bind.CallOpts{From: e.chain.Address(account)})
+	if err != nil {
+		return 0, err
+	}
+	return count.Int64(), nil
+}
+
+func (e *proxyEnv) expectCount(proxy common.Address, account int, want int64) {
+	e.t.Helper()
+	got, err := e.count(proxy, account)
+	if err != nil {
+		e.t.Fatalf("getCount as account %d: %v", account, err)
+	}
+	if got != want {
+		e.t.Fatalf("count %d, want %d", got, want)
+	}
+}
+
+func (e *proxyEnv) expectSlots(proxy, implementation, admin common.Address) {
+	e.t.Helper()
+	info, err := Inspect(e.ctx, e.chain.Client(), proxy)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if info.Implementation != implementation || info.Admin != admin || info.Beacon != (common.Address{}) {
+		e.t.Fatalf("slots %+v, want implementation %s admin %s", info, implementation.Hex(), admin.Hex())
+	}
+}
+
+// expectLog checks that receipt carries one log of the proxy with the given topics and data
+func expectLog(t *testing.T, receipt *types.Receipt, proxy common.Address, data []byte, topics ...common.Hash) {
+	t.Helper()
+	for _, log := range receipt.Logs {
+		if log.Address != proxy || len(log.Topics) != len(topics) || log.Topics[0] != topics[0] {
+			continue
+		}
+		for i := range topics {
+			if log.Topics[i] != topics[i] {
+				t.Fatalf("log topics %v, want %v", log.Topics, topics)
+			}
+		}
+		if !bytes.Equal(log.Data, data) {
+			t.Fatalf("log data %x, want %x", log.Data, data)
+		}
+		return
+	}
+	t.Fatalf("no log with topics %v in %d logs", topics, len(receipt.Logs))
+}
+
+func addressTopic(a common.Address) common.Hash {
+	return common.BytesToHash(a.Bytes())
+}
+
+func addressWords(addresses ...common.Address) []byte {
+	var data []byte
+	for _, a := range addresses {
+		data = append(data, common.LeftPadBytes(a.Bytes(), 32)...)
+	}
+	return data
+}
+
+func TestTransparentProxyRoundTrip(t *testing.T) {
+	e := newProxyEnv(t)
+	admin, user := e.chain.Address(0), e.chain.Address(1)
+
+	proxy, receipt, err := e.deploy(Transparent, e.impl, e.calldata("increment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLog(t, receipt, proxy, nil, upgradedTopic, addressTopic(e.impl))
+	expectLog(t, receipt, proxy, addressWords(common.Address{}, admin), adminChangedTopic)
+	e.expectSlots(proxy, e.impl, admin)
+	if info, _ := Inspect(e.ctx, e.chain.Client(), proxy); info.Kind() != Transparent {
+		t.Errorf("kind %s, want transparent", info.Kind())
+	}
+
+	// The initializer ran in the proxy's storage, not the implementation's
+	e.expectCount(proxy, 1, 1)
+	counter, err := contracts.NewCounterCaller(e.impl, e.chain.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := counter.GetCount(nil); err != nil || count.Sign() != 0 {
+		t.Fatalf("implementation count %v, %v", count, err)
+	}
+
+	// Anyone but the admin is delegated
+	proxied, err := contracts.NewCounterTransactor(proxy, e.chain.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := proxied.Increment(e.auth(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.receipt(tx)
+	e.expectCount(proxy, 1, 2)
+
+	// The admin cannot reach the implementation
+	if _, err := e.count(proxy, 0); err == nil {
+		t.Fatal("admin call was delegated")
+	}
+
+	// upgradeToAndCall keeps the storage and runs the call against the new implementation
+	next := e.deployCounter()
+	receipt, err = e.admin(0, proxy, "upgradeToAndCall", next, e.calldata("increment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLog(t, receipt, proxy, nil, upgradedTopic, addressTopic(next))
+	e.expectSlots(proxy, next, admin)
+	e.expectCount(proxy, 1, 3)
+
+	// Without data only the slot changes
+	if _, err := e.admin(0, proxy, "upgradeToAndCall", e.impl, []byte{}); err != nil {
+		t.Fatal(err)
+	}
+	e.expectSlots(proxy, e.impl, admin)
+	e.expectCount(proxy, 1, 3)
+
+	// A reverting call bubbles its reason up and undoes the upgrade
+	if _, err := e.admin(0, proxy, "upgradeToAndCall", next, e.calldata("reset")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.admin(0, proxy, "upgradeToAndCall", e.impl, e.calldata("decrement")); err == nil ||
+		!strings.Contains(err.Error(), "Counter cannot be negative") {
+		t.Fatalf("reverting upgrade call: got %v", err)
+	}
+	e.expectSlots(proxy, next, admin)
+
+	// Implementations without code, unknown admin selectors and non-admin upgrades are rejected
+	if _, err := e.admin(0, proxy, "upgradeToAndCall", user, []byte{}); err == nil {
+		t.Fatal("upgrade to an account without code succeeded")
+	}
+	if _, err := e.count(proxy, 0); err == nil {
+		t.Fatal("unknown admin selector did not revert")
+	}
+	if _, err := e.admin(1, proxy, "upgradeToAndCall", e.impl, []byte{}); err == nil {
+		t.Fatal("non-admin upgrade succeeded")
+	}
+	e.expectSlots(proxy, next, admin)
+
+	// changeAdmin hands the admin role over
+	if _, err := e.admin(0, proxy, "changeAdmin", common.Address{}); err == nil {
+		t.Fatal("changeAdmin to the zero address succeeded")
+	}
+	receipt, err = e.admin(0, proxy, "changeAdmin", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLog(t, receipt, proxy, addressWords(admin, user), adminChangedTopic)
+	e.expectSlots(proxy, next, user)
+	e.expectCount(proxy, 0, 0)
+	if _, err := e.count(proxy, 1); err == nil {
+		t.Fatal("new admin call was delegated")
+	}
+}
+
+func TestUUPSProxy(t *testing.T) {
+	e := newProxyEnv(t)
+
+	proxy, receipt, err := e.deploy(UUPS, e.impl, e.calldata("increment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLog(t, receipt, proxy, nil, upgradedTopic, addressTopic(e.impl))
+	e.expectSlots(proxy, e.impl, common.Address{})
+	// Without an admin every caller is delegated
+	e.expectCount(proxy, 0, 1)
+	e.expectCount(proxy, 1, 1)
+}
+
+func TestProxyConstructorReverts(t *testing.T) {
+	e := newProxyEnv(t)
+
+	for _, kind := range []Kind{Transparent, UUPS} {
+		if _, _, err := e.deploy(kind, e.impl, e.calldata("decrement")); err == nil ||
+			!strings.Contains(err.Error(), "Counter cannot be negative") {
+			t.Errorf("%s: reverting initializer: got %v", kind, err)
+		}
+		if _, _, err := e.deploy(kind, e.chain.Address(1), nil); err == nil {
+			t.Errorf("%s: implementation without code was accepted", kind)
+		}
+	}
+}
+
+func TestInspectRejectsNonProxy(t *testing.T) {
+	e := newProxyEnv(t)
+	if _, err := Inspect(e.ctx, e.chain.Client(), e.impl); !errors.Is(err, ErrNotProxy) {
+		t.Fatalf("got %v, want ErrNotProxy", err)
+	}
+}
+
+func TestSlotConstants(t *testing.T) {
+	for name, slot := range map[string]common.Hash{
+		"eip1967.proxy.implementation": ImplementationSlot,
+		"eip1967.proxy.admin":          AdminSlot,
+		"eip1967.proxy.beacon":         BeaconSlot,
+	} {
+		want := new(big.Int).Sub(crypto.Keccak256Hash([]byte(name)).Big(), big.NewInt(1))
+		if slot.Big().Cmp(want) != 0 {
+			t.Errorf("%s slot %s, want keccak256 - 1", name, slot.Hex())
+		}
+	}
+}
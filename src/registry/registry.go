@@ -0,0 +1,169 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Version is the registry file format written by this package
+const Version = 1
+
+// DefaultDir holds one <chainID>.json per network
+const DefaultDir = "deployments"
+
+// DirFromEnv returns DEPLOYMENTS_DIR, falling back to DefaultDir
+func DirFromEnv() string {
+	if dir := os.Getenv("DEPLOYMENTS_DIR"); dir != "" {
+		return dir
+	}
+	return DefaultDir
+}
+
+// Deployment is one recorded contract deployment
+type Deployment struct {
+	Name            string         `json:"name"`
+	Address         common.Address `json:"address"`
+	TxHash          common.Hash    `json:"txHash"`
+	Block           uint64         `json:"block"`
+	Deployer        common.Address `json:"deployer"`
+	BytecodeHash    common.Hash    `json:"bytecodeHash"`    // keccak256 of the linked creation code
+	ConstructorArgs hexutil.Bytes  `json:"constructorArgs"` // ABI-encoded constructor arguments
+	Salt            *common.Hash   `json:"salt,omitempty"`  // CREATE2 salt; nil for plain CREATE deployments
+	DeployedAt      time.Time      `json:"deployedAt"`
+
+	// Proxies record their pattern and current implementation; implementations their storage layout
+	Proxy          string          `json:"proxy,omitempty"`
+	Implementation *common.Address `json:"implementation,omitempty"`
+	StorageLayout  json.RawMessage `json:"storageLayout,omitempty"`
+}
+
+// BytecodeHash hashes creation code the way deployments record it
+func BytecodeHash(creation []byte) common.Hash {
+	return crypto.Keccak256Hash(creation)
+}
+
+// Registry is the deployment record of one chain
+type Registry struct {
+	Version     int          `json:"version"`
+	ChainID     uint64       `json:"chainId"`
+	Deployments []Deployment `json:"deployments"`
+
+	path string
+}
+
+// Path returns the registry file of a chain
+func Path(dir string, chainID *big.Int) string {
+	return filepath.Join(dir, chainID.String()+".json")
+}
+
+// Load reads the registry of a chain; a missing file is an empty registry
+func Load(dir string, chainID *big.Int) (*Registry, error) {
+	path := Path(dir, chainID)
+	registry := &Registry{Version: Version, ChainID: chainID.Uint64(), path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deployment registry: %v", err)
+	}
+	if err := json.Unmarshal(data, registry); err != nil {
+		return nil, fmt.Errorf("failed to parse deployment registry %s: %v", path, err)
+	}
+	if registry.Version > Version {
+		return nil, fmt.Errorf("deployment registry %s has version %d, this tool understands up to %d", path, registry.Version, Version)
+	}
+	if registry.ChainID != chainID.Uint64() {
+		return nil, fmt.Errorf("deployment registry %s is for chain %d, not %s", path, registry.ChainID, chainID)
+	}
+	registry.Version = Version
+	return registry, nil
+}
+
+// Path returns the file the registry is stored in
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// Save writes the registry atomically
+func (r *Registry) Save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create registry directory: %v", err)
+	}
+	if r.Deployments == nil {
+		r.Deployments = []Deployment{}
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write deployment registry: %v", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to write deployment registry: %v", err)
+	}
+	return nil
+}
+
+// Add records a deployment
+func (r *Registry) Add(d Deployment) {
+	r.Deployments = append(r.Deployments, d)
+}
+
+// Latest returns the most recent deployment of a contract name (case-insensitive)
+func (r *Registry) Latest(name string) (Deployment, bool) {
+	for i := len(r.Deployments) - 1; i >= 0; i-- {
+		if strings.EqualFold(r.Deployments[i].Name, name) {
+			return r.Deployments[i], true
+		}
+	}
+	return Deployment{}, false
+}
+
+// FindIdentical returns the most recent deployment of name with the same creation code and constructor arguments
+func (r *Registry) FindIdentical(name string, bytecodeHash common.Hash, args []byte) (Deployment, bool) {
+	for i := len(r.Deployments) - 1; i >= 0; i-- {
+		d := r.Deployments[i]
+		if strings.EqualFold(d.Name, name) && d.BytecodeHash == bytecodeHash && string(d.ConstructorArgs) == string(args) {
+			return d, true
+		}
+	}
+	return Deployment{}, false
+}
+
+// Find returns the most recent deployment at address, for updating in place
+func (r *Registry) Find(address common.Address) *Deployment {
+	for i := len(r.Deployments) - 1; i >= 0; i-- {
+		if r.Deployments[i].Address == address {
+			return &r.Deployments[i]
+		}
+	}
+	return nil
+}
+
+// Prune removes the deployments drop selects and returns them
+func (r *Registry) Prune(drop func(Deployment) bool) []Deployment {
+	var kept, removed []Deployment
+	for _, d := range r.Deployments {
+		if drop(d) {
+			removed = append(removed, d)
+		} else {
+			kept = append(kept, d)
+		}
+	}
+	r.Deployments = kept
+	return removed
+}
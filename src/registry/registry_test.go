@@ -0,0 +1,156 @@
+package registry
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var chainID = big.NewInt(1337)
+
+func deployment(name string, address byte, code string, args ...byte) Deployment {
+	return Deployment{
+		Name:            name,
+		Address:         common.Address{19: address},
+		BytecodeHash:    BytecodeHash([]byte(code)),
+		ConstructorArgs: args,
+		DeployedAt:      time.Date(2025, 8, 20, 12, 0, int(address), 0, time.UTC),
+	}
+}
+
+func TestLoadAndSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DefaultDir)
+
+	registry, err := Load(dir, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.Deployments) != 0 || registry.Path() != filepath.Join(dir, "1337.json") {
+		t.Fatalf("missing file: got %+v at %s", registry.Deployments, registry.Path())
+	}
+	salt := common.HexToHash("0x01")
+	proxied := deployment("Counter", 2, "counter", 1)
+	proxied.Salt = &salt
+	registry.Add(deployment("Counter", 1, "counter"))
+	registry.Add(proxied)
+	if err := registry.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(dir, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Deployments) != 2 || loaded.Deployments[1].Salt == nil || *loaded.Deployments[1].Salt != salt ||
+		!loaded.Deployments[1].DeployedAt.Equal(proxied.DeployedAt) || string(loaded.Deployments[1].ConstructorArgs) != "\x01" {
+		t.Errorf("loaded %+v", loaded.Deployments)
+	}
+	if _, err := os.Stat(registry.Path() + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"newer version", `{"version": 2, "chainId": 1337, "deployments": []}`, "has version 2, this tool understands up to 1"},
+		{"other chain", `{"version": 1, "chainId": 1, "deployments": []}`, "is for chain 1, not 1337"},
+		{"invalid JSON", `{"version": 1,`, "failed to parse deployment registry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(Path(dir, chainID), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(dir, chainID); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Older files are upgraded to the current version
+	dir := t.TempDir()
+	if err := os.WriteFile(Path(dir, chainID), []byte(`{"version": 0, "chainId": 1337, "deployments": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if registry, err := Load(dir, chainID); err != nil || registry.Version != Version {
+		t.Errorf("older version: got %+v, %v", registry, err)
+	}
+}
+
+func TestFindIdentical(t *testing.T) {
+	registry := &Registry{}
+	registry.Add(deployment("Counter", 1, "counter"))
+	registry.Add(deployment("Counter", 2, "counter", 7))
+	registry.Add(deployment("Counter", 3, "counter"))
+	registry.Add(deployment("Box", 4, "counter"))
+
+	tests := []struct {
+		name    string
+		code    string
+		args    []byte
+		address byte // 0 when nothing matches
+	}{
+		{"Counter", "counter", nil, 3},
+		{"counter", "counter", nil, 3},
+		{"Counter", "counter", []byte{7}, 2},
+		{"Counter", "counter", []byte{8}, 0},
+		{"Counter", "counter v2", nil, 0},
+		{"Box", "counter", nil, 4},
+		{"Token", "counter", nil, 0},
+	}
+	for _, tt := range tests {
+		d, ok := registry.FindIdentical(tt.name, BytecodeHash([]byte(tt.code)), tt.args)
+		if ok != (tt.address != 0) || d.Address != (common.Address{19: tt.address}) {
+			t.Errorf("FindIdentical(%s, %s, %x) = %s, %v", tt.name, tt.code, tt.args, d.Address.Hex(), ok)
+		}
+	}
+
+	if d, ok := registry.Latest("COUNTER"); !ok || d.Address[19] != 3 {
+		t.Errorf("Latest = %s, %v", d.Address.Hex(), ok)
+	}
+	if d := registry.Find(common.Address{19: 2}); d == nil || len(d.ConstructorArgs) != 1 {
+		t.Errorf("Find = %+v", d)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	registry := &Registry{}
+	for i := byte(1); i <= 4; i++ {
+		registry.Add(deployment("Counter", i, "counter"))
+	}
+
+	removed := registry.Prune(func(d Deployment) bool { return d.Address[19]%2 == 0 })
+	if len(removed) != 2 || removed[0].Address[19] != 2 || removed[1].Address[19] != 4 {
+		t.Errorf("removed %+v", removed)
+	}
+	if len(registry.Deployments) != 2 || registry.Deployments[0].Address[19] != 1 || registry.Deployments[1].Address[19] != 3 {
+		t.Errorf("kept %+v", registry.Deployments)
+	}
+	if removed := registry.Prune(func(Deployment) bool { return false }); len(removed) != 0 || len(registry.Deployments) != 2 {
+		t.Errorf("pruning nothing removed %+v", removed)
+	}
+	registry.Prune(func(Deployment) bool { return true })
+
+	// An emptied registry saves an empty list rather than null
+	registry.path = filepath.Join(t.TempDir(), "1337.json")
+	if err := registry.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(registry.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"deployments": []`) {
+		t.Errorf("saved %s", data)
+	}
+}
@@ -0,0 +1,229 @@
+package rpcbatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/fuckEthereum/src/blockref"
+)
+
+// Defaults for chunking and concurrency
+const (
+	DefaultBatchSize = 100
+	DefaultWorkers   = 4
+)
+
+// ErrNotFound is the per-item error for a receipt or block the node does not know
+var ErrNotFound = errors.New("not found")
+
+// Caller is implemented by rpc.Client (ethclient.Client.Client())
+type Caller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// Options tunes how requests are split up
+type Options struct {
+	BatchSize int // requests per JSON-RPC batch (default DefaultBatchSize)
+	Workers   int // batches in flight at once (default DefaultWorkers)
+}
+
+// Result is the outcome of one item; Err is set instead of Value when that item failed
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Request is one call of a batch
+type Request struct {
+	Method string
+	Args   []interface{}
+}
+
+// Do sends the requests in chunks of BatchSize with up to Workers chunks in flight and
+// returns the raw result of each. A failed chunk fails every item in it; other chunks
+// are unaffected.
+func Do(ctx context.Context, caller Caller, requests []Request, opts Options) []Result[json.RawMessage] {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	raws := make([]json.RawMessage, len(requests))
+	elems := make([]rpc.BatchElem, len(requests))
+	for i, req := range requests {
+		elems[i] = rpc.BatchElem{Method: req.Method, Args: req.Args, Result: &raws[i]}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for start := 0; start < len(elems); start += batchSize {
+		end := start + batchSize
+		if end > len(elems) {
+			end = len(elems)
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(batch []rpc.BatchElem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := caller.BatchCallContext(ctx, batch); err != nil {
+				for i := range batch {
+					batch[i].Error = fmt.Errorf("batch failed: %v", err)
+				}
+			}
+		}(elems[start:end])
+	}
+	wg.Wait()
+
+	results := make([]Result[json.RawMessage], len(requests))
+	for i, elem := range elems {
+		switch {
+		case elem.Error != nil:
+			results[i].Err = elem.Error
+		case len(raws[i]) == 0 || string(raws[i]) == "null":
+			results[i].Err = ErrNotFound
+		default:
+			results[i].Value = raws[i]
+		}
+	}
+	return results
+}
+
+// call runs Do and decodes every successful result
+func call[T any](ctx context.Context, caller Caller, requests []Request, opts Options, decode func(json.RawMessage) (T, error)) []Result[T] {
+	raws := Do(ctx, caller, requests, opts)
+	results := make([]Result[T], len(raws))
+	for i, raw := range raws {
+		if raw.Err != nil {
+			results[i].Err = raw.Err
+			continue
+		}
+		value, err := decode(raw.Value)
+		if err != nil {
+			results[i].Err = fmt.Errorf("failed to decode %s result: %v", requests[i].Method, err)
+			continue
+		}
+		results[i].Value = value
+	}
+	return results
+}
+
+func decodeJSON[T any](raw json.RawMessage) (T, error) {
+	var v T
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+func accountRequests(method string, accounts []common.Address, at blockref.Ref) []Request {
+	requests := make([]Request, len(accounts))
+	for i, account := range accounts {
+		requests[i] = Request{Method: method, Args: []interface{}{account, at.RPCArg()}}
+	}
+	return requests
+}
+
+// Balances reads the balance of every account at the ref
+func Balances(ctx context.Context, caller Caller, accounts []common.Address, at blockref.Ref, opts Options) []Result[*big.Int] {
+	results := call(ctx, caller, accountRequests("eth_getBalance", accounts, at), opts, func(raw json.RawMessage) (*big.Int, error) {
+		v, err := decodeJSON[hexutil.Big](raw)
+		return (*big.Int)(&v), err
+	})
+	for i := range results {
+		results[i].Err = blockref.Wrap(results[i].Err, at)
+	}
+	return results
+}
+
+// Nonces reads the transaction count of every account at the ref
+func Nonces(ctx context.Context, caller Caller, accounts []common.Address, at blockref.Ref, opts Options) []Result[uint64] {
+	results := call(ctx, caller, accountRequests("eth_getTransactionCount", accounts, at), opts, func(raw json.RawMessage) (uint64, error) {
+		v, err := decodeJSON[hexutil.Uint64](raw)
+		return uint64(v), err
+	})
+	for i := range results {
+		results[i].Err = blockref.Wrap(results[i].Err, at)
+	}
+	return results
+}
+
+// Codes reads the code of every account at the ref; accounts without code get an empty slice
+func Codes(ctx context.Context, caller Caller, accounts []common.Address, at blockref.Ref, opts Options) []Result[[]byte] {
+	results := call(ctx, caller, accountRequests("eth_getCode", accounts, at), opts, func(raw json.RawMessage) ([]byte, error) {
+		v, err := decodeJSON[hexutil.Bytes](raw)
+		return v, err
+	})
+	for i := range results {
+		results[i].Err = blockref.Wrap(results[i].Err, at)
+	}
+	return results
+}
+
+// Receipts reads the receipt of every transaction; pending or unknown ones fail with ErrNotFound
+func Receipts(ctx context.Context, caller Caller, hashes []common.Hash, opts Options) []Result[*types.Receipt] {
+	requests := make([]Request, len(hashes))
+	for i, hash := range hashes {
+		requests[i] = Request{Method: "eth_getTransactionReceipt", Args: []interface{}{hash}}
+	}
+	return call(ctx, caller, requests, opts, func(raw json.RawMessage) (*types.Receipt, error) {
+		receipt := new(types.Receipt)
+		return receipt, json.Unmarshal(raw, receipt)
+	})
+}
+
+// Headers reads block headers by number
+func Headers(ctx context.Context, caller Caller, numbers []uint64, opts Options) []Result[*types.Header] {
+	return call(ctx, caller, blockRequests(numbers, false), opts, func(raw json.RawMessage) (*types.Header, error) {
+		header := new(types.Header)
+		return header, json.Unmarshal(raw, header)
+	})
+}
+
+// Blocks reads full blocks (with transactions and withdrawals) by number.
+// Uncle headers are not fetched; the blocks carry only their uncle hash.
+func Blocks(ctx context.Context, caller Caller, numbers []uint64, opts Options) []Result[*types.Block] {
+	return call(ctx, caller, blockRequests(numbers, true), opts, decodeBlock)
+}
+
+func blockRequests(numbers []uint64, full bool) []Request {
+	requests := make([]Request, len(numbers))
+	for i, number := range numbers {
+		requests[i] = Request{Method: "eth_getBlockByNumber", Args: []interface{}{hexutil.EncodeUint64(number), full}}
+	}
+	return requests
+}
+
+func decodeBlock(raw json.RawMessage) (*types.Block, error) {
+	header := new(types.Header)
+	if err := json.Unmarshal(raw, header); err != nil {
+		return nil, err
+	}
+	var body struct {
+		Hash         common.Hash          `json:"hash"`
+		Transactions []*types.Transaction `json:"transactions"`
+		Withdrawals  []*types.Withdrawal  `json:"withdrawals"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+	block := types.NewBlockWithHeader(header).WithBody(types.Body{
+		Transactions: body.Transactions,
+		Withdrawals:  body.Withdrawals,
+	})
+	if block.Hash() != body.Hash {
+		return nil, fmt.Errorf("block hash mismatch: node reports %s, header hashes to %s", body.Hash.Hex(), block.Hash().Hex())
+	}
+	return block, nil
+}
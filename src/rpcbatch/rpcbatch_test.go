@@ -0,0 +1,189 @@
+package rpcbatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/fuckEthereum/src/blockref"
+)
+
+// stub is a JSON-RPC endpoint that answers batches of test_* calls:
+//
+//	test_echo [x]  → x
+//	test_null      → null
+//	test_error     → a JSON-RPC error for that item
+//	test_poison    → the whole HTTP request fails
+//	eth_getBalance → 0x10
+type stub struct {
+	mu          sync.Mutex
+	sizes       []int
+	inFlight    int
+	maxInFlight int
+}
+
+type stubRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type stubError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type stubResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *stubError      `json:"error,omitempty"`
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var batch []stubRequest
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		http.Error(w, "expected a batch", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.sizes = append(s.sizes, len(batch))
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	// Hold the batch long enough for the others to pile up
+	time.Sleep(20 * time.Millisecond)
+
+	responses := make([]stubResponse, len(batch))
+	for i, req := range batch {
+		resp := stubResponse{JSONRPC: "2.0", ID: req.ID}
+		switch req.Method {
+		case "test_echo":
+			resp.Result = req.Params[0]
+		case "test_null":
+			resp.Result = json.RawMessage("null")
+		case "test_error":
+			resp.Error = &stubError{Code: -32000, Message: "item failed"}
+		case "test_poison":
+			http.Error(w, "upstream unavailable", http.StatusBadGateway)
+			return
+		case "eth_getBalance":
+			resp.Result = json.RawMessage(`"0x10"`)
+		default:
+			resp.Error = &stubError{Code: -32601, Message: "method not found"}
+		}
+		responses[i] = resp
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(responses)
+}
+
+func dialStub(t *testing.T) (*stub, *rpc.Client) {
+	t.Helper()
+	s := &stub{}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	client, err := rpc.DialHTTP(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return s, client
+}
+
+func echo(i int) Request {
+	return Request{Method: "test_echo", Args: []interface{}{i}}
+}
+
+func TestDoChunksAndWorkers(t *testing.T) {
+	s, client := dialStub(t)
+
+	requests := make([]Request, 23)
+	for i := range requests {
+		requests[i] = echo(i)
+	}
+	results := Do(context.Background(), client, requests, Options{BatchSize: 5, Workers: 2})
+
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("item %d: %v", i, result.Err)
+		}
+		var got int
+		if err := json.Unmarshal(result.Value, &got); err != nil || got != i {
+			t.Errorf("item %d = %s, want %d", i, result.Value, i)
+		}
+	}
+
+	total := 0
+	for _, size := range s.sizes {
+		if size > 5 {
+			t.Errorf("batch of %d exceeds BatchSize 5", size)
+		}
+		total += size
+	}
+	if len(s.sizes) != 5 || total != len(requests) {
+		t.Errorf("batch sizes %v, want 5 batches covering %d requests", s.sizes, len(requests))
+	}
+	if s.maxInFlight != 2 {
+		t.Errorf("%d batches in flight at most, want Workers = 2", s.maxInFlight)
+	}
+}
+
+func TestDoPerItemResults(t *testing.T) {
+	_, client := dialStub(t)
+
+	requests := []Request{
+		echo(0), {Method: "test_null"}, {Method: "test_error"}, echo(3), // first chunk
+		echo(4), {Method: "test_poison"}, echo(6), echo(7), // second chunk fails as a whole
+		echo(8), // third chunk
+	}
+	results := Do(context.Background(), client, requests, Options{BatchSize: 4, Workers: 1})
+
+	for _, i := range []int{0, 3, 8} {
+		if results[i].Err != nil {
+			t.Errorf("item %d: %v", i, results[i].Err)
+		}
+	}
+	if !errors.Is(results[1].Err, ErrNotFound) {
+		t.Errorf("null result: got %v, want ErrNotFound", results[1].Err)
+	}
+	if results[2].Err == nil || !strings.Contains(results[2].Err.Error(), "item failed") {
+		t.Errorf("item error: got %v", results[2].Err)
+	}
+	for i := 4; i < 8; i++ {
+		if results[i].Err == nil || !strings.Contains(results[i].Err.Error(), "batch failed") {
+			t.Errorf("item %d in the failed chunk: got %v", i, results[i].Err)
+		}
+	}
+}
+
+func TestBalancesDecodes(t *testing.T) {
+	_, client := dialStub(t)
+
+	accounts := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	for i, result := range Balances(context.Background(), client, accounts, blockref.Latest, Options{}) {
+		if result.Err != nil {
+			t.Fatalf("account %d: %v", i, result.Err)
+		}
+		if result.Value.Int64() != 16 {
+			t.Errorf("account %d balance %s, want 16", i, result.Value)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package simchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ChainID is the chain ID of every simulated chain
+var ChainID = big.NewInt(1337)
+
+// DefaultBalance is what each account starts with: 10000 ETH
+var DefaultBalance = new(big.Int).Mul(big.NewInt(10000), big.NewInt(params.Ether))
+
+// Chain is an in-process chain on go-ethereum's simulated backend, with pre-funded accounts.
+// Every sent transaction is mined into its own block right away, like an automining dev node,
+// so code that waits for receipts runs unchanged.
+type Chain struct {
+	backend *simulated.Backend
+	client  *Client
+	keys    []*ecdsa.PrivateKey
+}
+
+// New starts a chain with accounts funded with balance each; a nil balance means DefaultBalance
+func New(accounts int, balance *big.Int) (*Chain, error) {
+	return NewWithAlloc(accounts, balance, nil)
+}
+
+// NewWithAlloc is New with extra genesis accounts, e.g. contracts with preset code and storage
+func NewWithAlloc(accounts int, balance *big.Int, extra types.GenesisAlloc) (*Chain, error) {
+	if accounts < 1 {
+		return nil, errors.New("simulated chain needs at least one account")
+	}
+	if balance == nil {
+		balance = DefaultBalance
+	}
+
+	keys := make([]*ecdsa.PrivateKey, accounts)
+	alloc := make(types.GenesisAlloc, accounts+len(extra))
+	for address, account := range extra {
+		alloc[address] = account
+	}
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %v", err)
+		}
+		keys[i] = key
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = types.Account{Balance: new(big.Int).Set(balance)}
+	}
+
+	// Unprotected transactions are allowed so the keyless CREATE2 factory can be deployed
+	backend := simulated.NewBackend(alloc, func(nodeConf *node.Config, _ *ethconfig.Config) {
+		nodeConf.AllowUnprotectedTxs = true
+	})
+	client := backend.Client()
+	extended, ok := client.(extendedClient)
+	if !ok {
+		backend.Close()
+		return nil, errors.New("simulated client does not expose the ethclient methods")
+	}
+	// The embedded *ethclient.Client is a field named Client, which hides its Client() method
+	ec, ok := reflect.Indirect(reflect.ValueOf(client)).FieldByName("Client").Interface().(*ethclient.Client)
+	if !ok {
+		backend.Close()
+		return nil, errors.New("simulated client does not wrap an ethclient")
+	}
+
+	return &Chain{
+		backend: backend,
+		client:  &Client{Client: client, extended: extended, rpc: ec.Client(), commit: backend.Commit},
+		keys:    keys,
+	}, nil
+}
+
+// Client returns the automining client of the chain
+func (c *Chain) Client() *Client {
+	return c.client
+}
+
+// Backend returns the simulated backend, e.g. to commit empty blocks or adjust time
+func (c *Chain) Backend() *simulated.Backend {
+	return c.backend
+}
+
+// Accounts returns the number of pre-funded accounts
+func (c *Chain) Accounts() int {
+	return len(c.keys)
+}
+
+// Key returns the private key of account i
+func (c *Chain) Key(i int) *ecdsa.PrivateKey {
+	return c.keys[i]
+}
+
+// KeyHex returns the private key of account i in the hex form PRIVATE_KEY uses
+func (c *Chain) KeyHex(i int) string {
+	return common.Bytes2Hex(crypto.FromECDSA(c.keys[i]))
+}
+
+// Address returns the address of account i
+func (c *Chain) Address(i int) common.Address {
+	return crypto.PubkeyToAddress(c.keys[i].PublicKey)
+}
+
+// Close shuts the chain down
+func (c *Chain) Close() error {
+	return c.backend.Close()
+}
+
+// extendedClient is the part of *ethclient.Client that simulated.Client does not declare
+// but the underlying client implements
+type extendedClient interface {
+	BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error)
+	EstimateGasAtBlock(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (uint64, error)
+	TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error)
+}
+
+// Client talks to a Chain. It implements the interfaces the toolkit takes from *ethclient.Client
+// and mines a block after every transaction it sends.
+type Client struct {
+	simulated.Client
+	extended extendedClient
+	rpc      *rpc.Client
+	commit   func() common.Hash
+}
+
+// SendTransaction submits tx and mines it
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.commit()
+	return nil
+}
+
+// BalanceAtHash returns the balance of account as of the block with the given hash
+func (c *Client) BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error) {
+	return c.extended.BalanceAtHash(ctx, account, blockHash)
+}
+
+// EstimateGasAtBlock estimates msg against the state of a block; nil means latest
+func (c *Client) EstimateGasAtBlock(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (uint64, error) {
+	return c.extended.EstimateGasAtBlock(ctx, msg, blockNumber)
+}
+
+// RPC returns the raw JSON-RPC client, e.g. for batched requests
+func (c *Client) RPC() *rpc.Client {
+	return c.rpc
+}
+
+// TransactionSender returns the sender of the transaction at index in block
+func (c *Client) TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error) {
+	return c.extended.TransactionSender(ctx, tx, block, index)
+}
@@ -0,0 +1,258 @@
+package simulate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/txpipe"
+	"github.com/fuckEthereum/src/units"
+)
+
+// Backend is the read-only node access needed to simulate a transaction
+type Backend interface {
+	PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error)
+	EstimateGasAtBlock(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error)
+}
+
+// Tracer issues raw JSON-RPC calls; *rpc.Client implements it
+type Tracer interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// Report is the predicted outcome of a transaction that was never signed
+type Report struct {
+	Success       bool
+	RevertReason  string
+	ReturnData    []byte
+	GasEstimate   uint64   // gas the node expects the tx to use
+	GasLimit      uint64   // estimate with the safety multiplier applied
+	FeeLow        *big.Int // estimate * (base fee + tip)
+	FeeHigh       *big.Int // limit * (2 * base fee + tip), the worst case
+	BalanceBefore *big.Int
+	BalanceAfter  [2]*big.Int // best and worst case balance after the tx
+	Events        []txpipe.Event
+	Traced        bool   // true if events came from debug_traceCall
+	TraceError    string // why tracing was unavailable
+}
+
+// Simulator predicts transactions against pending state without signing anything
+type Simulator struct {
+	backend    Backend
+	tracer     Tracer
+	multiplier float64
+}
+
+// New creates a simulator; tracer may be nil when debug_traceCall is unavailable
+func New(backend Backend, tracer Tracer, multiplier float64) *Simulator {
+	if multiplier < 1 {
+		multiplier = gas.DefaultMultiplier
+	}
+	return &Simulator{
+		backend:    backend,
+		tracer:     tracer,
+		multiplier: multiplier,
+	}
+}
+
+// Simulate runs eth_call and eth_estimateGas at pending state. contractABI is
+// used to decode emitted events and may be nil.
+func (s *Simulator) Simulate(ctx context.Context, msg ethereum.CallMsg, contractABI *abi.ABI) (*Report, error) {
+	report := &Report{}
+	pending := big.NewInt(int64(rpc.PendingBlockNumber))
+
+	balance, err := s.backend.PendingBalanceAt(ctx, msg.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %v", err)
+	}
+	report.BalanceBefore = balance
+
+	// eth_call tells us success or the revert reason and the return data
+	output, err := s.backend.PendingCallContract(ctx, msg)
+	if err != nil {
+		revert := gas.AsRevert(err)
+		if revert == nil {
+			return nil, fmt.Errorf("eth_call failed: %v", err)
+		}
+		report.RevertReason = revert.Reason
+		if report.RevertReason == "" {
+			report.RevertReason = revert.Err.Error()
+		}
+		return report, nil
+	}
+	report.Success = true
+	report.ReturnData = output
+
+	estimate, err := s.backend.EstimateGasAtBlock(ctx, msg, pending)
+	if err != nil {
+		if revert := gas.AsRevert(err); revert != nil {
+			report.Success = false
+			report.RevertReason = revert.Error()
+			return report, nil
+		}
+		return nil, fmt.Errorf("eth_estimateGas failed: %v", err)
+	}
+	report.GasEstimate = estimate
+	report.GasLimit = uint64(float64(estimate) * s.multiplier)
+
+	if err := s.fees(ctx, report); err != nil {
+		return nil, err
+	}
+
+	value := msg.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	spentLow := new(big.Int).Add(value, report.FeeLow)
+	spentHigh := new(big.Int).Add(value, report.FeeHigh)
+	report.BalanceAfter = [2]*big.Int{
+		new(big.Int).Sub(balance, spentLow),
+		new(big.Int).Sub(balance, spentHigh),
+	}
+
+	if s.tracer != nil {
+		logs, err := s.trace(ctx, msg)
+		if err != nil {
+			report.TraceError = err.Error()
+		} else {
+			report.Traced = true
+			if contractABI != nil {
+				report.Events = txpipe.DecodeEvents(contractABI, logs)
+			}
+		}
+	} else {
+		report.TraceError = "no tracer configured"
+	}
+	return report, nil
+}
+
+// fees computes the fee range from the pending base fee and suggested tip
+func (s *Simulator) fees(ctx context.Context, report *Report) error {
+	header, err := s.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest header: %v", err)
+	}
+
+	estimate := new(big.Int).SetUint64(report.GasEstimate)
+	limit := new(big.Int).SetUint64(report.GasLimit)
+
+	if header.BaseFee == nil {
+		gasPrice, err := s.backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get gas price: %v", err)
+		}
+		report.FeeLow = new(big.Int).Mul(estimate, gasPrice)
+		report.FeeHigh = new(big.Int).Mul(limit, gasPrice)
+		return nil
+	}
+
+	tip, err := s.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get gas tip: %v", err)
+	}
+	low := new(big.Int).Add(header.BaseFee, tip)
+	high := new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip)
+	report.FeeLow = new(big.Int).Mul(estimate, low)
+	report.FeeHigh = new(big.Int).Mul(limit, high)
+	return nil
+}
+
+// callFrame is the subset of callTracer output we need
+type callFrame struct {
+	Logs []struct {
+		Address common.Address `json:"address"`
+		Topics  []common.Hash  `json:"topics"`
+		Data    hexutil.Bytes  `json:"data"`
+	} `json:"logs"`
+	Calls []callFrame `json:"calls"`
+}
+
+// trace runs debug_traceCall with the callTracer and collects emitted logs in order
+func (s *Simulator) trace(ctx context.Context, msg ethereum.CallMsg) ([]*types.Log, error) {
+	config := map[string]interface{}{
+		"tracer":       "callTracer",
+		"tracerConfig": map[string]interface{}{"withLog": true},
+	}
+
+	var frame callFrame
+	if err := s.tracer.CallContext(ctx, &frame, "debug_traceCall", toCallArg(msg), "pending", config); err != nil {
+		return nil, fmt.Errorf("debug_traceCall unavailable: %v", err)
+	}
+
+	var logs []*types.Log
+	var walk func(f *callFrame)
+	walk = func(f *callFrame) {
+		for _, l := range f.Logs {
+			logs = append(logs, &types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data})
+		}
+		for i := range f.Calls {
+			walk(&f.Calls[i])
+		}
+	}
+	walk(&frame)
+	return logs, nil
+}
+
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["input"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	return arg
+}
+
+// PrintReport prints a simulation report in the CLI's style
+func PrintReport(label string, report *Report) {
+	fmt.Printf("🧪 Dry run: %s (nothing signed or sent)\n", label)
+	if !report.Success {
+		fmt.Printf("   ❌ Would revert: %s\n", report.RevertReason)
+		return
+	}
+
+	fmt.Printf("   ✅ Would succeed\n")
+	if len(report.ReturnData) > 0 {
+		fmt.Printf("   ↩️  Return data: %s\n", hexutil.Encode(report.ReturnData))
+	}
+	fmt.Printf("   ⛽ Gas: %d (limit %d)\n", report.GasEstimate, report.GasLimit)
+	fmt.Printf("   💸 Fee: %s – %s\n", units.FormatWei(report.FeeLow, units.Ether, 8), units.FormatWei(report.FeeHigh, units.Ether, 8))
+	fmt.Printf("   💰 Balance: %s → %s – %s\n",
+		units.FormatWei(report.BalanceBefore, units.Ether, 8),
+		units.FormatWei(report.BalanceAfter[1], units.Ether, 8),
+		units.FormatWei(report.BalanceAfter[0], units.Ether, 8))
+
+	if !report.Traced {
+		fmt.Printf("   ℹ️  Events unavailable: %s\n", report.TraceError)
+		return
+	}
+	if len(report.Events) == 0 {
+		fmt.Println("   📣 No decodable events")
+	}
+	for _, event := range report.Events {
+		fields, _ := json.Marshal(event.Fields)
+		fmt.Printf("   📣 %s %s\n", event.Name, fields)
+	}
+}
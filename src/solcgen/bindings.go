@@ -0,0 +1,174 @@
+package solcgen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/abigen"
+)
+
+// Default locations, relative to the repository root
+const (
+	ContractsDir = "contracts"
+	ArtifactsDir = "build"
+	Package      = "contracts"
+)
+
+// LayoutSuffix names the storage layout written next to <Name>.abi
+const LayoutSuffix = ".storage-layout.json"
+
+// Contract is one compiled contract. Bin and DeployedBin are hex without 0x;
+// DeployedBin and StorageLayout are empty when loaded from artifacts that do not carry them.
+type Contract struct {
+	Name          string
+	Source        string
+	ABI           string
+	Bin           string
+	DeployedBin   string
+	StorageLayout string // solc storageLayout JSON, used to check proxy upgrades
+}
+
+// BindingFile returns the path of the contract's Go binding, e.g. contracts/counter.go
+func (c Contract) BindingFile(dir string) string {
+	return filepath.Join(dir, strings.ToLower(c.Name)+".go")
+}
+
+func sortContracts(contracts []Contract) {
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].Name < contracts[j].Name })
+}
+
+// Sources lists the Solidity files in dir
+func Sources(dir string) ([]string, error) {
+	sources, err := filepath.Glob(filepath.Join(dir, "*.sol"))
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no .sol files in %s", dir)
+	}
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// LoadArtifacts reads pre-built <Name>.abi / <Name>.bin pairs (the layout of `solc --abi --bin -o build`),
+// plus <Name>.bin-runtime and <Name>.storage-layout.json when present
+func LoadArtifacts(dir string) ([]Contract, error) {
+	abis, err := filepath.Glob(filepath.Join(dir, "*.abi"))
+	if err != nil {
+		return nil, err
+	}
+	if len(abis) == 0 {
+		return nil, fmt.Errorf("no .abi files in %s", dir)
+	}
+
+	var contracts []Contract
+	for _, abiPath := range abis {
+		base := strings.TrimSuffix(abiPath, ".abi")
+		abiJSON, err := os.ReadFile(abiPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", abiPath, err)
+		}
+		bin, err := os.ReadFile(base + ".bin")
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s.bin: %v", base, err)
+		}
+		runtimeBin, err := os.ReadFile(base + ".bin-runtime")
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s.bin-runtime: %v", base, err)
+		}
+		layout, err := os.ReadFile(base + LayoutSuffix)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s%s: %v", base, LayoutSuffix, err)
+		}
+		contracts = append(contracts, Contract{
+			Name:          filepath.Base(base),
+			Source:        abiPath,
+			ABI:           strings.TrimSpace(string(abiJSON)),
+			Bin:           strings.TrimPrefix(strings.TrimSpace(string(bin)), "0x"),
+			DeployedBin:   strings.TrimPrefix(strings.TrimSpace(string(runtimeBin)), "0x"),
+			StorageLayout: strings.TrimSpace(string(layout)),
+		})
+	}
+	sortContracts(contracts)
+	return contracts, nil
+}
+
+// WriteArtifacts stores contracts in the LoadArtifacts layout
+func WriteArtifacts(dir string, contracts []Contract) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	for _, c := range contracts {
+		files := map[string]string{".abi": c.ABI, ".bin": c.Bin, ".bin-runtime": c.DeployedBin}
+		if c.StorageLayout != "" {
+			files[LayoutSuffix] = c.StorageLayout
+		}
+		for ext, content := range files {
+			path := filepath.Join(dir, c.Name+ext)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Binding is the generated Go source for one contract
+type Binding struct {
+	Contract Contract
+	Path     string
+	Code     []byte
+}
+
+// Generate runs abigen on every contract, one file per contract in dir
+func Generate(dir string, contracts []Contract) ([]Binding, error) {
+	var bindings []Binding
+	for _, c := range contracts {
+		code, err := abigen.Bind([]string{c.Name}, []string{c.ABI}, []string{c.Bin}, nil, Package, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate binding for %s: %v", c.Name, err)
+		}
+		bindings = append(bindings, Binding{Contract: c, Path: c.BindingFile(dir), Code: []byte(code)})
+	}
+	return bindings, nil
+}
+
+// Write stores the bindings and reports which files changed
+func Write(bindings []Binding) ([]string, error) {
+	var changed []string
+	for _, b := range bindings {
+		current, err := os.ReadFile(b.Path)
+		if err == nil && bytes.Equal(current, b.Code) {
+			continue
+		}
+		if err := os.WriteFile(b.Path, b.Code, 0644); err != nil {
+			return changed, fmt.Errorf("failed to write %s: %v", b.Path, err)
+		}
+		changed = append(changed, b.Path)
+	}
+	return changed, nil
+}
+
+// Stale compares the bindings with the checked-in files and lists the ones that are
+// missing or out of date
+func Stale(bindings []Binding) ([]string, error) {
+	var stale []string
+	for _, b := range bindings {
+		current, err := os.ReadFile(b.Path)
+		if os.IsNotExist(err) {
+			stale = append(stale, b.Path+" (missing)")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", b.Path, err)
+		}
+		if !bytes.Equal(current, b.Code) {
+			stale = append(stale, b.Path)
+		}
+	}
+	return stale, nil
+}
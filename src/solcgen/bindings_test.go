@@ -0,0 +1,97 @@
+package solcgen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fuckEthereum/contracts"
+)
+
+// counter is the Counter contract as compiled into the checked-in binding
+func counter() Contract {
+	return Contract{
+		Name:   "Counter",
+		Source: filepath.Join(ContractsDir, "Counter.sol"),
+		ABI:    contracts.CounterMetaData.ABI,
+		Bin:    strings.TrimPrefix(contracts.CounterMetaData.Bin, "0x"),
+	}
+}
+
+func TestCheckedInBindingIsUpToDate(t *testing.T) {
+	bindings, err := Generate(filepath.Join("..", "..", ContractsDir), []Contract{counter()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale, err := Stale(bindings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 0 {
+		t.Errorf("regenerating from CounterMetaData changes %v", stale)
+	}
+}
+
+func TestStale(t *testing.T) {
+	dir := t.TempDir()
+	bindings, err := Generate(dir, []Contract{counter()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "counter.go")
+	if bindings[0].Path != path {
+		t.Fatalf("binding path %s, want %s", bindings[0].Path, path)
+	}
+
+	stale, err := Stale(bindings)
+	if err != nil || !reflect.DeepEqual(stale, []string{path + " (missing)"}) {
+		t.Fatalf("before writing: got %v, %v", stale, err)
+	}
+	changed, err := Write(bindings)
+	if err != nil || !reflect.DeepEqual(changed, []string{path}) {
+		t.Fatalf("first write: got %v, %v", changed, err)
+	}
+	if stale, err := Stale(bindings); err != nil || len(stale) != 0 {
+		t.Fatalf("after writing: got %v, %v", stale, err)
+	}
+	if changed, err := Write(bindings); err != nil || len(changed) != 0 {
+		t.Fatalf("second write: got %v, %v", changed, err)
+	}
+
+	// A hand edit makes the file stale until it is regenerated
+	edited := append([]byte("// edited\n"), bindings[0].Code...)
+	if err := os.WriteFile(path, edited, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if stale, err := Stale(bindings); err != nil || !reflect.DeepEqual(stale, []string{path}) {
+		t.Fatalf("after editing: got %v, %v", stale, err)
+	}
+	if changed, err := Write(bindings); err != nil || !reflect.DeepEqual(changed, []string{path}) {
+		t.Fatalf("rewrite: got %v, %v", changed, err)
+	}
+}
+
+func TestArtifactsRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ArtifactsDir)
+	withLayout := counter()
+	withLayout.DeployedBin = "6080"
+	withLayout.StorageLayout = `{"storage":[]}`
+	plain := Contract{Name: "Box", ABI: "[]", Bin: "00"}
+
+	if err := WriteArtifacts(dir, []Contract{withLayout, plain}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadArtifacts(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Loaded contracts name their .abi file as the source
+	plain.Source = filepath.Join(dir, "Box.abi")
+	withLayout.Source = filepath.Join(dir, "Counter.abi")
+	want := []Contract{plain, withLayout}
+	if !reflect.DeepEqual(loaded, want) {
+		t.Errorf("loaded %+v\nwant %+v", loaded, want)
+	}
+}
@@ -0,0 +1,270 @@
+package solcgen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DefaultVersion is the pinned compiler release; contracts/counter.go was built with it
+const DefaultVersion = "0.8.30"
+
+// binariesURL hosts the official static solc builds
+const binariesURL = "https://binaries.soliditylang.org"
+
+// VersionFromEnv returns SOLC_VERSION, falling back to DefaultVersion
+func VersionFromEnv() string {
+	if v := os.Getenv("SOLC_VERSION"); v != "" {
+		return strings.TrimPrefix(v, "v")
+	}
+	return DefaultVersion
+}
+
+// CacheDir returns SOLC_CACHE, falling back to <user cache dir>/solc
+func CacheDir() (string, error) {
+	if dir := os.Getenv("SOLC_CACHE"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory (set SOLC_CACHE): %v", err)
+	}
+	return filepath.Join(dir, "solc"), nil
+}
+
+// Solc is a solc binary of a known version
+type Solc struct {
+	Path    string
+	Version string
+}
+
+// Find returns the cached solc for version, or SOLC_PATH when set.
+// The binary must report exactly the pinned version.
+func Find(version string) (*Solc, error) {
+	path := os.Getenv("SOLC_PATH")
+	if path == "" {
+		dir, err := CacheDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "solc-v"+version)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, fmt.Errorf("solc %s is not in the cache (%s); run with --install or set SOLC_PATH", version, path)
+		}
+	}
+
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %v", path, err)
+	}
+	if !strings.Contains(string(out), "Version: "+version+"+") {
+		return nil, fmt.Errorf("%s is not solc %s: %s", path, version, strings.TrimSpace(string(out)))
+	}
+	return &Solc{Path: path, Version: version}, nil
+}
+
+// platform returns the binaries.soliditylang.org directory for this machine
+func platform() (string, error) {
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64":
+		return "linux-amd64", nil
+	case "darwin/amd64", "darwin/arm64":
+		// The macOS builds are universal binaries
+		return "macosx-amd64", nil
+	case "windows/amd64":
+		return "windows-amd64", nil
+	}
+	return "", fmt.Errorf("no static solc build for %s/%s; set SOLC_PATH", runtime.GOOS, runtime.GOARCH)
+}
+
+// buildList is the list.json published next to the binaries
+type buildList struct {
+	Builds []struct {
+		Path    string `json:"path"`
+		Version string `json:"version"`
+		SHA256  string `json:"sha256"`
+	} `json:"builds"`
+	Releases map[string]string `json:"releases"`
+}
+
+// Install downloads the official solc release for version into the cache,
+// checking it against the sha256 published in the release list
+func Install(version string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, "solc-v"+version)
+	if _, err := os.Stat(target); err == nil {
+		return target, nil
+	}
+
+	plat, err := platform()
+	if err != nil {
+		return "", err
+	}
+	var list buildList
+	if err := getJSON(binariesURL+"/"+plat+"/list.json", &list); err != nil {
+		return "", fmt.Errorf("failed to fetch solc release list: %v", err)
+	}
+	file, ok := list.Releases[version]
+	if !ok {
+		return "", fmt.Errorf("solc %s is not a published release for %s", version, plat)
+	}
+	var checksum string
+	for _, build := range list.Builds {
+		if build.Path == file {
+			checksum = strings.TrimPrefix(build.SHA256, "0x")
+		}
+	}
+	if checksum == "" {
+		return "", fmt.Errorf("no checksum published for %s", file)
+	}
+
+	resp, err := http.Get(binariesURL + "/" + plat + "/" + file)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", file, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", file, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", file, err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != checksum {
+		return "", fmt.Errorf("checksum mismatch for %s", file)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0755); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return "", fmt.Errorf("failed to install solc: %v", err)
+	}
+	return target, nil
+}
+
+func getJSON(url string, v interface{}) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// standardInput is solc's --standard-json input
+type standardInput struct {
+	Language string                    `json:"language"`
+	Sources  map[string]standardSource `json:"sources"`
+	Settings struct {
+		Optimizer struct {
+			Enabled bool `json:"enabled"`
+			Runs    int  `json:"runs"`
+		} `json:"optimizer"`
+		OutputSelection map[string]map[string][]string `json:"outputSelection"`
+	} `json:"settings"`
+}
+
+type standardSource struct {
+	URLs []string `json:"urls"`
+}
+
+// standardOutput is the part of solc's --standard-json output we use
+type standardOutput struct {
+	Errors []struct {
+		Severity         string `json:"severity"`
+		FormattedMessage string `json:"formattedMessage"`
+	} `json:"errors"`
+	Contracts map[string]map[string]struct {
+		ABI           json.RawMessage `json:"abi"`
+		StorageLayout json.RawMessage `json:"storageLayout"`
+		EVM           struct {
+			Bytecode struct {
+				Object string `json:"object"`
+			} `json:"bytecode"`
+			DeployedBytecode struct {
+				Object string `json:"object"`
+			} `json:"deployedBytecode"`
+		} `json:"evm"`
+	} `json:"contracts"`
+}
+
+// Compile compiles the sources (paths relative to root) with the same settings as
+// `solc --abi --bin`: optimizer off, default EVM version
+func (s *Solc) Compile(root string, sources []string) ([]Contract, error) {
+	var input standardInput
+	input.Language = "Solidity"
+	input.Sources = make(map[string]standardSource)
+	for _, source := range sources {
+		name := filepath.ToSlash(source)
+		input.Sources[name] = standardSource{URLs: []string{name}}
+	}
+	input.Settings.Optimizer.Runs = 200
+	input.Settings.OutputSelection = map[string]map[string][]string{
+		"*": {"*": {"abi", "evm.bytecode.object", "evm.deployedBytecode.object", "storageLayout"}},
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(s.Path, "--standard-json", "--base-path", ".", "--allow-paths", ".")
+	cmd.Dir = root
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("solc failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var output standardOutput
+	if err := json.Unmarshal(out, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse solc output: %v", err)
+	}
+	var problems []string
+	for _, e := range output.Errors {
+		if e.Severity == "error" {
+			problems = append(problems, strings.TrimSpace(e.FormattedMessage))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("compilation failed:\n%s", strings.Join(problems, "\n"))
+	}
+
+	var contracts []Contract
+	for _, source := range sources {
+		for name, c := range output.Contracts[filepath.ToSlash(source)] {
+			contracts = append(contracts, Contract{
+				Name:          name,
+				Source:        source,
+				ABI:           string(c.ABI),
+				Bin:           c.EVM.Bytecode.Object,
+				DeployedBin:   c.EVM.DeployedBytecode.Object,
+				StorageLayout: string(c.StorageLayout),
+			})
+		}
+	}
+	sortContracts(contracts)
+	return contracts, nil
+}
@@ -12,12 +12,19 @@ import (
 
 	"syscall"
 
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fuckEthereum/src/ens"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/policy"
+	"github.com/fuckEthereum/src/txhistory"
+	"github.com/fuckEthereum/src/units"
 	"golang.org/x/term"
 )
 
@@ -25,13 +32,24 @@ import (
 type SecureKeystoreWallet struct {
 	keystorePath string
 	account      *accounts.Account
+	password     PasswordFunc
 	// 注意：不存储密码，每次使用时临时输入
 }
 
-// NewSecureKeystoreWallet creates a new secure keystore wallet
+// PasswordFunc supplies the keystore password each time it is needed
+type PasswordFunc func(prompt string) (string, error)
+
+// NewSecureKeystoreWallet creates a new secure keystore wallet that prompts for its password
 func NewSecureKeystoreWallet(keystorePath string) *SecureKeystoreWallet {
+	return NewSecureKeystoreWalletWithPassword(keystorePath, promptPassword)
+}
+
+// NewSecureKeystoreWalletWithPassword creates a secure keystore wallet that asks password
+// for the password when signing instead of prompting on the terminal
+func NewSecureKeystoreWalletWithPassword(keystorePath string, password PasswordFunc) *SecureKeystoreWallet {
 	return &SecureKeystoreWallet{
 		keystorePath: keystorePath,
+		password:     password,
 	}
 }
 
@@ -54,13 +72,13 @@ func (kw *SecureKeystoreWallet) CreateAccount() error {
 	}
 
 	// Prompt for password
-	password, err := promptPassword("Enter password for new keystore: ")
+	password, err := kw.password("Enter password for new keystore: ")
 	if err != nil {
 		return err
 	}
 
 	// Confirm password
-	confirmPassword, err := promptPassword("Confirm password: ")
+	confirmPassword, err := kw.password("Confirm password: ")
 	if err != nil {
 		return err
 	}
@@ -149,8 +167,8 @@ func (kw *SecureKeystoreWallet) SignTransaction(tx *types.Transaction, chainID *
 		return nil, fmt.Errorf("no account loaded")
 	}
 
-	// Prompt for password each time (never store it)
-	password, err := promptPassword("Enter password to sign transaction: ")
+	// Ask for the password each time (never store it)
+	password, err := kw.password("Enter password to sign transaction: ")
 	if err != nil {
 		return nil, err
 	}
@@ -175,37 +193,57 @@ func (kw *SecureKeystoreWallet) SignTransaction(tx *types.Transaction, chainID *
 	return signedTx, nil
 }
 
-// TransferETHWithSecureKeystore performs ETH transfer using secure keystore
+// TransferETHWithSecureKeystore performs ETH transfer using secure keystore.
+// The node's eth_chainId must match the profile's chain ID before anything is signed.
 func TransferETHWithSecureKeystore(
 	keystorePath string,
 	keystoreFile string,
 	toAddress string,
-	amount *big.Int,
-	rpcURL string,
+	amount units.Amount,
+	profile *network.Profile,
 ) error {
-	fmt.Println("🔐 开始创建安全 Keystore 钱包...")
+	fmt.Println("🌐 开始连接以太坊网络...")
+	// Connect to Ethereum client
+	client, err := ethclient.Dial(profile.RPCURL)
+	if err != nil {
+		fmt.Printf("❌ 连接以太坊网络失败: %v\n", err)
+		return fmt.Errorf("failed to connect to Ethereum client: %v", err)
+	}
+	defer client.Close()
+	fmt.Printf("✅ 成功连接到以太坊网络: %s\n", profile.RPCURL)
 
-	// Create secure keystore wallet
 	wallet := NewSecureKeystoreWallet(keystorePath)
-	fmt.Printf("✅ 钱包实例创建成功，路径: %s\n", keystorePath)
+	return TransferETHWithSecureKeystoreOn(client, wallet, keystoreFile, toAddress, amount, profile)
+}
+
+// TransferETHWithSecureKeystoreOn performs ETH transfer from a keystore file in the wallet's
+// directory on a backend. The wallet supplies the password when the transaction is signed.
+func TransferETHWithSecureKeystoreOn(
+	client TransferBackend,
+	wallet *SecureKeystoreWallet,
+	keystoreFile string,
+	toAddress string,
+	amount units.Amount,
+	profile *network.Profile,
+) error {
+	fmt.Printf("🔐 Keystore 钱包路径: %s\n", wallet.keystorePath)
 
 	fmt.Println("📥 开始导入 Keystore 文件...")
 	// Import keystore
-	if err := wallet.ImportKeystore(keystorePath + "/" + keystoreFile); err != nil {
+	if err := wallet.ImportKeystore(wallet.keystorePath + "/" + keystoreFile); err != nil {
 		fmt.Printf("❌ 导入 Keystore 失败: %v\n", err)
 		return fmt.Errorf("failed to import keystore: %v", err)
 	}
 	fmt.Printf("✅ Keystore 文件导入成功: %s\n", keystoreFile)
 
-	fmt.Println("🌐 开始连接以太坊网络...")
-	// Connect to Ethereum client
-	client, err := ethclient.Dial(rpcURL)
+	fmt.Println("🔗 获取并校验链 ID...")
+	// Use eth_chainId (not net_version) for EIP-155 and cross-check the profile
+	chainID, err := network.VerifyChainID(context.Background(), client, profile)
 	if err != nil {
-		fmt.Printf("❌ 连接以太坊网络失败: %v\n", err)
-		return fmt.Errorf("failed to connect to Ethereum client: %v", err)
+		fmt.Printf("❌ 链 ID 校验失败: %v\n", err)
+		return err
 	}
-	defer client.Close()
-	fmt.Printf("✅ 成功连接到以太坊网络: %s\n", rpcURL)
+	fmt.Printf("✅ 链 ID: %s (%s)\n", chainID.String(), profile.Name)
 
 	fmt.Println("📍 获取发送方地址...")
 	// Get address from keystore
@@ -218,9 +256,17 @@ func TransferETHWithSecureKeystore(
 	fmt.Printf("✅ 发送方地址: %s\n", fromAddress.Hex())
 
 	fmt.Println("📍 解析接收方地址...")
-	// Parse recipient address
-	toAddr := common.HexToAddress(toAddress)
-	fmt.Printf("✅ 接收方地址: %s\n", toAddr.Hex())
+	// Parse recipient address (hex or ENS name)
+	toAddr, err := ResolveAddress(client, toAddress)
+	if err != nil {
+		fmt.Printf("❌ 解析接收方地址失败: %v\n", err)
+		return fmt.Errorf("failed to resolve recipient: %v", err)
+	}
+	if ens.IsName(toAddress) {
+		fmt.Printf("✅ 接收方地址: %s → %s\n", toAddress, toAddr.Hex())
+	} else {
+		fmt.Printf("✅ 接收方地址: %s\n", toAddr.Hex())
+	}
 
 	fmt.Println("🔢 获取账户 Nonce...")
 	// Get nonce
@@ -238,8 +284,7 @@ func TransferETHWithSecureKeystore(
 		fmt.Printf("❌ 获取 Gas 价格失败: %v\n", err)
 		return fmt.Errorf("failed to get gas price: %v", err)
 	}
-	gasPriceGwei := new(big.Int).Div(gasPrice, big.NewInt(1e9))
-	fmt.Printf("✅ Gas 价格: %s wei (%s Gwei)\n", gasPrice.String(), gasPriceGwei.String())
+	fmt.Printf("✅ Gas 价格: %s (%s)\n", units.FormatWei(gasPrice, units.Wei, 0), units.FormatWei(gasPrice, units.Gwei, -1))
 
 	// 检查发送方余额
 	fmt.Println("💰 检查发送方余额...")
@@ -248,14 +293,32 @@ func TransferETHWithSecureKeystore(
 		fmt.Printf("❌ 获取余额失败: %v\n", err)
 		return fmt.Errorf("failed to get balance: %v", err)
 	}
-	balanceEth := new(big.Float).Quo(new(big.Float).SetInt(balance), big.NewFloat(1e18))
-	fmt.Printf("✅ 当前余额: %s ETH (%s wei)\n", balanceEth.Text('f', 18), balance.String())
+	fmt.Printf("✅ 当前余额: %s (%s)\n", units.FormatWei(balance, units.Ether, -1), units.FormatWei(balance, units.Wei, 0))
+
+	fmt.Println("⛽ 估算 Gas 限制...")
+	// Estimate gas for the exact transfer (the recipient may be a contract)
+	estimator := gas.NewEstimator(client, gas.MultiplierFromEnv())
+	estimate, err := estimator.Estimate(context.Background(), ethereum.CallMsg{
+		From:  fromAddress,
+		To:    &toAddr,
+		Value: amount.Wei(),
+	}, gas.DefaultTransferGas)
+	if err != nil {
+		fmt.Printf("❌ Gas 估算失败，交易将会失败: %v\n", err)
+		return fmt.Errorf("gas estimation failed: %v", err)
+	}
+	gasLimit := estimate.Limit
+	if estimate.Fallback {
+		fmt.Printf("⚠️  无法估算 Gas (%v)，使用默认值 %d\n", estimate.FallbackReason, gasLimit)
+	} else {
+		fmt.Printf("✅ Gas 估算: %d (×%.2f → %d)\n", estimate.Estimated, estimator.Multiplier(), gasLimit)
+	}
 
 	// 检查余额是否足够
-	gasCost := new(big.Int).Mul(gasPrice, big.NewInt(21000))
-	totalCost := new(big.Int).Add(amount, gasCost)
+	gasCost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit))
+	totalCost := new(big.Int).Add(amount.Wei(), gasCost)
 	if balance.Cmp(totalCost) < 0 {
-		fmt.Printf("❌ 余额不足！需要 %s wei，当前余额 %s wei\n", totalCost.String(), balance.String())
+		fmt.Printf("❌ 余额不足！需要 %s，当前余额 %s\n", units.FormatWei(totalCost, units.Ether, -1), units.FormatWei(balance, units.Ether, -1))
 		return fmt.Errorf("insufficient balance: need %s wei, have %s wei", totalCost.String(), balance.String())
 	}
 	fmt.Printf("✅ 余额充足，可以执行转账\n")
@@ -265,31 +328,48 @@ func TransferETHWithSecureKeystore(
 	tx := types.NewTransaction(
 		nonce,
 		toAddr,
-		amount,
-		21000, // Standard gas limit for ETH transfer
+		amount.Wei(),
+		gasLimit,
 		gasPrice,
 		nil, // No data for simple ETH transfer
 	)
 	fmt.Printf("✅ 交易创建成功\n")
 	fmt.Printf("   Nonce: %d\n", nonce)
 	fmt.Printf("   接收方: %s\n", toAddr.Hex())
-	fmt.Printf("   金额: %s wei\n", amount.String())
-	fmt.Printf("   Gas 限制: 21000\n")
-	fmt.Printf("   Gas 价格: %s wei\n", gasPrice.String())
+	fmt.Printf("   金额: %s (%s)\n", amount.Format(units.Ether, -1), amount.Format(units.Wei, 0))
+	fmt.Printf("   Gas 限制: %d\n", gasLimit)
+	fmt.Printf("   Gas 价格: %s\n", units.FormatWei(gasPrice, units.Gwei, -1))
 
-	fmt.Println("🔗 获取链 ID...")
-	// Get chain ID
-	chainID, err := client.NetworkID(context.Background())
+	fmt.Println("🛡️  检查交易策略...")
+	// Evaluate the transaction policy before anything is signed
+	rules, err := policy.LoadFromEnv()
 	if err != nil {
-		fmt.Printf("❌ 获取链 ID 失败: %v\n", err)
-		return fmt.Errorf("failed to get chain ID: %v", err)
+		fmt.Printf("❌ 加载交易策略失败: %v\n", err)
+		return err
+	}
+	engine := policy.NewEngine(rules, policy.StdinConfirmer)
+	policyTx := &policy.Tx{
+		ChainID:     chainID,
+		NetworkName: network.DisplayName(chainID),
+		Mainnet:     network.HoldsValue(profile, chainID),
+		Label:       "transfer",
+		From:        fromAddress,
+		To:          &toAddr,
+		Value:       amount.Wei(),
+		GasLimit:    gasLimit,
+		FeePerGas:   gasPrice,
+	}
+	if err := engine.Authorize(policyTx); err != nil {
+		fmt.Printf("❌ 交易被策略拒绝: %v\n", err)
+		return err
 	}
-	fmt.Printf("✅ 链 ID: %s\n", chainID.String())
+	fmt.Printf("✅ 交易策略检查通过\n")
 
 	fmt.Println("🔐 开始签名交易...")
 	// Sign transaction with secure keystore (password prompted here)
 	signedTx, err := wallet.SignTransaction(tx, chainID)
 	if err != nil {
+		releaseAllowance(engine, policyTx, err)
 		fmt.Printf("❌ 交易签名失败: %v\n", err)
 		return fmt.Errorf("failed to sign transaction: %v", err)
 	}
@@ -299,23 +379,37 @@ func TransferETHWithSecureKeystore(
 	// Send transaction
 	err = client.SendTransaction(context.Background(), signedTx)
 	if err != nil {
+		releaseAllowance(engine, policyTx, err)
 		fmt.Printf("❌ 发送交易失败: %v\n", err)
 		return fmt.Errorf("failed to send transaction: %v", err)
 	}
 
 	txHash := signedTx.Hash().Hex()
 	fmt.Printf("🎉 交易发送成功！\n")
+
+	// Record the transaction in the local history so it can be tracked later
+	if err := txhistory.RecordSent(txhistory.PathFromEnv(), signedTx, "transfer"); err != nil {
+		fmt.Printf("⚠️  记录交易历史失败: %v\n", err)
+	}
 	fmt.Printf("📋 交易哈希: %s\n", txHash)
-	fmt.Printf("🔗 Sepolia Etherscan: https://sepolia.etherscan.io/tx/%s\n", txHash)
+	if url := profile.TxURL(txHash); url != "" {
+		fmt.Printf("🔗 区块浏览器: %s\n", url)
+	}
 
 	// 计算预估 Gas 费用
-	estimatedGasCost := new(big.Int).Mul(gasPrice, big.NewInt(21000))
-	estimatedGasCostGwei := new(big.Int).Div(estimatedGasCost, big.NewInt(1e9))
-	fmt.Printf("⛽ 预估 Gas 费用: %s wei (%s Gwei)\n", estimatedGasCost.String(), estimatedGasCostGwei.String())
+	estimatedGasCost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit))
+	fmt.Printf("⛽ 预估 Gas 费用: %s (%s)\n", units.FormatWei(estimatedGasCost, units.Ether, -1), units.FormatWei(estimatedGasCost, units.Gwei, -1))
 
 	return nil
 }
 
+// releaseAllowance stops an approved transfer that was never sent from counting toward the daily limit
+func releaseAllowance(engine *policy.Engine, tx *policy.Tx, cause error) {
+	if err := engine.Release(tx, cause); err != nil {
+		fmt.Printf("⚠️  释放策略额度失败: %v\n", err)
+	}
+}
+
 // CreateSecureKeystoreFile creates a new secure keystore file
 func CreateSecureKeystoreFile(keystorePath string) (string, error) {
 	// Create keystore directory if it doesn't exist
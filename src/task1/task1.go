@@ -3,49 +3,97 @@ package task1
 import (
 	"context"
 	"fmt"
-	"math/big"
 
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fuckEthereum/src/blockref"
+	"github.com/fuckEthereum/src/ens"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/simulate"
+	"github.com/fuckEthereum/src/units"
 )
 
-func QueryBlock(client *ethclient.Client, blockNumber *uint64) (*types.Block, error) {
-	var blockNum *big.Int
+// Backend is what the task1 helpers need from a node. *ethclient.Client implements it, and so
+// does simchain.Client, which runs them against an in-process simulated chain.
+type Backend interface {
+	ethereum.ChainReader
+	ethereum.ChainIDReader
+	ethereum.TransactionReader
+	ethereum.GasPricer
+	ethereum.GasEstimator
+	bind.ContractCaller
+	blockref.BalanceReader
+}
+
+// TransferBackend is what a keystore transfer and its dry run need from a node on top of Backend
+type TransferBackend interface {
+	Backend
+	simulate.Backend
+	ethereum.PendingStateReader
+	ethereum.TransactionSender
+}
+
+// QueryBlock fetches a block by number; nil means the latest block
+func QueryBlock(client ethereum.ChainReader, blockNumber *uint64) (*types.Block, error) {
 	if blockNumber == nil {
-		blockNum = nil
-	} else {
-		blockNum = big.NewInt(int64(*blockNumber))
+		return QueryBlockAt(client, blockref.Latest)
 	}
-	block, err := client.BlockByNumber(context.Background(), blockNum)
-	if err != nil {
-		return nil, err
+	return QueryBlockAt(client, blockref.Number(*blockNumber))
+}
+
+// QueryBlockAt fetches the block selected by number, hash or tag
+func QueryBlockAt(client ethereum.ChainReader, at blockref.Ref) (*types.Block, error) {
+	if hash, ok := at.BlockHash(); ok {
+		return client.BlockByHash(context.Background(), hash)
 	}
-	return block, nil
+	return client.BlockByNumber(context.Background(), at.BlockNumber())
+}
+
+// ResolveAddress accepts a hex address or an ENS name and returns the address it refers to
+func ResolveAddress(client bind.ContractCaller, input string) (common.Address, error) {
+	resolver := ens.NewResolver(client, ens.DefaultRegistry)
+	return resolver.ResolveAddress(context.Background(), input)
 }
 
-// TransferETH performs ETH transfer using secure keystore
-func TransferETH() error {
+// TransferETH performs ETH transfer using secure keystore.
+// With dryRun set the transfer is only simulated and the keystore is never unlocked.
+func TransferETH(dryRun bool) error {
 	fmt.Println("🚀 开始执行 ETH 转账...")
 
 	// 转账参数
 	keystorePath := "./credentials"
 	keystoreFile := "UTC--2025-08-19T04-11-33.145529000Z--ed2026d04ed4c5ae27d4b460b72030054f85d86e"
 	toAddress := "0x5691ab974191673eFe1ce2090f2404b26E2f7D9d"
-	amount := big.NewInt(10000000000000000) // 0.01 ETH
+	amountInput := "0.01 ether"
 	rpcURL := "https://eth-sepolia.g.alchemy.com/v2/vG2GE3gIGxYnxU5KzF3kN79qhQAvl2mS"
+	profile, err := network.Lookup("sepolia")
+	if err != nil {
+		return err
+	}
+
+	amount, err := units.Parse(amountInput)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %v", err)
+	}
 
 	fmt.Printf("📁 Keystore 路径: %s\n", keystorePath)
 	fmt.Printf("📄 Keystore 文件: %s\n", keystoreFile)
 	fmt.Printf("📍 接收地址: %s\n", toAddress)
-	fmt.Printf("💰 转账金额: %s wei (%.8f ETH)\n", amount.String(), new(big.Float).Quo(new(big.Float).SetInt(amount), big.NewFloat(1e18)))
+	fmt.Printf("💰 转账金额: %s (%s)\n", amount.Format(units.Ether, -1), amount.Format(units.Wei, 0))
 	fmt.Printf("🌐 RPC URL: %s\n", rpcURL)
 
-	err := TransferETHWithSecureKeystore(
+	if dryRun {
+		return SimulateETHTransfer(keystorePath, keystoreFile, toAddress, amount, profile.WithRPC(rpcURL))
+	}
+
+	err = TransferETHWithSecureKeystore(
 		keystorePath,
 		keystoreFile,
 		toAddress,
 		amount,
-		rpcURL,
+		profile.WithRPC(rpcURL),
 	)
 
 	if err != nil {
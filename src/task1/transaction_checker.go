@@ -2,12 +2,23 @@ package task1
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
 
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fuckEthereum/src/blockref"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/rpcbatch"
+	"github.com/fuckEthereum/src/txhistory"
+	"github.com/fuckEthereum/src/units"
 )
 
 // TransactionStatus represents the status of a transaction
@@ -24,23 +35,47 @@ type TransactionStatus struct {
 	Error             string
 }
 
-// CheckTransactionStatus checks the status of a transaction
-func CheckTransactionStatus(txHash string, rpcURL string) (*TransactionStatus, error) {
-	// Connect to Ethereum client
+// dial connects to rpcURL for the helpers that take a URL
+func dial(rpcURL string) (*ethclient.Client, error) {
 	client, err := ethclient.Dial(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum client: %v", err)
 	}
+	return client, nil
+}
+
+// CheckTransactionStatus checks the status of a transaction
+func CheckTransactionStatus(txHash string, rpcURL string) (*TransactionStatus, error) {
+	client, err := dial(rpcURL)
+	if err != nil {
+		return nil, err
+	}
 	defer client.Close()
 
-	// Get network information
-	networkID, err := client.NetworkID(context.Background())
+	return CheckTransactionStatusOn(client, txHash)
+}
+
+// CheckTransactionStatusOn checks the status of a transaction on a backend and records
+// what it observed in the local transaction history
+func CheckTransactionStatusOn(client Backend, txHash string) (*TransactionStatus, error) {
+	status, err := transactionStatus(client, txHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get network ID: %v", err)
+		return nil, err
+	}
+	recordObservation(txHash, statusUpdate(status))
+	return status, nil
+}
+
+// transactionStatus looks up the status of a transaction without touching the history
+func transactionStatus(client Backend, txHash string) (*TransactionStatus, error) {
+	// Get the chain ID, which names the network and recovers the sender
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
 	}
 
 	// Determine network name
-	networkName := getNetworkName(networkID)
+	networkName := network.DisplayName(chainID)
 
 	// Parse transaction hash
 	hash := common.HexToHash(txHash)
@@ -51,7 +86,7 @@ func CheckTransactionStatus(txHash string, rpcURL string) (*TransactionStatus, e
 		// Check if transaction is pending
 		_, isPending, err := client.TransactionByHash(context.Background(), hash)
 		if err != nil {
-			return nil, fmt.Errorf("transaction not found: %v", err)
+			return nil, fmt.Errorf("transaction not found: %w", err)
 		}
 
 		if isPending {
@@ -78,12 +113,10 @@ func CheckTransactionStatus(txHash string, rpcURL string) (*TransactionStatus, e
 		status = "FAILED"
 	}
 
-	// Get sender address from transaction
-	fromAddr := common.Address{}
-	if tx.To() != nil {
-		// This is a simplified approach - in production you'd need to recover the sender
-		// For now, we'll use a placeholder
-		fromAddr = common.HexToAddress("0x0000000000000000000000000000000000000000")
+	// Recover the sender from the signature
+	fromAddr, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %v", err)
 	}
 
 	return &TransactionStatus{
@@ -99,14 +132,65 @@ func CheckTransactionStatus(txHash string, rpcURL string) (*TransactionStatus, e
 	}, nil
 }
 
+// recordObservation updates the local transaction history; failures only warn
+func recordObservation(txHash string, update txhistory.Update) {
+	if err := txhistory.Observe(txhistory.PathFromEnv(), txHash, update); err != nil {
+		fmt.Printf("⚠️  Failed to update transaction history: %v\n", err)
+	}
+}
+
+// HistoryConnector dials rpcURL once per reconcile pass of the history reconciler
+func HistoryConnector(rpcURL string) txhistory.Connector {
+	return func(ctx context.Context) (txhistory.Checker, func(), error) {
+		client, err := dial(rpcURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return HistoryChecker(client), client.Close, nil
+	}
+}
+
+// HistoryChecker looks up transactions on client for the history reconciler.
+// It leaves writing the history to the reconciler.
+func HistoryChecker(client Backend) txhistory.Checker {
+	return func(ctx context.Context, hash string) (txhistory.Update, bool, error) {
+		status, err := transactionStatus(client, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			return txhistory.Update{}, false, nil
+		}
+		if err != nil {
+			return txhistory.Update{}, false, err
+		}
+		return statusUpdate(status), true, nil
+	}
+}
+
+// statusUpdate converts a transaction status into a history update
+func statusUpdate(status *TransactionStatus) txhistory.Update {
+	update := txhistory.Update{Status: status.Status, GasUsed: status.GasUsed}
+	if status.BlockNumber != nil {
+		update.BlockNumber = status.BlockNumber.Uint64()
+	}
+	if status.EffectiveGasPrice != nil {
+		update.EffectiveGasPrice = status.EffectiveGasPrice.String()
+		update.Fee = new(big.Int).Mul(status.EffectiveGasPrice, new(big.Int).SetUint64(status.GasUsed)).String()
+	}
+	return update
+}
+
 // WaitForTransaction waits for a transaction to be mined
 func WaitForTransaction(txHash string, rpcURL string, maxWaitTime time.Duration) (*TransactionStatus, error) {
-	client, err := ethclient.Dial(rpcURL)
+	client, err := dial(rpcURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum client: %v", err)
+		return nil, err
 	}
 	defer client.Close()
 
+	return WaitForTransactionOn(client, txHash, maxWaitTime)
+}
+
+// WaitForTransactionOn waits for a transaction to be mined on a backend
+func WaitForTransactionOn(client Backend, txHash string, maxWaitTime time.Duration) (*TransactionStatus, error) {
 	hash := common.HexToHash(txHash)
 	fmt.Println("txHash", txHash)
 	startTime := time.Now()
@@ -116,7 +200,7 @@ func WaitForTransaction(txHash string, rpcURL string, maxWaitTime time.Duration)
 		_, err := client.TransactionReceipt(context.Background(), hash)
 		if err == nil {
 			// Transaction is mined
-			return CheckTransactionStatus(txHash, rpcURL)
+			return CheckTransactionStatusOn(client, txHash)
 		}
 
 		// Wait before checking again
@@ -126,31 +210,81 @@ func WaitForTransaction(txHash string, rpcURL string, maxWaitTime time.Duration)
 	return nil, fmt.Errorf("transaction not mined within %v", maxWaitTime)
 }
 
-// GetAccountBalance gets the balance of an account
+// GetAccountBalance gets the latest balance of an account
 func GetAccountBalance(address string, rpcURL string) (*big.Int, error) {
-	client, err := ethclient.Dial(rpcURL)
+	return GetAccountBalanceAt(address, rpcURL, blockref.Latest)
+}
+
+// GetAccountBalanceAt gets the balance of an account as of the selected block
+func GetAccountBalanceAt(address string, rpcURL string, at blockref.Ref) (*big.Int, error) {
+	client, err := dial(rpcURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum client: %v", err)
+		return nil, err
 	}
 	defer client.Close()
 
-	addr := common.HexToAddress(address)
-	balance, err := client.BalanceAt(context.Background(), addr, nil)
+	return GetAccountBalanceOn(client, address, at)
+}
+
+// GetAccountBalanceOn gets the balance of an account on a backend as of the selected block
+func GetAccountBalanceOn(client Backend, address string, at blockref.Ref) (*big.Int, error) {
+	addr, err := ResolveAddress(client, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve address: %v", err)
+	}
+	balance, err := blockref.BalanceAt(context.Background(), client, addr, at)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get balance: %v", err)
+		return nil, fmt.Errorf("failed to get balance at %s: %w", at, err)
 	}
 
 	return balance, nil
 }
 
+// GetAccountBalancesAt gets the balances of many accounts in batched requests.
+// Each result carries its own error, so one bad address does not fail the rest.
+// Batching needs a JSON-RPC connection, so there is no backend variant.
+func GetAccountBalancesAt(addresses []string, rpcURL string, at blockref.Ref) ([]rpcbatch.Result[*big.Int], error) {
+	client, err := dial(rpcURL)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	results := make([]rpcbatch.Result[*big.Int], len(addresses))
+	var (
+		accounts []common.Address
+		slots    []int
+	)
+	for i, address := range addresses {
+		addr, err := ResolveAddress(client, address)
+		if err != nil {
+			results[i].Err = fmt.Errorf("failed to resolve address: %v", err)
+			continue
+		}
+		accounts = append(accounts, addr)
+		slots = append(slots, i)
+	}
+
+	balances := rpcbatch.Balances(context.Background(), client.Client(), accounts, at, rpcbatch.Options{})
+	for i, balance := range balances {
+		results[slots[i]] = balance
+	}
+	return results, nil
+}
+
 // GetGasPrice gets the current gas price
 func GetGasPrice(rpcURL string) (*big.Int, error) {
-	client, err := ethclient.Dial(rpcURL)
+	client, err := dial(rpcURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum client: %v", err)
+		return nil, err
 	}
 	defer client.Close()
 
+	return GetGasPriceOn(client)
+}
+
+// GetGasPriceOn gets the current gas price of a backend
+func GetGasPriceOn(client Backend) (*big.Int, error) {
 	gasPrice, err := client.SuggestGasPrice(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gas price: %v", err)
@@ -159,77 +293,76 @@ func GetGasPrice(rpcURL string) (*big.Int, error) {
 	return gasPrice, nil
 }
 
-// GetNetworkInfo gets information about the current network
+// GetNetworkInfo gets information about the current network in one batched request
 func GetNetworkInfo(rpcURL string) (map[string]interface{}, error) {
-	client, err := ethclient.Dial(rpcURL)
+	client, err := dial(rpcURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum client: %v", err)
+		return nil, err
 	}
 	defer client.Close()
 
-	// Get network ID
-	networkID, err := client.NetworkID(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to get network ID: %v", err)
+	// Network ID, latest header and gas price in a single batch round trip
+	results := rpcbatch.Do(context.Background(), client.Client(), []rpcbatch.Request{
+		{Method: "net_version"},
+		{Method: "eth_getBlockByNumber", Args: []interface{}{"latest", false}},
+		{Method: "eth_gasPrice"},
+	}, rpcbatch.Options{})
+	for i, what := range []string{"network ID", "latest block", "gas price"} {
+		if results[i].Err != nil {
+			return nil, fmt.Errorf("failed to get %s: %v", what, results[i].Err)
+		}
 	}
 
-	// Get latest block
-	latestBlock, err := client.BlockByNumber(context.Background(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get latest block: %v", err)
+	var version string
+	if err := json.Unmarshal(results[0].Value, &version); err != nil {
+		return nil, fmt.Errorf("failed to parse network ID: %v", err)
+	}
+	networkID, ok := new(big.Int).SetString(version, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid network ID %q", version)
 	}
 
-	// Get gas price
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price: %v", err)
+	var latestBlock types.Header
+	if err := json.Unmarshal(results[1].Value, &latestBlock); err != nil {
+		return nil, fmt.Errorf("failed to parse latest block: %v", err)
+	}
+
+	var price hexutil.Big
+	if err := json.Unmarshal(results[2].Value, &price); err != nil {
+		return nil, fmt.Errorf("failed to parse gas price: %v", err)
 	}
+	gasPrice := price.ToInt()
 
 	return map[string]interface{}{
 		"networkID":    networkID.String(),
-		"networkName":  getNetworkName(networkID),
-		"latestBlock":  latestBlock.Number().String(),
+		"networkName":  network.DisplayName(networkID),
+		"latestBlock":  latestBlock.Number.String(),
 		"gasPrice":     gasPrice.String(),
-		"gasPriceGwei": new(big.Int).Div(gasPrice, big.NewInt(1e9)).String(),
+		"gasPriceGwei": units.NewAmount(gasPrice).Number(units.Gwei, -1),
 	}, nil
 }
 
-// getNetworkName converts network ID to human-readable name
-func getNetworkName(networkID *big.Int) string {
-	switch networkID.String() {
-	case "1":
-		return "Ethereum Mainnet"
-	case "3":
-		return "Ropsten Testnet (Deprecated)"
-	case "4":
-		return "Rinkeby Testnet (Deprecated)"
-	case "5":
-		return "Goerli Testnet (Deprecated)"
-	case "11155111":
-		return "Sepolia Testnet"
-	case "137":
-		return "Polygon Mainnet"
-	case "80001":
-		return "Polygon Mumbai Testnet"
-	case "56":
-		return "BSC Mainnet"
-	case "97":
-		return "BSC Testnet"
-	default:
-		return fmt.Sprintf("Unknown Network (ID: %s)", networkID.String())
-	}
-}
-
 // ValidateTransaction checks if a transaction can be sent
-func ValidateTransaction(fromAddress, toAddress string, amount *big.Int, rpcURL string) error {
-	client, err := ethclient.Dial(rpcURL)
+func ValidateTransaction(fromAddress, toAddress string, amount units.Amount, rpcURL string) error {
+	client, err := dial(rpcURL)
 	if err != nil {
-		return fmt.Errorf("failed to connect to Ethereum client: %v", err)
+		return err
 	}
 	defer client.Close()
 
-	fromAddr := common.HexToAddress(fromAddress)
-	_ = common.HexToAddress(toAddress) // Use toAddress parameter
+	return ValidateTransactionOn(client, fromAddress, toAddress, amount)
+}
+
+// ValidateTransactionOn checks if a transaction can be sent on a backend
+func ValidateTransactionOn(client Backend, fromAddress, toAddress string, amount units.Amount) error {
+	fromAddr, err := ResolveAddress(client, fromAddress)
+	if err != nil {
+		return fmt.Errorf("failed to resolve sender: %v", err)
+	}
+	toAddr, err := ResolveAddress(client, toAddress)
+	if err != nil {
+		return fmt.Errorf("failed to resolve recipient: %v", err)
+	}
 
 	// Check sender balance
 	balance, err := client.BalanceAt(context.Background(), fromAddr, nil)
@@ -243,20 +376,28 @@ func ValidateTransaction(fromAddress, toAddress string, amount *big.Int, rpcURL
 		return fmt.Errorf("failed to get gas price: %v", err)
 	}
 
-	// Estimate gas
-	gasLimit := uint64(21000) // Standard ETH transfer
+	// Estimate gas for the exact transfer; a revert means the transfer cannot succeed
+	estimate, err := gas.NewEstimator(client, gas.MultiplierFromEnv()).Estimate(context.Background(), ethereum.CallMsg{
+		From:  fromAddr,
+		To:    &toAddr,
+		Value: amount.Wei(),
+	}, gas.DefaultTransferGas)
+	if err != nil {
+		return fmt.Errorf("gas estimation failed: %v", err)
+	}
+	gasLimit := estimate.Limit
 	gasCost := new(big.Int).Mul(gasPrice, big.NewInt(int64(gasLimit)))
-	totalCost := new(big.Int).Add(amount, gasCost)
+	totalCost := new(big.Int).Add(amount.Wei(), gasCost)
 
 	if balance.Cmp(totalCost) < 0 {
-		return fmt.Errorf("insufficient balance: have %s wei, need %s wei", balance.String(), totalCost.String())
+		return fmt.Errorf("insufficient balance: have %s, need %s", units.FormatWei(balance, units.Ether, -1), units.FormatWei(totalCost, units.Ether, -1))
 	}
 
 	fmt.Printf("✅ Transaction validation passed\n")
-	fmt.Printf("💰 Balance: %s wei\n", balance.String())
-	fmt.Printf("💸 Transfer amount: %s wei\n", amount.String())
-	fmt.Printf("⛽ Gas cost: %s wei\n", gasCost.String())
-	fmt.Printf("💳 Total cost: %s wei\n", totalCost.String())
+	fmt.Printf("💰 Balance: %s\n", units.FormatWei(balance, units.Ether, -1))
+	fmt.Printf("💸 Transfer amount: %s\n", amount.Format(units.Ether, -1))
+	fmt.Printf("⛽ Gas cost: %s (limit %d)\n", units.FormatWei(gasCost, units.Ether, -1), gasLimit)
+	fmt.Printf("💳 Total cost: %s\n", units.FormatWei(totalCost, units.Ether, -1))
 
 	return nil
 }
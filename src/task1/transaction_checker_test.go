@@ -0,0 +1,294 @@
+package task1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/src/blockref"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/simchain"
+	"github.com/fuckEthereum/src/txhistory"
+	"github.com/fuckEthereum/src/units"
+)
+
+const testPassword = "correct horse"
+
+// transferEnv is a simulated chain whose first account is stored in a keystore
+type transferEnv struct {
+	chain        *simchain.Chain
+	profile      *network.Profile
+	keystorePath string
+	keystoreFile string
+	historyPath  string
+}
+
+// newTransferEnv points deployments, transaction history and the policy audit log at a
+// temporary directory and imports account 0 of a fresh chain into a keystore there
+func newTransferEnv(t *testing.T) *transferEnv {
+	t.Helper()
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.json")
+	policy := fmt.Sprintf(`{"auditLog": %q}`, filepath.Join(dir, "policy_audit.jsonl"))
+	if err := os.WriteFile(policyFile, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e := &transferEnv{keystorePath: filepath.Join(dir, "keystore"), historyPath: filepath.Join(dir, "tx_history.db")}
+	t.Setenv("POLICY_FILE", policyFile)
+	t.Setenv("TX_HISTORY_DB", e.historyPath)
+	t.Setenv("DEPLOYMENTS_DIR", filepath.Join(dir, "deployments"))
+
+	chain, err := simchain.New(2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	e.chain = chain
+
+	ks := keystore.NewKeyStore(e.keystorePath, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(chain.Key(0), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.keystoreFile = filepath.Base(account.URL.Path)
+
+	e.profile, err = network.Lookup("local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// wallet returns a keystore wallet that answers every password prompt with password
+func (e *transferEnv) wallet(password string) *SecureKeystoreWallet {
+	return NewSecureKeystoreWalletWithPassword(e.keystorePath, func(string) (string, error) {
+		return password, nil
+	})
+}
+
+func (e *transferEnv) balance(t *testing.T, account int, at blockref.Ref) *big.Int {
+	t.Helper()
+	balance, err := GetAccountBalanceOn(e.chain.Client(), e.chain.Address(account).Hex(), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func (e *transferEnv) nonce(t *testing.T) uint64 {
+	t.Helper()
+	nonce, err := e.chain.Client().NonceAt(context.Background(), e.chain.Address(0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nonce
+}
+
+// transfer sends amount from the keystore account to account 1 and returns the mined transaction
+func (e *transferEnv) transfer(t *testing.T, amount units.Amount) *types.Transaction {
+	t.Helper()
+	err := TransferETHWithSecureKeystoreOn(e.chain.Client(), e.wallet(testPassword), e.keystoreFile,
+		e.chain.Address(1).Hex(), amount, e.profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := QueryBlock(e.chain.Client(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions()) != 1 {
+		t.Fatalf("latest block has %d transactions, want the transfer", len(block.Transactions()))
+	}
+	return block.Transactions()[0]
+}
+
+// history returns the history record of tx
+func (e *transferEnv) history(t *testing.T, tx *types.Transaction) *txhistory.Record {
+	t.Helper()
+	store, err := txhistory.Open(e.historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	rec, err := store.Get(tx.Hash().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestTransferWithSecureKeystore(t *testing.T) {
+	e := newTransferEnv(t)
+	amount := units.MustParse("1 ether")
+	before := e.balance(t, 1, blockref.Latest)
+
+	tx := e.transfer(t, amount)
+
+	if received := new(big.Int).Sub(e.balance(t, 1, blockref.Latest), before); received.Cmp(amount.Wei()) != 0 {
+		t.Errorf("recipient received %s, want %s", units.FormatWei(received, units.Ether, -1), amount.Format(units.Ether, -1))
+	}
+
+	// The sender paid the value plus the fee of the receipt
+	receipt, err := e.chain.Client().TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	spent := new(big.Int).Sub(e.balance(t, 0, blockref.Number(receipt.BlockNumber.Uint64()-1)), e.balance(t, 0, blockref.Hash(receipt.BlockHash)))
+	if want := new(big.Int).Add(amount.Wei(), fee); spent.Cmp(want) != 0 {
+		t.Errorf("sender spent %s, want %s", spent, want)
+	}
+
+	store, err := txhistory.Open(e.historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if rec, err := store.Get(tx.Hash().Hex()); err != nil || rec.Label != "transfer" {
+		t.Errorf("history record %+v, %v", rec, err)
+	}
+}
+
+func TestTransferWithWrongPassword(t *testing.T) {
+	e := newTransferEnv(t)
+	err := TransferETHWithSecureKeystoreOn(e.chain.Client(), e.wallet("wrong"), e.keystoreFile,
+		e.chain.Address(1).Hex(), units.MustParse("1 ether"), e.profile)
+	if err == nil || !strings.Contains(err.Error(), "failed to unlock account") {
+		t.Fatalf("got %v, want an unlock failure", err)
+	}
+	if nonce := e.nonce(t); nonce != 0 {
+		t.Errorf("nonce %d, want nothing sent", nonce)
+	}
+}
+
+func TestTransferRejectsWrongChain(t *testing.T) {
+	e := newTransferEnv(t)
+	sepolia, err := network.Lookup("sepolia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = TransferETHWithSecureKeystoreOn(e.chain.Client(), e.wallet(testPassword), e.keystoreFile,
+		e.chain.Address(1).Hex(), units.MustParse("1 ether"), sepolia)
+	if !errors.Is(err, network.ErrChainIDMismatch) {
+		t.Fatalf("got %v, want ErrChainIDMismatch", err)
+	}
+}
+
+func TestSimulateTransferSendsNothing(t *testing.T) {
+	e := newTransferEnv(t)
+	err := SimulateETHTransferOn(e.chain.Client(), e.keystorePath, e.keystoreFile,
+		e.chain.Address(1).Hex(), units.MustParse("1 ether"), e.profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce := e.nonce(t); nonce != 0 {
+		t.Errorf("nonce %d, want nothing sent", nonce)
+	}
+}
+
+func TestCheckTransactionStatus(t *testing.T) {
+	e := newTransferEnv(t)
+	client := e.chain.Client()
+	tx := e.transfer(t, units.MustParse("1 ether"))
+
+	status, err := CheckTransactionStatusOn(client, tx.Hash().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != "SUCCESS" {
+		t.Errorf("status is %s, want SUCCESS", status.Status)
+	}
+	if status.From != e.chain.Address(0) || status.To == nil || *status.To != e.chain.Address(1) {
+		t.Errorf("unexpected sender or recipient: %s → %v", status.From.Hex(), status.To)
+	}
+	if status.Value.Cmp(units.MustParse("1 ether").Wei()) != 0 {
+		t.Errorf("value is %s, want 1 ETH", status.Value)
+	}
+
+	waited, err := WaitForTransactionOn(client, tx.Hash().Hex(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if waited.BlockNumber.Cmp(status.BlockNumber) != 0 {
+		t.Errorf("waited receipt is in block %s, status says %s", waited.BlockNumber, status.BlockNumber)
+	}
+
+	unknown := common.HexToHash("0x01").Hex()
+	if _, err := CheckTransactionStatusOn(client, unknown); !errors.Is(err, ethereum.NotFound) {
+		t.Errorf("unknown transaction: got %v, want not found", err)
+	}
+}
+
+func TestHistoryReconcile(t *testing.T) {
+	e := newTransferEnv(t)
+	client := e.chain.Client()
+	tx := e.transfer(t, units.MustParse("1 ether"))
+	ctx := context.Background()
+
+	// Forget the outcome so the reconciler has something to find
+	rec, err := txhistory.NewRecord(tx, "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := txhistory.Open(e.historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(rec); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// The checker only reports; writing the history is left to the reconciler
+	update, found, err := HistoryChecker(client)(ctx, tx.Hash().Hex())
+	if err != nil || !found || update.Status != txhistory.StatusSuccess || update.BlockNumber == 0 || update.Fee == "" {
+		t.Fatalf("checker returned %+v, %v, %v", update, found, err)
+	}
+	if got := e.history(t, tx); got.Status != txhistory.StatusPending || !got.UpdatedAt.Equal(rec.UpdatedAt) {
+		t.Fatalf("checker wrote the history: %+v", got)
+	}
+	if _, found, err := HistoryChecker(client)(ctx, common.HexToHash("0x01").Hex()); found || err != nil {
+		t.Fatalf("unknown transaction: found %v, %v", found, err)
+	}
+
+	dials := 0
+	connect := func(ctx context.Context) (txhistory.Checker, func(), error) {
+		dials++
+		return HistoryChecker(client), func() {}, nil
+	}
+	changed, err := txhistory.NewReconciler(e.historyPath, connect, time.Hour).Once(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 1 || dials != 1 {
+		t.Fatalf("changed %d over %d connections, want 1 over 1", changed, dials)
+	}
+	got := e.history(t, tx)
+	if got.Status != txhistory.StatusSuccess || len(got.Transitions) != 2 || got.BlockNumber != update.BlockNumber || got.Fee != update.Fee {
+		t.Fatalf("reconciled record %+v", got)
+	}
+}
+
+func TestValidateTransaction(t *testing.T) {
+	e := newTransferEnv(t)
+	client := e.chain.Client()
+	from, to := e.chain.Address(1).Hex(), e.chain.Address(0).Hex()
+
+	if err := ValidateTransactionOn(client, from, to, units.MustParse("1 ether")); err != nil {
+		t.Errorf("affordable transfer rejected: %v", err)
+	}
+	err := ValidateTransactionOn(client, from, to, units.MustParse("1000000 ether"))
+	if err == nil || !strings.Contains(err.Error(), "insufficient balance") {
+		t.Errorf("unaffordable transfer: got %v, want insufficient balance", err)
+	}
+}
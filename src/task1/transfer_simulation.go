@@ -0,0 +1,82 @@
+package task1
+
+import (
+	"context"
+	"fmt"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/simulate"
+	"github.com/fuckEthereum/src/units"
+)
+
+// SimulateETHTransfer predicts an ETH transfer from a keystore account.
+// The keystore is never unlocked and nothing is signed or sent.
+func SimulateETHTransfer(
+	keystorePath string,
+	keystoreFile string,
+	toAddress string,
+	amount units.Amount,
+	profile *network.Profile,
+) error {
+	client, err := dial(profile.RPCURL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return SimulateETHTransferOn(client, keystorePath, keystoreFile, toAddress, amount, profile)
+}
+
+// SimulateETHTransferOn predicts an ETH transfer from a keystore account on a backend.
+// The call is only traced when the backend is an RPC client.
+func SimulateETHTransferOn(
+	client TransferBackend,
+	keystorePath string,
+	keystoreFile string,
+	toAddress string,
+	amount units.Amount,
+	profile *network.Profile,
+) error {
+	fmt.Println("🧪 开始模拟 ETH 转账 (dry run)...")
+
+	wallet := NewSecureKeystoreWallet(keystorePath)
+	if err := wallet.ImportKeystore(keystorePath + "/" + keystoreFile); err != nil {
+		return fmt.Errorf("failed to import keystore: %v", err)
+	}
+	fromAddress, err := wallet.GetAddress()
+	if err != nil {
+		return fmt.Errorf("failed to get address: %v", err)
+	}
+
+	chainID, err := network.VerifyChainID(context.Background(), client, profile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ 网络: %s (链 ID %s)\n", network.DisplayName(chainID), chainID)
+
+	toAddr, err := ResolveAddress(client, toAddress)
+	if err != nil {
+		return fmt.Errorf("failed to resolve recipient: %v", err)
+	}
+	fmt.Printf("📍 %s → %s: %s\n", fromAddress.Hex(), toAddr.Hex(), amount.Format(units.Ether, -1))
+
+	var tracer simulate.Tracer
+	if rpcClient, ok := client.(interface{ Client() *rpc.Client }); ok {
+		tracer = rpcClient.Client()
+	}
+	simulator := simulate.New(client, tracer, gas.MultiplierFromEnv())
+	report, err := simulator.Simulate(context.Background(), ethereum.CallMsg{
+		From:  fromAddress,
+		To:    &toAddr,
+		Value: amount.Wei(),
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to simulate transfer: %v", err)
+	}
+
+	simulate.PrintReport("transfer", report)
+	return nil
+}
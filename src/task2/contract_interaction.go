@@ -8,29 +8,77 @@ import (
 	"os"
 	"time"
 
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/artifact"
+	"github.com/fuckEthereum/src/blockref"
+	"github.com/fuckEthereum/src/create2"
+	"github.com/fuckEthereum/src/ens"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/network"
+	"github.com/fuckEthereum/src/policy"
+	"github.com/fuckEthereum/src/proxy"
+	"github.com/fuckEthereum/src/registry"
+	"github.com/fuckEthereum/src/simulate"
+	"github.com/fuckEthereum/src/txhistory"
+	"github.com/fuckEthereum/src/txpipe"
+	"github.com/fuckEthereum/src/units"
+	"github.com/fuckEthereum/src/verify"
 )
 
+// Backend is what ContractInteraction needs from a node. *ethclient.Client implements it, and so
+// does simchain.Client, which runs everything against an in-process simulated chain.
+type Backend interface {
+	txpipe.Backend
+	create2.Backend
+	simulate.Backend
+	blockref.BalanceReader
+	proxy.StorageReader
+	ethereum.ChainIDReader
+}
+
 // ContractInteraction demonstrates how to interact with the Counter contract on Sepolia testnet
 type ContractInteraction struct {
-	client     *ethclient.Client
-	privateKey *ecdsa.PrivateKey
-	address    common.Address
-	instance   *contracts.Counter
+	client          Backend
+	rpcClient       *ethclient.Client // nil unless dialed by NewContractInteraction
+	privateKey      *ecdsa.PrivateKey
+	address         common.Address
+	instance        *contracts.Counter
+	contractAddress common.Address
+	counterABI      *abi.ABI
+	pipeline        *txpipe.Pipeline
+	simulator       *simulate.Simulator // non-nil in dry-run mode
+	chainID         *big.Int
+	force           bool // deploy even when the registry has an identical deployment
 }
 
-// NewContractInteraction creates a new contract interaction instance
-func NewContractInteraction(rpcURL, privateKeyHex string) (*ContractInteraction, error) {
-	// Connect to Sepolia testnet
-	client, err := ethclient.Dial(rpcURL)
+// NewContractInteraction creates a new contract interaction instance for a network profile.
+// Extra hooks (e.g. txpipe.DryRunHooks) run after logging and before the transaction policy.
+func NewContractInteraction(profile *network.Profile, privateKeyHex string, hooks ...txpipe.Hooks) (*ContractInteraction, error) {
+	// Connect to the configured network
+	client, err := ethclient.Dial(profile.RPCURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum client: %v", err)
 	}
 
+	ci, err := NewContractInteractionWithBackend(client, profile, privateKeyHex, hooks...)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	ci.rpcClient = client
+	return ci, nil
+}
+
+// NewContractInteractionWithBackend creates a contract interaction instance on an existing
+// backend, such as a simulated chain. The profile may be nil to accept any chain ID.
+// The backend is not closed by Close.
+func NewContractInteractionWithBackend(client Backend, profile *network.Profile, privateKeyHex string, hooks ...txpipe.Hooks) (*ContractInteraction, error) {
 	// Parse private key
 	privateKey, err := crypto.HexToECDSA(privateKeyHex)
 	if err != nil {
@@ -45,297 +93,561 @@ func NewContractInteraction(rpcURL, privateKeyHex string) (*ContractInteraction,
 	}
 	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	return &ContractInteraction{
-		client:     client,
-		privateKey: privateKey,
-		address:    address,
-	}, nil
-}
-
-// DeployContract deploys the Counter contract to Sepolia testnet
-func (ci *ContractInteraction) DeployContract() error {
-	fmt.Println("🚀 Deploying Counter contract to Sepolia testnet...")
-
-	// Get the chain ID
-	chainID, err := ci.client.NetworkID(context.Background())
+	// Get the EIP-155 chain ID and make sure it is the network we think it is
+	chainID, err := network.VerifyChainID(context.Background(), client, profile)
 	if err != nil {
-		return fmt.Errorf("failed to get chain ID: %v", err)
+		return nil, err
 	}
 
-	// Get the nonce
-	nonce, err := ci.client.PendingNonceAt(context.Background(), ci.address)
+	// Create the signer template shared by every write
+	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
 	if err != nil {
-		return fmt.Errorf("failed to get nonce: %v", err)
+		return nil, fmt.Errorf("failed to create transactor: %v", err)
 	}
 
-	// Get gas price
-	gasPrice, err := ci.client.SuggestGasPrice(context.Background())
+	counterABI, err := contracts.CounterMetaData.GetAbi()
 	if err != nil {
-		return fmt.Errorf("failed to get gas price: %v", err)
+		return nil, fmt.Errorf("failed to parse Counter ABI: %v", err)
 	}
 
-	// Create transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(ci.privateKey, chainID)
+	rules, err := policy.LoadFromEnv()
 	if err != nil {
-		return fmt.Errorf("failed to create transactor: %v", err)
+		return nil, err
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // No ETH sent
-	auth.GasLimit = uint64(300000) // Set gas limit
-	auth.GasPrice = gasPrice
+	engine := policy.NewEngine(rules, policy.StdinConfirmer)
 
-	// Deploy the contract
-	contractAddress, tx, instance, err := contracts.DeployCounter(auth, ci.client)
-	if err != nil {
-		return fmt.Errorf("failed to deploy contract: %v", err)
-	}
+	// Policy runs last so a dry run never prompts or counts toward the daily limit
+	chain := append([]txpipe.Hooks{txpipe.LogHooks()}, hooks...)
+	chain = append(chain, engine.Hooks(chainID, profile), txhistory.Hooks(txhistory.PathFromEnv()))
 
-	ci.instance = instance
+	estimator := gas.NewEstimator(client, gas.MultiplierFromEnv())
+	pipeline := txpipe.New(client, auth, estimator, chain...)
 
-	fmt.Printf("📝 Contract deployment transaction hash: %s\n", tx.Hash().Hex())
-	fmt.Printf("📍 Contract address: %s\n", contractAddress.Hex())
+	return &ContractInteraction{
+		client:     client,
+		privateKey: privateKey,
+		address:    address,
+		counterABI: counterABI,
+		pipeline:   pipeline,
+		chainID:    chainID,
+	}, nil
+}
+
+// EnableDryRun makes every write simulate against pending state instead of signing.
+// Calls are only traced when connected over RPC.
+func (ci *ContractInteraction) EnableDryRun() {
+	var tracer simulate.Tracer
+	if ci.rpcClient != nil {
+		tracer = ci.rpcClient.Client()
+	}
+	ci.simulator = simulate.New(ci.client, tracer, gas.MultiplierFromEnv())
+}
+
+// ForceDeploy makes deploys ignore identical deployments recorded in the registry
+func (ci *ContractInteraction) ForceDeploy() {
+	ci.force = true
+}
 
-	// Wait for transaction to be mined
-	fmt.Println("⏳ Waiting for transaction to be mined...")
-	receipt, err := bind.WaitMined(context.Background(), ci.client, tx)
+// simulateRequest predicts the outcome of a pipeline request without signing it
+func (ci *ContractInteraction) simulateRequest(req *txpipe.Request) error {
+	data, err := req.CallData()
 	if err != nil {
-		return fmt.Errorf("failed to wait for transaction: %v", err)
+		return fmt.Errorf("failed to pack %s: %v", req.Label, err)
 	}
 
-	if receipt.Status == 0 {
-		return fmt.Errorf("contract deployment failed")
+	report, err := ci.simulator.Simulate(context.Background(), ethereum.CallMsg{
+		From:  ci.address,
+		To:    req.To,
+		Value: req.Value,
+		Data:  data,
+	}, req.ABI)
+	if err != nil {
+		return fmt.Errorf("failed to simulate %s: %v", req.Label, err)
 	}
 
-	fmt.Printf("✅ Contract deployed successfully! Gas used: %d\n", receipt.GasUsed)
+	simulate.PrintReport(req.Label, report)
 	return nil
 }
 
-// LoadExistingContract loads an existing contract instance
-func (ci *ContractInteraction) LoadExistingContract(contractAddress string) error {
-	address := common.HexToAddress(contractAddress)
-	instance, err := contracts.NewCounter(address, ci.client)
+// DeployContract deploys the Counter contract through the transaction pipeline,
+// reusing an identical deployment from the registry unless forced
+func (ci *ContractInteraction) DeployContract() error {
+	fmt.Println("🚀 Deploying Counter contract...")
+
+	req := &txpipe.Request{
+		Label:       "deploy Counter",
+		ABI:         ci.counterABI,
+		Bytecode:    common.FromHex(contracts.CounterMetaData.Bin),
+		FallbackGas: gas.DefaultDeployGas,
+	}
+	address, err := ci.deploy(registry.Deployment{Name: "Counter"}, req)
 	if err != nil {
-		return fmt.Errorf("failed to create contract instance: %v", err)
+		return fmt.Errorf("failed to deploy contract: %v", err)
+	}
+	if address == (common.Address{}) {
+		return nil
 	}
 
+	instance, err := contracts.NewCounter(address, ci.client)
+	if err != nil {
+		return fmt.Errorf("failed to bind deployed contract: %v", err)
+	}
 	ci.instance = instance
-	fmt.Printf("📋 Loaded existing contract at address: %s\n", contractAddress)
+	ci.contractAddress = address
+	ci.reportVerification()
 	return nil
 }
 
-// GetCurrentCount retrieves the current count from the contract
-func (ci *ContractInteraction) GetCurrentCount() (*big.Int, error) {
-	if ci.instance == nil {
-		return nil, fmt.Errorf("contract instance not initialized")
-	}
+// DeployArtifact deploys a contract loaded from a Foundry or Hardhat artifact through the
+// transaction pipeline, after linking libraries into its creation code
+func (ci *ContractInteraction) DeployArtifact(art *artifact.Artifact, args []interface{}, libs map[string]common.Address, value *big.Int) (common.Address, error) {
+	fmt.Printf("🚀 Deploying %s...\n", art.Name)
 
-	count, err := ci.instance.GetCount(nil)
+	bytecode, err := art.Link(libs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get count: %v", err)
+		return common.Address{}, fmt.Errorf("failed to link %s: %v", art.Name, err)
 	}
 
-	return count, nil
-}
-
-// IncrementCount increments the counter by 1
-func (ci *ContractInteraction) IncrementCount() error {
-	if ci.instance == nil {
-		return fmt.Errorf("contract instance not initialized")
+	req := &txpipe.Request{
+		Label:       "deploy " + art.Name,
+		ABI:         &art.ABI,
+		Args:        args,
+		Bytecode:    bytecode,
+		Value:       value,
+		FallbackGas: gas.DefaultDeployGas,
 	}
+	address, err := ci.deploy(registry.Deployment{Name: art.Name}, req)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to deploy %s: %v", art.Name, err)
+	}
+	return address, nil
+}
 
-	fmt.Println("➕ Incrementing counter...")
+// deploy runs a deployment request and records it in the registry of the current chain,
+// starting from record (at least its Name). An identical recorded deployment that still has
+// code is returned instead, unless forced. The address is zero when nothing was deployed (dry run).
+func (ci *ContractInteraction) deploy(record registry.Deployment, req *txpipe.Request) (common.Address, error) {
+	ctx := context.Background()
+	name := record.Name
 
-	// Get the chain ID
-	chainID, err := ci.client.NetworkID(context.Background())
+	args, err := req.ABI.Pack("", req.Args...)
 	if err != nil {
-		return fmt.Errorf("failed to get chain ID: %v", err)
+		return common.Address{}, fmt.Errorf("failed to pack constructor arguments: %v", err)
 	}
+	bytecodeHash := registry.BytecodeHash(req.Bytecode)
 
-	// Get the nonce
-	nonce, err := ci.client.PendingNonceAt(context.Background(), ci.address)
+	reg, err := registry.Load(registry.DirFromEnv(), ci.chainID)
 	if err != nil {
-		return fmt.Errorf("failed to get nonce: %v", err)
+		return common.Address{}, err
+	}
+	if existing, ok := reg.FindIdentical(name, bytecodeHash, args); ok && !ci.force {
+		code, err := ci.client.CodeAt(ctx, existing.Address, nil)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("failed to check recorded deployment: %v", err)
+		}
+		if len(code) > 0 {
+			fmt.Printf("⏭️  Identical %s already deployed at %s (block %d), skipping; force to redeploy\n", name, existing.Address.Hex(), existing.Block)
+			return existing.Address, nil
+		}
+		fmt.Printf("⚠️  Recorded %s at %s has no code, deploying again\n", name, existing.Address.Hex())
 	}
 
-	// Get gas price
-	gasPrice, err := ci.client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return fmt.Errorf("failed to get gas price: %v", err)
+	if ci.simulator != nil {
+		return common.Address{}, ci.simulateRequest(req)
 	}
 
-	// Create transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(ci.privateKey, chainID)
+	result, err := ci.pipeline.Deploy(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to create transactor: %v", err)
+		return common.Address{}, err
+	}
+	if result.DryRun {
+		return common.Address{}, nil
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // No ETH sent
-	auth.GasLimit = uint64(100000) // Set gas limit
-	auth.GasPrice = gasPrice
+	fmt.Printf("📍 Contract address: %s\n", result.ContractAddress.Hex())
+	record.Address = result.ContractAddress
+	record.BytecodeHash = bytecodeHash
+	record.ConstructorArgs = args
+	ci.record(reg, record, result)
+	return result.ContractAddress, nil
+}
 
-	// Call the increment function
-	tx, err := ci.instance.Increment(auth)
-	if err != nil {
-		return fmt.Errorf("failed to call increment: %v", err)
+// record completes a deployment from its pipeline result and saves it in the registry
+func (ci *ContractInteraction) record(reg *registry.Registry, deployment registry.Deployment, result *txpipe.Result) {
+	deployment.TxHash = result.Tx.Hash()
+	deployment.Block = result.Receipt.BlockNumber.Uint64()
+	deployment.Deployer = ci.address
+	deployment.DeployedAt = time.Now().UTC()
+
+	reg.Add(deployment)
+	if err := reg.Save(); err != nil {
+		// The contract is deployed either way; losing the record only costs idempotency
+		fmt.Printf("⚠️  Failed to record deployment: %v\n", err)
+	} else {
+		fmt.Printf("🗂️  Recorded in %s\n", reg.Path())
 	}
+}
 
-	fmt.Printf("📝 Increment transaction hash: %s\n", tx.Hash().Hex())
+// DeployCounterDeterministic deploys the Counter through the CREATE2 factory, so the
+// same salt gives the same address on every chain
+func (ci *ContractInteraction) DeployCounterDeterministic(salt common.Hash) error {
+	fmt.Println("🚀 Deploying Counter contract with CREATE2...")
 
-	// Wait for transaction to be mined
-	fmt.Println("⏳ Waiting for transaction to be mined...")
-	receipt, err := bind.WaitMined(context.Background(), ci.client, tx)
+	address, err := ci.deployCreate2("Counter", ci.counterABI, common.FromHex(contracts.CounterMetaData.Bin), nil, nil, salt)
 	if err != nil {
-		return fmt.Errorf("failed to wait for transaction: %v", err)
+		return fmt.Errorf("failed to deploy contract: %v", err)
 	}
-
-	if receipt.Status == 0 {
-		return fmt.Errorf("increment transaction failed")
+	if address == (common.Address{}) {
+		return nil
 	}
 
-	fmt.Printf("✅ Counter incremented successfully! Gas used: %d\n", receipt.GasUsed)
+	instance, err := contracts.NewCounter(address, ci.client)
+	if err != nil {
+		return fmt.Errorf("failed to bind deployed contract: %v", err)
+	}
+	ci.instance = instance
+	ci.contractAddress = address
+	ci.reportVerification()
 	return nil
 }
 
-// DecrementCount decrements the counter by 1
-func (ci *ContractInteraction) DecrementCount() error {
-	if ci.instance == nil {
-		return fmt.Errorf("contract instance not initialized")
+// DeployArtifactDeterministic is DeployArtifact through the CREATE2 factory
+func (ci *ContractInteraction) DeployArtifactDeterministic(art *artifact.Artifact, args []interface{}, libs map[string]common.Address, value *big.Int, salt common.Hash) (common.Address, error) {
+	fmt.Printf("🚀 Deploying %s with CREATE2...\n", art.Name)
+
+	bytecode, err := art.Link(libs)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to link %s: %v", art.Name, err)
+	}
+	address, err := ci.deployCreate2(art.Name, &art.ABI, bytecode, args, value, salt)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to deploy %s: %v", art.Name, err)
 	}
+	return address, nil
+}
 
-	fmt.Println("➖ Decrementing counter...")
+// deployCreate2 sends creation code through the CREATE2 factory and records the deployment.
+// Existing code at the pre-computed address is returned as is. The address is zero when
+// nothing was deployed (dry run).
+func (ci *ContractInteraction) deployCreate2(name string, contractABI *abi.ABI, bytecode []byte, args []interface{}, value *big.Int, salt common.Hash) (common.Address, error) {
+	ctx := context.Background()
 
-	// Get the chain ID
-	chainID, err := ci.client.NetworkID(context.Background())
+	initCode, err := create2.InitCode(contractABI, bytecode, args...)
 	if err != nil {
-		return fmt.Errorf("failed to get chain ID: %v", err)
+		return common.Address{}, err
 	}
+	target := create2.Address(create2.FactoryAddress, salt, initCode)
+	fmt.Printf("🎯 CREATE2 address: %s (salt %s)\n", target.Hex(), salt.Hex())
 
-	// Get the nonce
-	nonce, err := ci.client.PendingNonceAt(context.Background(), ci.address)
+	code, err := ci.client.CodeAt(ctx, target, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get nonce: %v", err)
+		return common.Address{}, fmt.Errorf("failed to get code at %s: %v", target.Hex(), err)
+	}
+	if len(code) > 0 {
+		fmt.Printf("⏭️  %s already has code, skipping deploy\n", target.Hex())
+		return target, nil
 	}
 
-	// Get gas price
-	gasPrice, err := ci.client.SuggestGasPrice(context.Background())
+	if err := ci.ensureFactory(ctx); err != nil {
+		return common.Address{}, err
+	}
+
+	factory := create2.FactoryAddress
+	req := &txpipe.Request{
+		Label:       "create2 " + name,
+		ABI:         contractABI,
+		To:          &factory,
+		Data:        create2.Calldata(salt, initCode),
+		Value:       value,
+		FallbackGas: gas.DefaultDeployGas,
+	}
+	if ci.simulator != nil {
+		return common.Address{}, ci.simulateRequest(req)
+	}
+
+	result, err := ci.pipeline.Transact(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to get gas price: %v", err)
+		return common.Address{}, err
+	}
+	if result.DryRun {
+		return common.Address{}, nil
 	}
+	code, err = ci.client.CodeAt(ctx, target, nil)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to get code at %s: %v", target.Hex(), err)
+	}
+	if len(code) == 0 {
+		return common.Address{}, fmt.Errorf("factory call %s left no code at %s", result.Tx.Hash().Hex(), target.Hex())
+	}
+	fmt.Printf("📍 Contract address: %s\n", target.Hex())
 
-	// Create transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(ci.privateKey, chainID)
+	reg, err := registry.Load(registry.DirFromEnv(), ci.chainID)
 	if err != nil {
-		return fmt.Errorf("failed to create transactor: %v", err)
+		fmt.Printf("⚠️  Failed to record deployment: %v\n", err)
+		return target, nil
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // No ETH sent
-	auth.GasLimit = uint64(100000) // Set gas limit
-	auth.GasPrice = gasPrice
+	ci.record(reg, registry.Deployment{
+		Name:            name,
+		Address:         target,
+		BytecodeHash:    registry.BytecodeHash(bytecode),
+		ConstructorArgs: initCode[len(bytecode):],
+		Salt:            &salt,
+	}, result)
+	return target, nil
+}
 
-	// Call the decrement function
-	tx, err := ci.instance.Decrement(auth)
+// ensureFactory makes sure the CREATE2 factory exists, deploying it on local chains
+func (ci *ContractInteraction) ensureFactory(ctx context.Context) error {
+	code, err := ci.client.CodeAt(ctx, create2.FactoryAddress, nil)
 	if err != nil {
-		return fmt.Errorf("failed to call decrement: %v", err)
+		return fmt.Errorf("failed to get factory code: %v", err)
+	}
+	if len(code) > 0 {
+		return nil
+	}
+	if !network.IsLocal(ci.chainID) {
+		return fmt.Errorf("%w at %s on chain %s", create2.ErrNoFactory, create2.FactoryAddress.Hex(), ci.chainID)
+	}
+	if ci.simulator != nil {
+		return fmt.Errorf("%w on this local chain yet; run without --dry-run to deploy it", create2.ErrNoFactory)
 	}
 
-	fmt.Printf("📝 Decrement transaction hash: %s\n", tx.Hash().Hex())
+	fmt.Printf("🏭 Deploying CREATE2 factory at %s...\n", create2.FactoryAddress.Hex())
+	fund := func(ctx context.Context, to common.Address, amount *big.Int) error {
+		_, err := ci.pipeline.Transact(ctx, &txpipe.Request{
+			Label:       "fund CREATE2 factory deployer",
+			To:          &to,
+			Value:       amount,
+			FallbackGas: gas.DefaultTransferGas,
+		})
+		return err
+	}
+	if _, err := create2.EnsureFactory(ctx, ci.client, fund); err != nil {
+		return err
+	}
+	fmt.Println("✅ CREATE2 factory deployed")
+	return nil
+}
 
-	// Wait for transaction to be mined
-	fmt.Println("⏳ Waiting for transaction to be mined...")
-	receipt, err := bind.WaitMined(context.Background(), ci.client, tx)
+// Transact sends any contract method through the transaction pipeline. It returns nil
+// without error in dry-run mode, after printing the simulation.
+func (ci *ContractInteraction) Transact(address common.Address, contractABI *abi.ABI, method string, args []interface{}, value *big.Int) (*txpipe.Result, error) {
+	req := &txpipe.Request{
+		Label:       method,
+		ABI:         contractABI,
+		To:          &address,
+		Method:      method,
+		Args:        args,
+		Value:       value,
+		FallbackGas: gas.DefaultCallGas,
+	}
+	if ci.simulator != nil {
+		return nil, ci.simulateRequest(req)
+	}
+
+	result, err := ci.pipeline.Transact(context.Background(), req)
 	if err != nil {
-		return fmt.Errorf("failed to wait for transaction: %v", err)
+		return result, err
 	}
+	if result.DryRun {
+		return nil, nil
+	}
+	return result, nil
+}
 
-	if receipt.Status == 0 {
-		return fmt.Errorf("decrement transaction failed")
+// Transfer sends ETH from the account through the transaction pipeline. It returns nil
+// without error in dry-run mode, after printing the simulation.
+func (ci *ContractInteraction) Transfer(to common.Address, value *big.Int) (*txpipe.Result, error) {
+	req := &txpipe.Request{
+		Label:       "transfer",
+		To:          &to,
+		Value:       value,
+		FallbackGas: gas.DefaultTransferGas,
+	}
+	if ci.simulator != nil {
+		return nil, ci.simulateRequest(req)
 	}
 
-	fmt.Printf("✅ Counter decremented successfully! Gas used: %d\n", receipt.GasUsed)
-	return nil
+	result, err := ci.pipeline.Transact(context.Background(), req)
+	if err != nil {
+		return result, err
+	}
+	if result.DryRun {
+		return nil, nil
+	}
+	return result, nil
 }
 
-// ResetCount resets the counter to 0
-func (ci *ContractInteraction) ResetCount() error {
-	if ci.instance == nil {
-		return fmt.Errorf("contract instance not initialized")
-	}
+// Address returns the account that signs every write
+func (ci *ContractInteraction) Address() common.Address {
+	return ci.address
+}
 
-	fmt.Println("🔄 Resetting counter...")
+// ContractAddress returns the address of the deployed or loaded Counter
+func (ci *ContractInteraction) ContractAddress() common.Address {
+	return ci.contractAddress
+}
+
+// ResolveContract turns an address, ENS name or contract name recorded in the deployment
+// registry into an address
+func (ci *ContractInteraction) ResolveContract(nameOrAddress string) (common.Address, error) {
+	if !common.IsHexAddress(nameOrAddress) && !ens.IsName(nameOrAddress) {
+		reg, err := registry.Load(registry.DirFromEnv(), ci.chainID)
+		if err != nil {
+			return common.Address{}, err
+		}
+		deployment, ok := reg.Latest(nameOrAddress)
+		if !ok {
+			return common.Address{}, fmt.Errorf("no deployment named %q in %s", nameOrAddress, reg.Path())
+		}
+		fmt.Printf("🗂️  %s → %s (registry, block %d)\n", nameOrAddress, deployment.Address.Hex(), deployment.Block)
+		return deployment.Address, nil
+	}
 
-	// Get the chain ID
-	chainID, err := ci.client.NetworkID(context.Background())
+	resolver := ens.NewResolver(ci.client, ens.DefaultRegistry)
+	address, err := resolver.ResolveAddress(context.Background(), nameOrAddress)
 	if err != nil {
-		return fmt.Errorf("failed to get chain ID: %v", err)
+		return common.Address{}, fmt.Errorf("failed to resolve contract address: %v", err)
 	}
+	if ens.IsName(nameOrAddress) {
+		fmt.Printf("🔎 Resolved %s → %s\n", nameOrAddress, address.Hex())
+	}
+	return address, nil
+}
 
-	// Get the nonce
-	nonce, err := ci.client.PendingNonceAt(context.Background(), ci.address)
+// LoadExistingContract loads an existing contract instance by address, ENS name or
+// contract name recorded in the deployment registry
+func (ci *ContractInteraction) LoadExistingContract(contractAddress string) error {
+	address, err := ci.ResolveContract(contractAddress)
 	if err != nil {
-		return fmt.Errorf("failed to get nonce: %v", err)
+		return err
 	}
 
-	// Get gas price
-	gasPrice, err := ci.client.SuggestGasPrice(context.Background())
+	instance, err := contracts.NewCounter(address, ci.client)
 	if err != nil {
-		return fmt.Errorf("failed to get gas price: %v", err)
+		return fmt.Errorf("failed to create contract instance: %v", err)
 	}
 
-	// Create transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(ci.privateKey, chainID)
+	ci.instance = instance
+	ci.contractAddress = address
+	fmt.Printf("📋 Loaded existing contract at address: %s\n", address.Hex())
+	ci.reportVerification()
+	return nil
+}
+
+// VerifyContract checks that the loaded address runs the compiled-in Counter
+func (ci *ContractInteraction) VerifyContract() (*verify.Result, error) {
+	expected, err := verify.FromCreation("Counter", common.FromHex(contracts.CounterMetaData.Bin))
 	if err != nil {
-		return fmt.Errorf("failed to create transactor: %v", err)
+		return nil, err
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // No ETH sent
-	auth.GasLimit = uint64(100000) // Set gas limit
-	auth.GasPrice = gasPrice
+	return verify.Verify(context.Background(), ci.client, ci.contractAddress, expected)
+}
 
-	// Call the reset function
-	tx, err := ci.instance.Reset(auth)
+// reportVerification prints the bytecode check; a mismatch is a warning, not an error
+func (ci *ContractInteraction) reportVerification() {
+	result, err := ci.VerifyContract()
 	if err != nil {
-		return fmt.Errorf("failed to call reset: %v", err)
+		fmt.Printf("⚠️  Could not verify bytecode: %v\n", err)
+		return
+	}
+	switch result.Status {
+	case verify.Match:
+		fmt.Println("🔍 Bytecode verified: runs the compiled-in Counter")
+	case verify.Partial:
+		fmt.Printf("🔍 Bytecode partially verified: %s\n", result.Reason)
+	default:
+		fmt.Printf("⚠️  Bytecode mismatch: %s is not the compiled-in Counter (%s)\n", ci.contractAddress.Hex(), result.Reason)
 	}
+}
 
-	fmt.Printf("📝 Reset transaction hash: %s\n", tx.Hash().Hex())
+// GetCurrentCount retrieves the current count from the contract
+func (ci *ContractInteraction) GetCurrentCount() (*big.Int, error) {
+	return ci.GetCountAt(blockref.Latest)
+}
 
-	// Wait for transaction to be mined
-	fmt.Println("⏳ Waiting for transaction to be mined...")
-	receipt, err := bind.WaitMined(context.Background(), ci.client, tx)
+// GetCountAt retrieves the count as of the selected block
+func (ci *ContractInteraction) GetCountAt(at blockref.Ref) (*big.Int, error) {
+	if ci.instance == nil {
+		return nil, fmt.Errorf("contract instance not initialized")
+	}
+
+	count, err := ci.instance.GetCount(at.CallOpts(context.Background()))
 	if err != nil {
-		return fmt.Errorf("failed to wait for transaction: %v", err)
+		return nil, fmt.Errorf("failed to get count at %s: %w", at, blockref.Wrap(err, at))
 	}
 
-	if receipt.Status == 0 {
-		return fmt.Errorf("reset transaction failed")
+	return count, nil
+}
+
+// transactCounter sends a Counter write method through the transaction pipeline.
+// The pipeline estimates gas with the exact calldata, so a call that would revert
+// fails with a *gas.RevertError before anything is signed.
+func (ci *ContractInteraction) transactCounter(method string) error {
+	if ci.instance == nil {
+		return fmt.Errorf("contract instance not initialized")
 	}
 
-	fmt.Printf("✅ Counter reset successfully! Gas used: %d\n", receipt.GasUsed)
-	return nil
+	req := &txpipe.Request{
+		Label:       method,
+		ABI:         ci.counterABI,
+		To:          &ci.contractAddress,
+		Method:      method,
+		FallbackGas: gas.DefaultCallGas,
+	}
+	if ci.simulator != nil {
+		return ci.simulateRequest(req)
+	}
+
+	_, err := ci.pipeline.Transact(context.Background(), req)
+	return err
+}
+
+// IncrementCount increments the counter by 1
+func (ci *ContractInteraction) IncrementCount() error {
+	return ci.transactCounter("increment")
+}
+
+// DecrementCount decrements the counter by 1; decrementing zero reverts with
+// "Counter cannot be negative"
+func (ci *ContractInteraction) DecrementCount() error {
+	return ci.transactCounter("decrement")
+}
+
+// ResetCount resets the counter to 0
+func (ci *ContractInteraction) ResetCount() error {
+	return ci.transactCounter("reset")
 }
 
 // GetAccountBalance returns the ETH balance of the account
 func (ci *ContractInteraction) GetAccountBalance() (*big.Int, error) {
-	balance, err := ci.client.BalanceAt(context.Background(), ci.address, nil)
+	return ci.GetAccountBalanceAt(blockref.Latest)
+}
+
+// GetAccountBalanceAt returns the ETH balance of the account as of the selected block
+func (ci *ContractInteraction) GetAccountBalanceAt(at blockref.Ref) (*big.Int, error) {
+	balance, err := blockref.BalanceAt(context.Background(), ci.client, ci.address, at)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get balance: %v", err)
+		return nil, fmt.Errorf("failed to get balance at %s: %w", at, err)
 	}
 	return balance, nil
 }
 
-// Close closes the Ethereum client connection
+// Close closes the Ethereum client connection opened by NewContractInteraction
 func (ci *ContractInteraction) Close() {
-	if ci.client != nil {
-		ci.client.Close()
+	if ci.rpcClient != nil {
+		ci.rpcClient.Close()
 	}
 }
 
-// RunContractDemo demonstrates the complete contract interaction workflow
-func RunContractDemo() error {
+// RunContractDemo demonstrates the complete contract interaction workflow.
+// In dry-run mode nothing is signed; counter writes are simulated against COUNTER_ADDRESS if set,
+// or against an identical Counter from the deployment registry. With force set, the Counter is
+// deployed even if the registry already has one.
+func RunContractDemo(dryRun, force bool) error {
 	// Get configuration from environment variables
-	rpcURL := os.Getenv("SEPOLIA_RPC_URL")
-	if rpcURL == "" {
-		rpcURL = "https://sepolia.infura.io/v3/YOUR_INFURA_PROJECT_ID" // Replace with your Infura project ID
+	profile, err := network.FromEnv()
+	if err != nil {
+		return err
 	}
 
 	privateKeyHex := os.Getenv("PRIVATE_KEY")
@@ -346,18 +658,25 @@ func RunContractDemo() error {
 	}
 
 	// Create contract interaction instance
-	ci, err := NewContractInteraction(rpcURL, privateKeyHex)
+	ci, err := NewContractInteraction(profile, privateKeyHex)
 	if err != nil {
 		return fmt.Errorf("failed to create contract interaction: %v", err)
 	}
 	defer ci.Close()
 
+	if dryRun {
+		ci.EnableDryRun()
+	}
+	if force {
+		ci.ForceDeploy()
+	}
+
 	// Check account balance
 	balance, err := ci.GetAccountBalance()
 	if err != nil {
 		return fmt.Errorf("failed to get balance: %v", err)
 	}
-	fmt.Printf("💰 Account balance: %s ETH\n", balance.String())
+	fmt.Printf("💰 Account balance: %s\n", units.FormatWei(balance, units.Ether, 6))
 
 	// Check if we have enough ETH for gas
 	gasPrice, err := ci.client.SuggestGasPrice(context.Background())
@@ -368,8 +687,8 @@ func RunContractDemo() error {
 
 	if balance.Cmp(requiredBalance) < 0 {
 		fmt.Printf("⚠️  Warning: Low balance. You may need more ETH for gas fees.\n")
-		fmt.Printf("   Current balance: %s ETH\n", balance.String())
-		fmt.Printf("   Estimated required: %s ETH\n", requiredBalance.String())
+		fmt.Printf("   Current balance: %s\n", units.FormatWei(balance, units.Ether, -1))
+		fmt.Printf("   Estimated required: %s\n", units.FormatWei(requiredBalance, units.Ether, -1))
 		fmt.Println("   Get testnet ETH from: https://sepoliafaucet.com/")
 	}
 
@@ -379,6 +698,17 @@ func RunContractDemo() error {
 		return fmt.Errorf("failed to deploy contract: %v", err)
 	}
 
+	if dryRun && ci.instance == nil {
+		counterAddress := os.Getenv("COUNTER_ADDRESS")
+		if counterAddress == "" {
+			fmt.Println("ℹ️  Set COUNTER_ADDRESS to also simulate increment/decrement/reset against an existing deployment")
+			return nil
+		}
+		if err := ci.LoadExistingContract(counterAddress); err != nil {
+			return err
+		}
+	}
+
 	// Wait a moment for the contract to be fully deployed
 	time.Sleep(2 * time.Second)
 
@@ -0,0 +1,161 @@
+package task2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fuckEthereum/src/blockref"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/simchain"
+	"github.com/fuckEthereum/src/units"
+)
+
+// newTestInteraction points deployments, transaction history and the policy audit log at a
+// temporary directory and returns a contract interaction signing with account 0 of a fresh chain
+func newTestInteraction(t *testing.T) (*simchain.Chain, *ContractInteraction) {
+	t.Helper()
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.json")
+	policy := fmt.Sprintf(`{"auditLog": %q}`, filepath.Join(dir, "policy_audit.jsonl"))
+	if err := os.WriteFile(policyFile, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("POLICY_FILE", policyFile)
+	t.Setenv("TX_HISTORY_DB", filepath.Join(dir, "tx_history.db"))
+	t.Setenv("DEPLOYMENTS_DIR", filepath.Join(dir, "deployments"))
+
+	chain, err := simchain.New(2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+
+	ci, err := NewContractInteractionWithBackend(chain.Client(), nil, chain.KeyHex(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain, ci
+}
+
+// newTestCounter also deploys a Counter
+func newTestCounter(t *testing.T) (*simchain.Chain, *ContractInteraction) {
+	t.Helper()
+	chain, ci := newTestInteraction(t)
+	if err := ci.DeployContract(); err != nil {
+		t.Fatal(err)
+	}
+	return chain, ci
+}
+
+func expectCount(t *testing.T, ci *ContractInteraction, want int64) {
+	t.Helper()
+	count, err := ci.GetCurrentCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Cmp(big.NewInt(want)) != 0 {
+		t.Fatalf("count is %s, want %d", count, want)
+	}
+}
+
+func TestDeployContract(t *testing.T) {
+	chain, ci := newTestCounter(t)
+
+	code, err := chain.Client().CodeAt(context.Background(), ci.ContractAddress(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) == 0 {
+		t.Fatalf("no code at %s", ci.ContractAddress().Hex())
+	}
+	expectCount(t, ci, 0)
+}
+
+func TestCounterWrites(t *testing.T) {
+	_, ci := newTestCounter(t)
+
+	steps := []struct {
+		name  string
+		write func() error
+		want  int64
+	}{
+		{"increment", ci.IncrementCount, 1},
+		{"increment again", ci.IncrementCount, 2},
+		{"decrement", ci.DecrementCount, 1},
+		{"increment after decrement", ci.IncrementCount, 2},
+		{"reset", ci.ResetCount, 0},
+	}
+	for _, step := range steps {
+		if err := step.write(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		expectCount(t, ci, step.want)
+	}
+}
+
+func TestDecrementZeroReverts(t *testing.T) {
+	_, ci := newTestCounter(t)
+	before, err := ci.GetAccountBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ci.DecrementCount()
+	var revert *gas.RevertError
+	if !errors.As(err, &revert) {
+		t.Fatalf("got %v, want a revert", err)
+	}
+	if revert.Reason != "Counter cannot be negative" {
+		t.Errorf("revert reason %q", revert.Reason)
+	}
+	expectCount(t, ci, 0)
+
+	// The revert is caught while estimating gas, so nothing was sent or paid for
+	if after, err := ci.GetAccountBalance(); err != nil || after.Cmp(before) != 0 {
+		t.Errorf("balance %v → %v, %v; want unchanged", before, after, err)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	chain, ci := newTestInteraction(t)
+	client := chain.Client()
+	to := chain.Address(1)
+	amount := units.MustParse("1 ether")
+
+	before, err := blockref.BalanceAt(context.Background(), client, to, blockref.Latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ci.Transfer(to, amount.Wei())
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := blockref.BalanceAt(context.Background(), client, to, blockref.Latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received := new(big.Int).Sub(after, before); received.Cmp(amount.Wei()) != 0 {
+		t.Errorf("recipient received %s, want %s", units.FormatWei(received, units.Ether, -1), amount.Format(units.Ether, -1))
+	}
+
+	// The sender paid the value plus the fee of the receipt
+	receipt := result.Receipt
+	fee := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	senderBefore, err := ci.GetAccountBalanceAt(blockref.Number(receipt.BlockNumber.Uint64() - 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	senderAfter, err := ci.GetAccountBalanceAt(blockref.Hash(receipt.BlockHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spent := new(big.Int).Sub(senderBefore, senderAfter)
+	if want := new(big.Int).Add(amount.Wei(), fee); spent.Cmp(want) != 0 {
+		t.Errorf("sender spent %s, want %s", spent, want)
+	}
+}
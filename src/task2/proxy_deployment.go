@@ -0,0 +1,329 @@
+package task2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fuckEthereum/src/artifact"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/proxy"
+	"github.com/fuckEthereum/src/registry"
+	"github.com/fuckEthereum/src/txpipe"
+)
+
+// ProxyInit is the initializer called through the proxy after a deploy or upgrade;
+// an empty Method calls nothing
+type ProxyInit struct {
+	Method string
+	Args   []interface{}
+}
+
+func (init ProxyInit) calldata(contractABI *abi.ABI) ([]byte, error) {
+	if init.Method == "" {
+		return []byte{}, nil
+	}
+	data, err := contractABI.Pack(init.Method, init.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s: %v", init.Method, err)
+	}
+	return data, nil
+}
+
+// implementationName is how implementations are recorded, so the contract name resolves to the proxy
+func implementationName(name string) string {
+	return name + "Implementation"
+}
+
+// DeployProxy deploys an implementation and an ERC-1967 proxy in front of it, calling init
+// through the proxy. A zero admin makes this account the admin of a transparent proxy.
+// It returns nil without error in dry-run mode when the implementation is not deployed yet.
+func (ci *ContractInteraction) DeployProxy(art *artifact.Artifact, libs map[string]common.Address, kind proxy.Kind, admin common.Address, init ProxyInit) (*proxy.Info, error) {
+	ctx := context.Background()
+	fmt.Printf("🚀 Deploying %s behind a %s proxy...\n", art.Name, kind)
+
+	data, err := init.calldata(&art.ABI)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := artifactLayout(art); err != nil {
+		return nil, err
+	}
+	if len(art.StorageLayout) == 0 {
+		fmt.Println("⚠️  No storage layout in the artifact; the next upgrade cannot be checked without one")
+	}
+
+	impl, err := ci.deployImplementation(art, libs)
+	if err != nil {
+		return nil, err
+	}
+	if impl == (common.Address{}) {
+		fmt.Println("ℹ️  Dry run stops here: the proxy needs the implementation's address")
+		return nil, nil
+	}
+	if kind == proxy.UUPS {
+		if err := ci.checkUUPS(ctx, impl); err != nil {
+			return nil, err
+		}
+	}
+
+	args := []interface{}{impl, data}
+	if kind == proxy.Transparent {
+		if admin == (common.Address{}) {
+			admin = ci.address
+		}
+		args = []interface{}{impl, admin, data}
+	}
+	address, err := ci.deploy(registry.Deployment{Name: art.Name, Proxy: string(kind), Implementation: &impl}, &txpipe.Request{
+		Label:       "deploy " + art.Name + " proxy",
+		ABI:         proxy.ABI(kind),
+		Args:        args,
+		Bytecode:    proxy.CreationCode(kind),
+		FallbackGas: gas.DefaultDeployGas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy proxy: %v", err)
+	}
+	if address == (common.Address{}) {
+		return nil, nil
+	}
+
+	info, err := proxy.Inspect(ctx, ci.client, address)
+	if err != nil {
+		return nil, err
+	}
+	PrintProxyInfo(info)
+	if info.Admin == ci.address {
+		fmt.Println("ℹ️  This account is the proxy admin: its calls are handled by the proxy, use another account to call the implementation")
+	}
+	return info, nil
+}
+
+// UpgradeProxy deploys a new implementation and points the proxy at it, after checking that
+// its storage layout is compatible with the current implementation. current overrides the
+// layout recorded in the registry for the current implementation. In dry-run mode the upgrade
+// call is simulated when the new implementation already exists.
+func (ci *ContractInteraction) UpgradeProxy(address common.Address, art *artifact.Artifact, libs map[string]common.Address, init ProxyInit, current *proxy.StorageLayout, unsafeSkipLayoutCheck bool) (*proxy.Info, error) {
+	ctx := context.Background()
+
+	info, err := proxy.Inspect(ctx, ci.client, address)
+	if err != nil {
+		return nil, err
+	}
+	PrintProxyInfo(info)
+
+	reg, err := registry.Load(registry.DirFromEnv(), ci.chainID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		if recorded := reg.Find(info.Implementation); recorded != nil && len(recorded.StorageLayout) > 0 {
+			if current, err = proxy.ParseLayout(recorded.StorageLayout); err != nil {
+				return nil, err
+			}
+		}
+	}
+	next, err := artifactLayout(art)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUpgradeLayout(current, next, unsafeSkipLayoutCheck); err != nil {
+		return nil, err
+	}
+
+	data, err := init.calldata(&art.ABI)
+	if err != nil {
+		return nil, err
+	}
+	impl, err := ci.deployImplementation(art, libs)
+	if err != nil {
+		return nil, err
+	}
+	if impl == (common.Address{}) {
+		fmt.Println("ℹ️  Dry run stops here: the upgrade needs the new implementation's address")
+		return info, nil
+	}
+	if impl == info.Implementation {
+		fmt.Printf("⏭️  %s already points at %s\n", address.Hex(), impl.Hex())
+		return info, nil
+	}
+
+	req, err := ci.upgradeRequest(ctx, info, art.Name, impl, data)
+	if err != nil {
+		return nil, err
+	}
+	if ci.simulator != nil {
+		return info, ci.simulateRequest(req)
+	}
+	result, err := ci.pipeline.Transact(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if result.DryRun {
+		return info, nil
+	}
+
+	upgraded, err := proxy.Inspect(ctx, ci.client, address)
+	if err != nil {
+		return nil, err
+	}
+	if upgraded.Implementation != impl {
+		return upgraded, fmt.Errorf("upgrade %s was mined but the implementation slot holds %s, not %s",
+			result.Tx.Hash().Hex(), upgraded.Implementation.Hex(), impl.Hex())
+	}
+	fmt.Printf("✅ %s upgraded: %s → %s\n", address.Hex(), info.Implementation.Hex(), impl.Hex())
+
+	// deployImplementation saved the registry, so reload before updating the proxy entry
+	if reg, err = registry.Load(registry.DirFromEnv(), ci.chainID); err == nil {
+		if recorded := reg.Find(address); recorded != nil {
+			recorded.Implementation = &impl
+			err = reg.Save()
+		}
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Failed to record upgrade: %v\n", err)
+	}
+	return upgraded, nil
+}
+
+// InspectProxy reads the EIP-1967 slots of a proxy
+func (ci *ContractInteraction) InspectProxy(address common.Address) (*proxy.Info, error) {
+	return proxy.Inspect(context.Background(), ci.client, address)
+}
+
+// deployImplementation deploys (or reuses) an implementation, recording its storage layout
+func (ci *ContractInteraction) deployImplementation(art *artifact.Artifact, libs map[string]common.Address) (common.Address, error) {
+	bytecode, err := art.Link(libs)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to link %s: %v", art.Name, err)
+	}
+	name := implementationName(art.Name)
+	impl, err := ci.deploy(registry.Deployment{Name: name, StorageLayout: art.StorageLayout}, &txpipe.Request{
+		Label:       "deploy " + name,
+		ABI:         &art.ABI,
+		Bytecode:    bytecode,
+		FallbackGas: gas.DefaultDeployGas,
+	})
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to deploy implementation: %v", err)
+	}
+	return impl, nil
+}
+
+// upgradeRequest builds the upgrade call for the proxy's pattern: UUPS proxies are upgraded
+// through the implementation, transparent proxies by their admin, either this account or an
+// OpenZeppelin ProxyAdmin it owns
+func (ci *ContractInteraction) upgradeRequest(ctx context.Context, info *proxy.Info, name string, impl common.Address, data []byte) (*txpipe.Request, error) {
+	req := &txpipe.Request{
+		Label:       "upgrade " + name,
+		To:          &info.Address,
+		Method:      "upgradeToAndCall",
+		Args:        []interface{}{impl, data},
+		FallbackGas: gas.DefaultCallGas,
+	}
+
+	switch info.Admin {
+	case common.Address{}:
+		if err := ci.checkUUPS(ctx, impl); err != nil {
+			return nil, err
+		}
+		req.ABI = proxy.UUPSABI
+	case ci.address:
+		req.ABI = proxy.TransparentABI
+	default:
+		admin := bind.NewBoundContract(info.Admin, *proxy.ProxyAdminABI, ci.client, ci.client, ci.client)
+		var out []interface{}
+		if err := admin.Call(&bind.CallOpts{Context: ctx}, &out, "owner"); err != nil {
+			return nil, fmt.Errorf("proxy admin %s is neither this account nor a ProxyAdmin: %v", info.Admin.Hex(), err)
+		}
+		if owner := *abi.ConvertType(out[0], new(common.Address)).(*common.Address); owner != ci.address {
+			return nil, fmt.Errorf("proxy admin %s is owned by %s, not this account", info.Admin.Hex(), owner.Hex())
+		}
+		req.ABI = proxy.ProxyAdminABI
+		req.To = &info.Admin
+		req.Method = "upgradeAndCall"
+		req.Args = []interface{}{info.Address, impl, data}
+	}
+	return req, nil
+}
+
+// checkUUPS makes sure an implementation can upgrade a UUPS proxy, so the proxy does not get stuck
+func (ci *ContractInteraction) checkUUPS(ctx context.Context, impl common.Address) error {
+	contract := bind.NewBoundContract(impl, *proxy.UUPSABI, ci.client, ci.client, ci.client)
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "proxiableUUID"); err != nil {
+		return fmt.Errorf("%w: %s has no proxiableUUID(): %v", proxy.ErrNotUUPS, impl.Hex(), err)
+	}
+	if uuid := common.Hash(out[0].([32]byte)); uuid != proxy.ImplementationSlot {
+		return fmt.Errorf("%w: %s reports proxiableUUID %s", proxy.ErrNotUUPS, impl.Hex(), uuid.Hex())
+	}
+	return nil
+}
+
+// artifactLayout parses the storage layout carried by an artifact, nil if it has none
+func artifactLayout(art *artifact.Artifact) (*proxy.StorageLayout, error) {
+	if len(art.StorageLayout) == 0 || bytes.Equal(art.StorageLayout, []byte("null")) {
+		art.StorageLayout = nil
+		return nil, nil
+	}
+	layout, err := proxy.ParseLayout(art.StorageLayout)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", art.Name, err)
+	}
+	// Store compacted in the registry
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, art.StorageLayout); err == nil {
+		art.StorageLayout = compact.Bytes()
+	}
+	return layout, nil
+}
+
+// checkUpgradeLayout refuses upgrades with unknown or incompatible storage layouts unless skipped
+func checkUpgradeLayout(current, next *proxy.StorageLayout, unsafeSkip bool) error {
+	if current == nil || next == nil {
+		which := "current"
+		if current != nil {
+			which = "new"
+		}
+		if unsafeSkip {
+			fmt.Printf("⚠️  Storage layout of the %s implementation is unknown, skipping the compatibility check\n", which)
+			return nil
+		}
+		return fmt.Errorf("%w: storage layout of the %s implementation is unknown; provide it or skip the check explicitly",
+			proxy.ErrIncompatibleLayout, which)
+	}
+
+	issues := proxy.CheckLayout(current, next)
+	for _, issue := range issues {
+		icon := "⚠️ "
+		if issue.Severity == proxy.Error {
+			icon = "❌"
+		}
+		fmt.Printf("   %s %s\n", icon, issue.Message)
+	}
+	if !proxy.HasErrors(issues) {
+		fmt.Println("✅ Storage layout is compatible")
+		return nil
+	}
+	if unsafeSkip {
+		fmt.Println("⚠️  Storage layout is incompatible, upgrading anyway")
+		return nil
+	}
+	return fmt.Errorf("%w: see the problems above", proxy.ErrIncompatibleLayout)
+}
+
+// PrintProxyInfo shows the EIP-1967 slots of a proxy
+func PrintProxyInfo(info *proxy.Info) {
+	fmt.Printf("🔀 %s proxy %s\n", info.Kind(), info.Address.Hex())
+	fmt.Printf("   Implementation: %s\n", info.Implementation.Hex())
+	if info.Admin != (common.Address{}) {
+		fmt.Printf("   Admin: %s\n", info.Admin.Hex())
+	}
+	if info.Beacon != (common.Address{}) {
+		fmt.Printf("   Beacon: %s\n", info.Beacon.Hex())
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"os"
 )
 
-// RunTask2 demonstrates abigen usage for smart contract interaction
-func RunTask2() error {
+// RunTask2 demonstrates abigen usage for smart contract interaction.
+// With dryRun set, every write is simulated and nothing is signed; with force set, the
+// Counter is redeployed even if the deployment registry has an identical one.
+func RunTask2(dryRun, force bool) error {
 	fmt.Println("🚀 Starting Task 2: Abigen Smart Contract Interaction Demo")
 	fmt.Println("============================================================")
 
@@ -21,7 +23,7 @@ func RunTask2() error {
 		return fmt.Errorf("PRIVATE_KEY not set")
 	}
 
-	if os.Getenv("SEPOLIA_RPC_URL") == "" {
+	if os.Getenv("SEPOLIA_RPC_URL") == "" && os.Getenv("ETH_RPC_URL") == "" {
 		fmt.Println("⚠️  Warning: SEPOLIA_RPC_URL not set, using default Infura URL")
 		fmt.Println("For better performance, set your own RPC URL:")
 		fmt.Println("  export SEPOLIA_RPC_URL=https://sepolia.infura.io/v3/YOUR_PROJECT_ID")
@@ -29,7 +31,7 @@ func RunTask2() error {
 	}
 
 	// Run the contract interaction demo
-	err := RunContractDemo()
+	err := RunContractDemo(dryRun, force)
 	if err != nil {
 		return fmt.Errorf("contract demo failed: %v", err)
 	}
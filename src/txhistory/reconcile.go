@@ -0,0 +1,105 @@
+package txhistory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Checker looks up the current status of a transaction on chain.
+// found is false when the node knows nothing about the hash.
+type Checker func(ctx context.Context, hash string) (update Update, found bool, err error)
+
+// Connector opens a Checker for one reconcile pass; close releases its connection
+type Connector func(ctx context.Context) (check Checker, close func(), err error)
+
+// Reconciler periodically re-checks pending transactions
+type Reconciler struct {
+	path      string
+	connect   Connector
+	dropAfter time.Duration
+}
+
+// NewReconciler creates a reconciler; transactions unknown to the node for longer
+// than dropAfter are marked as dropped
+func NewReconciler(path string, connect Connector, dropAfter time.Duration) *Reconciler {
+	return &Reconciler{
+		path:      path,
+		connect:   connect,
+		dropAfter: dropAfter,
+	}
+}
+
+// pending lists pending records and closes the store again, since BoltDB locks it exclusively
+func (r *Reconciler) pending() ([]*Record, error) {
+	store, err := Open(r.path)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	return store.Query(Filter{Status: StatusPending})
+}
+
+// Once checks every pending transaction a single time and returns how many changed status.
+// It connects once for the whole pass, and not at all when nothing is pending.
+func (r *Reconciler) Once(ctx context.Context) (int, error) {
+	records, err := r.pending()
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	check, closeChecker, err := r.connect(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer closeChecker()
+
+	changed := 0
+	for _, rec := range records {
+		update, found, err := check(ctx, rec.Hash)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to check %s: %v\n", rec.Hash, err)
+			continue
+		}
+
+		if !found {
+			if r.dropAfter <= 0 || time.Since(rec.CreatedAt) < r.dropAfter {
+				continue
+			}
+			update = Update{Status: StatusDropped}
+		}
+		if update.Status == rec.Status {
+			continue
+		}
+
+		if err := Observe(r.path, rec.Hash, update); err != nil {
+			return changed, err
+		}
+		fmt.Printf("🔄 %s: %s → %s\n", rec.Hash, rec.Status, update.Status)
+		changed++
+	}
+	return changed, nil
+}
+
+// Run reconciles every interval until ctx is cancelled
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Once(ctx); err != nil {
+			fmt.Printf("⚠️  Reconciliation failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Start runs the reconciler in a background goroutine
+func (r *Reconciler) Start(ctx context.Context, interval time.Duration) {
+	go r.Run(ctx, interval)
+}
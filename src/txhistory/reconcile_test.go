@@ -0,0 +1,199 @@
+package txhistory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeNode answers checks from a map of hash to update; hashes not in the map are unknown.
+// Hashes may be given in short form, e.g. "0x01".
+type fakeNode struct {
+	updates map[string]Update
+	failing map[string]bool
+	dials   int
+	closed  int
+	checked []string
+}
+
+func (n *fakeNode) connect(ctx context.Context) (Checker, func(), error) {
+	n.dials++
+	check := func(ctx context.Context, hash string) (Update, bool, error) {
+		n.checked = append(n.checked, hash)
+		for failing := range n.failing {
+			if common.HexToHash(failing) == common.HexToHash(hash) {
+				return Update{}, false, errors.New("connection reset")
+			}
+		}
+		for known, update := range n.updates {
+			if common.HexToHash(known) == common.HexToHash(hash) {
+				return update, true, nil
+			}
+		}
+		return Update{}, false, nil
+	}
+	return check, func() { n.closed++ }, nil
+}
+
+func TestReconcilerOnce(t *testing.T) {
+	store, path := openTestStore(t)
+	now := time.Now().UTC()
+	records := []struct {
+		hash      string
+		status    string
+		createdAt time.Time
+	}{
+		{"0x01", StatusPending, now.Add(-time.Minute)}, // mined meanwhile
+		{"0x02", StatusPending, now.Add(-time.Minute)}, // still in the mempool
+		{"0x03", StatusPending, now.Add(-time.Minute)}, // unknown, but too recent to drop
+		{"0x04", StatusPending, now.Add(-2 * time.Hour)},
+		{"0x05", StatusPending, now.Add(-time.Minute)}, // the node fails to answer
+		{"0x06", StatusSuccess, now.Add(-2 * time.Hour)},
+	}
+	for _, r := range records {
+		rec := testRecord(r.hash, alice, bob, r.createdAt)
+		rec.Status = r.status
+		if err := store.Add(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Close()
+
+	node := &fakeNode{
+		updates: map[string]Update{
+			"0x01": {Status: StatusSuccess, BlockNumber: 5, GasUsed: 21000},
+			"0x02": {Status: StatusPending},
+			"0x06": {Status: StatusFailed},
+		},
+		failing: map[string]bool{"0x05": true},
+	}
+	reconciler := NewReconciler(path, node.connect, time.Hour)
+	ctx := context.Background()
+
+	changed, err := reconciler.Once(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 {
+		t.Errorf("changed %d, want 2", changed)
+	}
+	// Only pending transactions are checked, over a single connection
+	if node.dials != 1 || node.closed != 1 {
+		t.Errorf("dialled %d times and closed %d, want once each", node.dials, node.closed)
+	}
+	if len(node.checked) != 5 {
+		t.Errorf("checked %v, want the five pending transactions", node.checked)
+	}
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"0x01": "PENDING SUCCESS",
+		"0x02": "PENDING",
+		"0x03": "PENDING",
+		"0x04": "PENDING DROPPED",
+		"0x05": "PENDING",
+		"0x06": "SUCCESS",
+	}
+	for hash, transitions := range want {
+		rec, err := store.Get(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(statuses(rec), " "); got != transitions {
+			t.Errorf("%s: transitions %s, want %s", hash, got, transitions)
+		}
+	}
+	mined, err := store.Get("0x01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mined.BlockNumber != 5 || mined.GasUsed != 21000 {
+		t.Errorf("mined record %+v, want the receipt applied", mined)
+	}
+	store.Close()
+
+	// A second pass finds nothing new to change
+	changed, err = reconciler.Once(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 0 || node.dials != 2 {
+		t.Errorf("second pass changed %d after %d dials, want 0 after 2", changed, node.dials)
+	}
+}
+
+func TestReconcilerDropAfter(t *testing.T) {
+	tests := []struct {
+		name      string
+		dropAfter time.Duration
+		age       time.Duration
+		want      string
+	}{
+		{"older than dropAfter", time.Hour, 2 * time.Hour, StatusDropped},
+		{"younger than dropAfter", time.Hour, 30 * time.Minute, StatusPending},
+		{"dropping disabled", 0, 24 * time.Hour, StatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, path := openTestStore(t)
+			if err := store.Add(testRecord("0x01", alice, bob, time.Now().UTC().Add(-tt.age))); err != nil {
+				t.Fatal(err)
+			}
+			store.Close()
+
+			node := &fakeNode{}
+			if _, err := NewReconciler(path, node.connect, tt.dropAfter).Once(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			store, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			rec, err := store.Get(common.HexToHash("0x01").Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.Status != tt.want {
+				t.Errorf("status %s, want %s", rec.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcilerSkipsConnectingWhenNothingIsPending(t *testing.T) {
+	store, path := openTestStore(t)
+	rec := testRecord("0x01", alice, bob, time.Now().UTC())
+	rec.Status = StatusSuccess
+	if err := store.Add(rec); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	reconciler := NewReconciler(path, func(ctx context.Context) (Checker, func(), error) {
+		return nil, nil, errors.New("node unreachable")
+	}, time.Hour)
+	if changed, err := reconciler.Once(context.Background()); err != nil || changed != 0 {
+		t.Fatalf("got %d, %v, want no work and no connection", changed, err)
+	}
+
+	pending := testRecord("0x02", alice, bob, time.Now().UTC())
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(pending); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	if _, err := reconciler.Once(context.Background()); err == nil || err.Error() != "node unreachable" {
+		t.Fatalf("got %v, want the connection error", err)
+	}
+}
@@ -0,0 +1,146 @@
+package txhistory
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/src/txpipe"
+)
+
+// NewRecord builds a pending record from a signed transaction
+func NewRecord(tx *types.Transaction, label string) (*Record, error) {
+	signer := types.LatestSignerForChainID(tx.ChainId())
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %v", err)
+	}
+
+	rec := &Record{
+		Hash:     tx.Hash().Hex(),
+		ChainID:  tx.ChainId().String(),
+		From:     from.Hex(),
+		Value:    tx.Value().String(),
+		Nonce:    tx.Nonce(),
+		GasLimit: tx.Gas(),
+		GasPrice: tx.GasFeeCap().String(),
+		Label:    label,
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		rec.GasTipCap = tx.GasTipCap().String()
+	}
+	if tx.To() != nil {
+		rec.To = tx.To().Hex()
+	}
+	return rec, nil
+}
+
+// RecordSent opens the store at path, records tx as pending and closes it again
+func RecordSent(path string, tx *types.Transaction, label string) error {
+	rec, err := NewRecord(tx, label)
+	if err != nil {
+		return err
+	}
+
+	store, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return store.Add(rec)
+}
+
+// Observe records a status observation for a hash; hashes the tool did not send are ignored
+func Observe(path string, hash string, update Update) error {
+	store, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := store.UpdateStatus(hash, update); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// ReceiptUpdate converts a receipt into a status update
+func ReceiptUpdate(receipt *types.Receipt) Update {
+	update := Update{
+		Status:      StatusSuccess,
+		GasUsed:     receipt.GasUsed,
+		BlockNumber: receipt.BlockNumber.Uint64(),
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		update.Status = StatusFailed
+	}
+	if receipt.EffectiveGasPrice != nil {
+		update.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+		fee := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		update.Fee = fee.String()
+	}
+	return update
+}
+
+// Hooks returns pipeline hooks that record every sent transaction and its outcome
+func Hooks(path string) txpipe.Hooks {
+	return txpipe.Hooks{
+		Sent: func(req *txpipe.Request, tx *types.Transaction) {
+			if err := RecordSent(path, tx, req.Label); err != nil {
+				fmt.Printf("⚠️  Failed to record %s in history: %v\n", tx.Hash().Hex(), err)
+			}
+		},
+		Mined: func(req *txpipe.Request, result *txpipe.Result) {
+			if err := Observe(path, result.Tx.Hash().Hex(), ReceiptUpdate(result.Receipt)); err != nil {
+				fmt.Printf("⚠️  Failed to update history for %s: %v\n", result.Tx.Hash().Hex(), err)
+			}
+		},
+	}
+}
+
+// csvHeader lists the exported columns in order
+var csvHeader = []string{
+	"hash", "chain_id", "from", "to", "value_wei", "nonce", "gas_limit", "gas_price_wei",
+	"gas_used", "effective_gas_price_wei", "fee_wei", "label", "status", "block_number",
+	"created_at", "updated_at",
+}
+
+// WriteCSV exports records as CSV with a header row
+func WriteCSV(w io.Writer, records []*Record) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		row := []string{
+			rec.Hash,
+			rec.ChainID,
+			rec.From,
+			rec.To,
+			rec.Value,
+			strconv.FormatUint(rec.Nonce, 10),
+			strconv.FormatUint(rec.GasLimit, 10),
+			rec.GasPrice,
+			strconv.FormatUint(rec.GasUsed, 10),
+			rec.EffectiveGasPrice,
+			rec.Fee,
+			rec.Label,
+			rec.Status,
+			strconv.FormatUint(rec.BlockNumber, 10),
+			rec.CreatedAt.Format(time.RFC3339),
+			rec.UpdatedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,249 @@
+package txhistory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultPath is where the history database lives unless TX_HISTORY_DB is set
+const DefaultPath = "data/txhistory.db"
+
+// Transaction statuses
+const (
+	StatusPending = "PENDING"
+	StatusSuccess = "SUCCESS"
+	StatusFailed  = "FAILED"
+	StatusDropped = "DROPPED"
+)
+
+// ErrNotFound is returned when a hash is not in the history
+var ErrNotFound = errors.New("transaction not in history")
+
+var txBucket = []byte("transactions")
+
+// Transition records when a transaction was observed in a status
+type Transition struct {
+	Status      string    `json:"status"`
+	At          time.Time `json:"at"`
+	BlockNumber uint64    `json:"blockNumber,omitempty"`
+}
+
+// Record is one transaction sent by the tool
+type Record struct {
+	Hash              string       `json:"hash"`
+	ChainID           string       `json:"chainId"`
+	From              string       `json:"from"`
+	To                string       `json:"to,omitempty"` // empty for contract creation
+	Value             string       `json:"value"`        // wei
+	Nonce             uint64       `json:"nonce"`
+	GasLimit          uint64       `json:"gasLimit"`
+	GasPrice          string       `json:"gasPrice,omitempty"`  // wei, or max fee per gas
+	GasTipCap         string       `json:"gasTipCap,omitempty"` // wei, EIP-1559 only
+	GasUsed           uint64       `json:"gasUsed,omitempty"`
+	EffectiveGasPrice string       `json:"effectiveGasPrice,omitempty"`
+	Fee               string       `json:"fee,omitempty"` // wei actually paid
+	Label             string       `json:"label,omitempty"`
+	Status            string       `json:"status"`
+	BlockNumber       uint64       `json:"blockNumber,omitempty"`
+	CreatedAt         time.Time    `json:"createdAt"`
+	UpdatedAt         time.Time    `json:"updatedAt"`
+	Transitions       []Transition `json:"transitions"`
+}
+
+// Store is a BoltDB-backed transaction history
+type Store struct {
+	db *bolt.DB
+}
+
+// PathFromEnv returns TX_HISTORY_DB or DefaultPath
+func PathFromEnv() string {
+	if path := os.Getenv("TX_HISTORY_DB"); path != "" {
+		return path
+	}
+	return DefaultPath
+}
+
+// Open opens (creating if needed) the history database.
+// BoltDB holds an exclusive lock, so callers should close the store promptly.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %v", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(txBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialise history database: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func key(hash string) []byte {
+	return []byte(strings.ToLower(common.HexToHash(hash).Hex()))
+}
+
+// Add stores a newly sent transaction as pending
+func (s *Store) Add(rec *Record) error {
+	now := time.Now().UTC()
+	if rec.Status == "" {
+		rec.Status = StatusPending
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = now
+	}
+	rec.UpdatedAt = now
+	rec.Hash = common.HexToHash(rec.Hash).Hex()
+	if len(rec.Transitions) == 0 {
+		rec.Transitions = []Transition{{Status: rec.Status, At: now}}
+	}
+	return s.put(rec)
+}
+
+// Get returns the record for a hash
+func (s *Store) Get(hash string) (*Record, error) {
+	var rec *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(txBucket).Get(key(hash))
+		if data == nil {
+			return ErrNotFound
+		}
+		rec = new(Record)
+		return json.Unmarshal(data, rec)
+	})
+	return rec, err
+}
+
+// Update describes what was observed about a transaction
+type Update struct {
+	Status            string
+	BlockNumber       uint64
+	GasUsed           uint64
+	EffectiveGasPrice string
+	Fee               string
+}
+
+// UpdateStatus applies an observation; a transition is appended only when the status changes.
+// Unknown hashes return ErrNotFound.
+func (s *Store) UpdateStatus(hash string, update Update) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(txBucket)
+		data := bucket.Get(key(hash))
+		if data == nil {
+			return ErrNotFound
+		}
+
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if update.Status != rec.Status {
+			rec.Transitions = append(rec.Transitions, Transition{Status: update.Status, At: now, BlockNumber: update.BlockNumber})
+			rec.Status = update.Status
+		}
+		if update.BlockNumber != 0 {
+			rec.BlockNumber = update.BlockNumber
+		}
+		if update.GasUsed != 0 {
+			rec.GasUsed = update.GasUsed
+		}
+		if update.EffectiveGasPrice != "" {
+			rec.EffectiveGasPrice = update.EffectiveGasPrice
+		}
+		if update.Fee != "" {
+			rec.Fee = update.Fee
+		}
+		rec.UpdatedAt = now
+
+		encoded, err := json.Marshal(&rec)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key(hash), encoded)
+	})
+}
+
+func (s *Store) put(rec *Record) error {
+	encoded, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %v", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(txBucket).Put(key(rec.Hash), encoded)
+	})
+}
+
+// Filter selects records; zero fields match everything
+type Filter struct {
+	Account string    // matches From or To
+	Status  string    // exact status
+	Since   time.Time // CreatedAt >= Since
+	Until   time.Time // CreatedAt < Until
+}
+
+func (f Filter) matches(rec *Record) bool {
+	if f.Account != "" {
+		account := common.HexToAddress(f.Account)
+		if !strings.EqualFold(rec.From, account.Hex()) && !strings.EqualFold(rec.To, account.Hex()) {
+			return false
+		}
+	}
+	if f.Status != "" && !strings.EqualFold(rec.Status, f.Status) {
+		return false
+	}
+	if !f.Since.IsZero() && rec.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !rec.CreatedAt.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Query returns matching records, oldest first
+func (s *Store) Query(filter Filter) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(txBucket).ForEach(func(k, v []byte) error {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("corrupt record %s: %v", k, err)
+			}
+			if filter.matches(&rec) {
+				records = append(records, &rec)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records, nil
+}
@@ -0,0 +1,214 @@
+package txhistory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	alice = "0x00000000000000000000000000000000000A11cE"
+	bob   = "0x0000000000000000000000000000000000000b0b"
+)
+
+var day = time.Date(2025, 8, 20, 12, 0, 0, 0, time.UTC)
+
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history", "tx_history.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, path
+}
+
+func testRecord(hash, from, to string, createdAt time.Time) *Record {
+	return &Record{
+		Hash:      hash,
+		ChainID:   "1337",
+		From:      from,
+		To:        to,
+		Value:     "1000",
+		Nonce:     7,
+		GasLimit:  21000,
+		GasPrice:  "2000000000",
+		Label:     "transfer",
+		CreatedAt: createdAt,
+	}
+}
+
+func statuses(rec *Record) []string {
+	var out []string
+	for _, transition := range rec.Transitions {
+		out = append(out, transition.Status)
+	}
+	return out
+}
+
+func TestUpdateStatus(t *testing.T) {
+	store, _ := openTestStore(t)
+	if err := store.Add(testRecord("0x01", alice, bob, day)); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		update      Update
+		transitions string
+		block       uint64
+		gasUsed     uint64
+		fee         string
+	}{
+		// Seen in the mempool again: no new transition
+		{Update{Status: StatusPending}, "PENDING", 0, 0, ""},
+		{Update{Status: StatusSuccess, BlockNumber: 10, GasUsed: 21000, EffectiveGasPrice: "2", Fee: "42000"}, "PENDING SUCCESS", 10, 21000, "42000"},
+		// Observing the same receipt twice keeps a single transition
+		{Update{Status: StatusSuccess, BlockNumber: 10, GasUsed: 21000}, "PENDING SUCCESS", 10, 21000, "42000"},
+		// A reorg moved the transaction back to pending; zero fields keep the old values
+		{Update{Status: StatusPending}, "PENDING SUCCESS PENDING", 10, 21000, "42000"},
+		{Update{Status: StatusFailed, BlockNumber: 12, GasUsed: 30000, Fee: "60000"}, "PENDING SUCCESS PENDING FAILED", 12, 30000, "60000"},
+	}
+	for i, step := range steps {
+		if err := store.UpdateStatus("0x01", step.update); err != nil {
+			t.Fatal(err)
+		}
+		rec, err := store.Get("0x01")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(statuses(rec), " "); got != step.transitions {
+			t.Fatalf("step %d: transitions %s, want %s", i, got, step.transitions)
+		}
+		if rec.Status != step.update.Status || rec.BlockNumber != step.block || rec.GasUsed != step.gasUsed || rec.Fee != step.fee {
+			t.Fatalf("step %d: record %+v", i, rec)
+		}
+		if !rec.CreatedAt.Equal(day) || rec.UpdatedAt.Before(rec.CreatedAt) {
+			t.Fatalf("step %d: created %s, updated %s", i, rec.CreatedAt, rec.UpdatedAt)
+		}
+	}
+
+	rec, err := store.Get("0x01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := rec.Transitions[len(rec.Transitions)-1]; last.BlockNumber != 12 {
+		t.Errorf("last transition in block %d, want 12", last.BlockNumber)
+	}
+	if rec.EffectiveGasPrice != "2" {
+		t.Errorf("effective gas price %q, want it kept from the first receipt", rec.EffectiveGasPrice)
+	}
+
+	if err := store.UpdateStatus("0x02", Update{Status: StatusSuccess}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown hash: got %v, want ErrNotFound", err)
+	}
+	if _, err := store.Get("0x02"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown hash: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestObserveIgnoresUnknownHashes(t *testing.T) {
+	store, path := openTestStore(t)
+	store.Close()
+	if err := Observe(path, "0x03", Update{Status: StatusSuccess}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	sent := testRecord("0x01", alice, bob, day)
+	sent.Status = StatusSuccess
+	deployed := testRecord("0x02", bob, "", day.Add(time.Hour))
+	deployed.Status = StatusPending
+
+	tests := []struct {
+		name   string
+		filter Filter
+		sent   bool
+		deploy bool
+	}{
+		{"everything", Filter{}, true, true},
+		{"sender", Filter{Account: alice}, true, false},
+		{"recipient", Filter{Account: bob}, true, true},
+		{"lowercase account", Filter{Account: strings.ToLower(alice)}, true, false},
+		{"other account", Filter{Account: "0x0000000000000000000000000000000000000001"}, false, false},
+		{"status", Filter{Status: StatusPending}, false, true},
+		{"status in lowercase", Filter{Status: "success"}, true, false},
+		{"since is inclusive", Filter{Since: day.Add(time.Hour)}, false, true},
+		{"until is exclusive", Filter{Until: day.Add(time.Hour)}, true, false},
+		{"date range", Filter{Since: day.Add(-time.Hour), Until: day.Add(2 * time.Hour)}, true, true},
+		{"empty range", Filter{Since: day.Add(time.Minute), Until: day.Add(time.Hour)}, false, false},
+		{"combined", Filter{Account: bob, Status: StatusSuccess, Since: day}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(sent); got != tt.sent {
+				t.Errorf("matches sent transaction = %v, want %v", got, tt.sent)
+			}
+			if got := tt.filter.matches(deployed); got != tt.deploy {
+				t.Errorf("matches deployment = %v, want %v", got, tt.deploy)
+			}
+		})
+	}
+}
+
+func TestQueryOrdersByCreation(t *testing.T) {
+	store, _ := openTestStore(t)
+	for i, hash := range []string{"0x0c", "0x0a", "0x0b"} {
+		if err := store.Add(testRecord(hash, alice, bob, day.Add(time.Duration(2-i)*time.Minute))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := store.Query(Filter{Since: day.Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Hash != common.HexToHash("0x0a").Hex() || records[1].Hash != common.HexToHash("0x0c").Hex() {
+		t.Fatalf("query returned %v", records)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	mined := testRecord("0x01", alice, bob, day)
+	mined.Status = StatusSuccess
+	mined.GasUsed = 21000
+	mined.EffectiveGasPrice = "2000000000"
+	mined.Fee = "42000000000000"
+	mined.BlockNumber = 10
+	mined.UpdatedAt = day.Add(time.Minute)
+	mined.Label = `deploy "v2", again`
+	deployed := testRecord("0x02", alice, "", day)
+	deployed.Status = StatusPending
+	deployed.UpdatedAt = day
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []*Record{mined, deployed}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		csvHeader,
+		{"0x01", "1337", alice, bob, "1000", "7", "21000", "2000000000", "21000", "2000000000", "42000000000000",
+			`deploy "v2", again`, "SUCCESS", "10", "2025-08-20T12:00:00Z", "2025-08-20T12:01:00Z"},
+		{"0x02", "1337", alice, "", "1000", "7", "21000", "2000000000", "0", "", "",
+			"transfer", "PENDING", "0", "2025-08-20T12:00:00Z", "2025-08-20T12:00:00Z"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d:\n got %q\nwant %q", i, rows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package txpipe
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fuckEthereum/src/gas"
+)
+
+// Stage identifies the pipeline step where a request failed
+type Stage string
+
+const (
+	StagePrepare  Stage = "prepare"
+	StageEstimate Stage = "estimate"
+	StageSend     Stage = "send"
+	StageWait     Stage = "wait"
+	StageReceipt  Stage = "receipt"
+)
+
+var (
+	// ErrDryRun is returned by a Prepared hook to stop before signing
+	ErrDryRun = errors.New("dry run")
+
+	// ErrReceiptFailed means the transaction was mined but reverted
+	ErrReceiptFailed = errors.New("transaction reverted on chain")
+
+	ErrNonceTooLow       = errors.New("nonce too low")
+	ErrInsufficientFunds = errors.New("insufficient funds for gas * price + value")
+	ErrUnderpriced       = errors.New("replacement transaction underpriced")
+	ErrAlreadyKnown      = errors.New("transaction already known")
+)
+
+// Error wraps a pipeline failure with the request label and stage
+type Error struct {
+	Label string
+	Stage Stage
+	Err   error
+}
+
+func (e *Error) Error() string {
+	if e.Label == "" {
+		return fmt.Sprintf("%s: %v", e.Stage, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Label, e.Stage, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// knownErrors maps node error messages to sentinel errors
+var knownErrors = []struct {
+	substring string
+	err       error
+}{
+	{"nonce too low", ErrNonceTooLow},
+	{"insufficient funds", ErrInsufficientFunds},
+	{"underpriced", ErrUnderpriced},
+	{"already known", ErrAlreadyKnown},
+}
+
+// mapError turns node errors into sentinel or revert errors callers can test for
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if revert := gas.AsRevert(err); revert != nil {
+		return revert
+	}
+	message := err.Error()
+	for _, known := range knownErrors {
+		if errors.Is(err, known.err) {
+			return err
+		}
+		if strings.Contains(message, known.substring) {
+			return fmt.Errorf("%w: %v", known.err, err)
+		}
+	}
+	return err
+}
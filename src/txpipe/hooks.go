@@ -0,0 +1,156 @@
+package txpipe
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/src/gas"
+)
+
+// Hooks observe the pipeline; every field is optional.
+// Prepared runs after estimation and before signing; returning ErrDryRun stops the request.
+// Mined runs for every receipt, including reverted ones, which then also reach Failed.
+type Hooks struct {
+	Prepared func(req *Request, opts *bind.TransactOpts, estimate *gas.Estimate) error
+	Sent     func(req *Request, tx *types.Transaction)
+	Mined    func(req *Request, result *Result)
+	Failed   func(req *Request, stage Stage, err error)
+}
+
+// Chain combines several hooks; they run in order and the first Prepared error wins
+func Chain(hooks ...Hooks) Hooks {
+	return Hooks{
+		Prepared: func(req *Request, opts *bind.TransactOpts, estimate *gas.Estimate) error {
+			for _, h := range hooks {
+				if h.Prepared != nil {
+					if err := h.Prepared(req, opts, estimate); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Sent: func(req *Request, tx *types.Transaction) {
+			for _, h := range hooks {
+				if h.Sent != nil {
+					h.Sent(req, tx)
+				}
+			}
+		},
+		Mined: func(req *Request, result *Result) {
+			for _, h := range hooks {
+				if h.Mined != nil {
+					h.Mined(req, result)
+				}
+			}
+		},
+		Failed: func(req *Request, stage Stage, err error) {
+			for _, h := range hooks {
+				if h.Failed != nil {
+					h.Failed(req, stage, err)
+				}
+			}
+		},
+	}
+}
+
+func (h Hooks) prepared(req *Request, opts *bind.TransactOpts, estimate *gas.Estimate) error {
+	if h.Prepared == nil {
+		return nil
+	}
+	return h.Prepared(req, opts, estimate)
+}
+
+func (h Hooks) sent(req *Request, tx *types.Transaction) {
+	if h.Sent != nil {
+		h.Sent(req, tx)
+	}
+}
+
+func (h Hooks) mined(req *Request, result *Result) {
+	if h.Mined != nil {
+		h.Mined(req, result)
+	}
+}
+
+func (h Hooks) failed(req *Request, stage Stage, err error) {
+	if h.Failed != nil {
+		h.Failed(req, stage, err)
+	}
+}
+
+// LogHooks prints each pipeline step to stdout
+func LogHooks() Hooks {
+	return Hooks{
+		Prepared: func(req *Request, opts *bind.TransactOpts, estimate *gas.Estimate) error {
+			if estimate.Fallback {
+				fmt.Printf("⚠️  %s: gas estimation unavailable (%v), using default limit %d\n", req.Label, estimate.FallbackReason, estimate.Limit)
+			} else {
+				fmt.Printf("⛽ %s: estimated gas %d → limit %d\n", req.Label, estimate.Estimated, estimate.Limit)
+			}
+			return nil
+		},
+		Sent: func(req *Request, tx *types.Transaction) {
+			fmt.Printf("📝 %s transaction hash: %s\n", req.Label, tx.Hash().Hex())
+			fmt.Println("⏳ Waiting for transaction to be mined...")
+		},
+		Mined: func(req *Request, result *Result) {
+			if result.Receipt.Status == types.ReceiptStatusFailed {
+				return
+			}
+			fmt.Printf("✅ %s mined in block %s. Gas used: %d\n", req.Label, result.Receipt.BlockNumber, result.Receipt.GasUsed)
+			for _, event := range result.Events {
+				fmt.Printf("   📣 %s %v\n", event.Name, event.Fields)
+			}
+		},
+		Failed: func(req *Request, stage Stage, err error) {
+			fmt.Printf("❌ %v\n", err)
+		},
+	}
+}
+
+// DryRunHooks stops every request after estimation so nothing is signed or sent
+func DryRunHooks() Hooks {
+	return Hooks{
+		Prepared: func(req *Request, opts *bind.TransactOpts, estimate *gas.Estimate) error {
+			fmt.Printf("🧪 %s: dry run, not signing (gas limit %d)\n", req.Label, estimate.Limit)
+			return ErrDryRun
+		},
+	}
+}
+
+// Metrics counts pipeline outcomes; it is safe for concurrent use
+type Metrics struct {
+	mu      sync.Mutex
+	Sent    int
+	Mined   int
+	Failed  map[Stage]int
+	GasUsed uint64
+}
+
+// Hooks returns hooks that update the metrics
+func (m *Metrics) Hooks() Hooks {
+	return Hooks{
+		Sent: func(req *Request, tx *types.Transaction) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.Sent++
+		},
+		Mined: func(req *Request, result *Result) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.Mined++
+			m.GasUsed += result.Receipt.GasUsed
+		},
+		Failed: func(req *Request, stage Stage, err error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if m.Failed == nil {
+				m.Failed = make(map[Stage]int)
+			}
+			m.Failed[stage]++
+		},
+	}
+}
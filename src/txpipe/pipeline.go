@@ -0,0 +1,266 @@
+package txpipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fuckEthereum/src/gas"
+)
+
+// Backend is everything the pipeline needs from a node
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// Request describes a single contract write or deployment
+type Request struct {
+	Label       string          // Human-readable name used in logs and errors
+	ABI         *abi.ABI        // ABI used to pack the call and decode events
+	To          *common.Address // Contract to call; nil deploys Bytecode
+	Method      string          // Method to call (ignored for deployments); empty sends Data as is
+	Args        []interface{}   // Method or constructor arguments
+	Data        []byte          // Raw calldata when Method is empty, e.g. for a contract without an ABI
+	Bytecode    []byte          // Creation code for deployments
+	Value       *big.Int        // Wei sent along with the transaction (nil = 0)
+	FallbackGas uint64          // Gas limit used only when estimation fails
+}
+
+// IsDeploy reports whether the request deploys a contract
+func (r *Request) IsDeploy() bool {
+	return r.To == nil
+}
+
+// CallData returns the exact calldata (or creation code) the request sends.
+// A deployment without an ABI sends Bytecode as is and cannot take constructor arguments.
+func (r *Request) CallData() ([]byte, error) {
+	if r.IsDeploy() {
+		if r.ABI == nil {
+			if len(r.Args) > 0 {
+				return nil, errors.New("constructor arguments require an ABI")
+			}
+			return common.CopyBytes(r.Bytecode), nil
+		}
+		input, err := r.ABI.Pack("", r.Args...)
+		if err != nil {
+			return nil, err
+		}
+		return append(common.CopyBytes(r.Bytecode), input...), nil
+	}
+	if r.Method == "" {
+		return r.Data, nil
+	}
+	if r.ABI == nil {
+		return nil, fmt.Errorf("method %s requires an ABI", r.Method)
+	}
+	return r.ABI.Pack(r.Method, r.Args...)
+}
+
+// Event is a decoded log emitted by the transaction
+type Event struct {
+	Name   string
+	Fields map[string]interface{}
+	Log    *types.Log
+}
+
+// Result is what the pipeline returns for a request
+type Result struct {
+	Request         *Request
+	Opts            *bind.TransactOpts
+	Estimate        *gas.Estimate
+	Tx              *types.Transaction
+	Receipt         *types.Receipt
+	ContractAddress common.Address
+	Events          []Event
+	DryRun          bool // true if the pipeline stopped before signing
+}
+
+// Pipeline runs contract writes through prepare, estimate, sign, send, wait and decode
+type Pipeline struct {
+	backend   Backend
+	auth      *bind.TransactOpts
+	estimator *gas.Estimator
+	hooks     Hooks
+}
+
+// New creates a pipeline. auth provides From and Signer; nonce, gas and context are set per request.
+func New(backend Backend, auth *bind.TransactOpts, estimator *gas.Estimator, hooks ...Hooks) *Pipeline {
+	return &Pipeline{
+		backend:   backend,
+		auth:      auth,
+		estimator: estimator,
+		hooks:     Chain(hooks...),
+	}
+}
+
+// From returns the sending account
+func (p *Pipeline) From() common.Address {
+	return p.auth.From
+}
+
+// Backend returns the node backend used by the pipeline
+func (p *Pipeline) Backend() Backend {
+	return p.backend
+}
+
+// Transact calls a contract method and waits for its receipt
+func (p *Pipeline) Transact(ctx context.Context, req *Request) (*Result, error) {
+	if req.To == nil {
+		return nil, p.fail(req, StagePrepare, errors.New("transact requires a contract address"))
+	}
+	return p.run(ctx, req)
+}
+
+// Deploy deploys a contract and waits for its receipt
+func (p *Pipeline) Deploy(ctx context.Context, req *Request) (*Result, error) {
+	if req.To != nil {
+		return nil, p.fail(req, StagePrepare, errors.New("deploy must not set a contract address"))
+	}
+	if len(req.Bytecode) == 0 {
+		return nil, p.fail(req, StagePrepare, errors.New("deploy requires bytecode"))
+	}
+	return p.run(ctx, req)
+}
+
+func (p *Pipeline) run(ctx context.Context, req *Request) (*Result, error) {
+	result := &Result{Request: req}
+
+	// Prepare: copy the signer template and fill nonce and gas price
+	opts, err := p.prepare(ctx)
+	if err != nil {
+		return result, p.fail(req, StagePrepare, err)
+	}
+	opts.Value = req.Value
+	result.Opts = opts
+
+	// Estimate with the exact calldata; reverts abort here
+	data, err := req.CallData()
+	if err != nil {
+		return result, p.fail(req, StagePrepare, fmt.Errorf("failed to pack call: %v", err))
+	}
+	estimate, err := p.estimator.Estimate(ctx, ethereum.CallMsg{
+		From:     opts.From,
+		To:       req.To,
+		GasPrice: opts.GasPrice,
+		Value:    req.Value,
+		Data:     data,
+	}, req.FallbackGas)
+	if err != nil {
+		return result, p.fail(req, StageEstimate, err)
+	}
+	opts.GasLimit = estimate.Limit
+	result.Estimate = estimate
+
+	if err := p.hooks.prepared(req, opts, estimate); err != nil {
+		if errors.Is(err, ErrDryRun) {
+			result.DryRun = true
+			return result, nil
+		}
+		return result, p.fail(req, StagePrepare, err)
+	}
+
+	// Sign and send through the generic bound contract
+	var tx *types.Transaction
+	if req.IsDeploy() {
+		contractABI := abi.ABI{}
+		if req.ABI != nil {
+			contractABI = *req.ABI
+		}
+		result.ContractAddress, tx, _, err = bind.DeployContract(opts, contractABI, req.Bytecode, p.backend, req.Args...)
+	} else if req.Method == "" {
+		contract := bind.NewBoundContract(*req.To, abi.ABI{}, p.backend, p.backend, p.backend)
+		tx, err = contract.RawTransact(opts, req.Data)
+	} else {
+		contract := bind.NewBoundContract(*req.To, *req.ABI, p.backend, p.backend, p.backend)
+		tx, err = contract.Transact(opts, req.Method, req.Args...)
+	}
+	if err != nil {
+		return result, p.fail(req, StageSend, err)
+	}
+	result.Tx = tx
+	p.hooks.sent(req, tx)
+
+	// Wait for the receipt and decode what happened
+	receipt, err := bind.WaitMined(ctx, p.backend, tx)
+	if err != nil {
+		return result, p.fail(req, StageWait, err)
+	}
+	result.Receipt = receipt
+	result.Events = DecodeEvents(req.ABI, receipt.Logs)
+	p.hooks.mined(req, result)
+
+	if receipt.Status == types.ReceiptStatusFailed {
+		return result, p.fail(req, StageReceipt, ErrReceiptFailed)
+	}
+	return result, nil
+}
+
+// prepare copies the signer template and fills nonce, gas price and context
+func (p *Pipeline) prepare(ctx context.Context) (*bind.TransactOpts, error) {
+	nonce, err := p.backend.PendingNonceAt(ctx, p.auth.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %v", err)
+	}
+
+	opts := *p.auth
+	opts.Context = ctx
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+
+	if opts.GasPrice == nil && opts.GasFeeCap == nil {
+		gasPrice, err := p.backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gas price: %v", err)
+		}
+		opts.GasPrice = gasPrice
+	}
+	return &opts, nil
+}
+
+func (p *Pipeline) fail(req *Request, stage Stage, err error) error {
+	wrapped := &Error{Label: req.Label, Stage: stage, Err: mapError(err)}
+	p.hooks.failed(req, stage, wrapped)
+	return wrapped
+}
+
+// DecodeEvents decodes the logs that match events in the ABI; other logs are skipped
+func DecodeEvents(contractABI *abi.ABI, logs []*types.Log) []Event {
+	if contractABI == nil {
+		return nil
+	}
+	var events []Event
+	for _, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		event, err := contractABI.EventByID(log.Topics[0])
+		if err != nil {
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		if len(log.Data) > 0 {
+			if err := contractABI.UnpackIntoMap(fields, event.Name, log.Data); err != nil {
+				continue
+			}
+		}
+		var indexed abi.Arguments
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if err := abi.ParseTopicsIntoMap(fields, indexed, log.Topics[1:]); err != nil {
+			continue
+		}
+
+		events = append(events, Event{Name: event.Name, Fields: fields, Log: log})
+	}
+	return events
+}
@@ -0,0 +1,382 @@
+package txpipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/gas"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+// recorder is a hook that appends "<name> <step> <label>" for every call
+type recorder struct {
+	name  string
+	steps *[]string
+}
+
+func (r recorder) Hooks() Hooks {
+	record := func(step string, req *Request) {
+		*r.steps = append(*r.steps, fmt.Sprintf("%s %s %s", r.name, step, req.Label))
+	}
+	return Hooks{
+		Prepared: func(req *Request, opts *bind.TransactOpts, estimate *gas.Estimate) error {
+			record("prepared", req)
+			return nil
+		},
+		Sent:   func(req *Request, tx *types.Transaction) { record("sent", req) },
+		Mined:  func(req *Request, result *Result) { record("mined", req) },
+		Failed: func(req *Request, stage Stage, err error) { record("failed:"+string(stage), req) },
+	}
+}
+
+func expectSteps(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("steps\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// unavailable is a gas estimator that always fails without a revert, forcing the fallback limit
+type unavailable struct{}
+
+func (unavailable) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 0, errors.New("method not found")
+}
+
+func newTestChain(t *testing.T) *simchain.Chain {
+	t.Helper()
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	return chain
+}
+
+func newTestPipeline(t *testing.T, chain *simchain.Chain, key int, estimator *gas.Estimator, hooks ...Hooks) *Pipeline {
+	t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(key), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimator == nil {
+		estimator = gas.NewEstimator(chain.Client(), gas.DefaultMultiplier)
+	}
+	return New(chain.Client(), auth, estimator, hooks...)
+}
+
+func counterABI(t *testing.T) *abi.ABI {
+	t.Helper()
+	parsed, err := contracts.CounterMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func deployRequest(t *testing.T) *Request {
+	return &Request{
+		Label:       "deploy",
+		ABI:         counterABI(t),
+		Bytecode:    common.FromHex(contracts.CounterMetaData.Bin),
+		FallbackGas: gas.DefaultDeployGas,
+	}
+}
+
+func callRequest(t *testing.T, to common.Address, method string) *Request {
+	return &Request{
+		Label:       method,
+		ABI:         counterABI(t),
+		To:          &to,
+		Method:      method,
+		FallbackGas: gas.DefaultCallGas,
+	}
+}
+
+func deploy(t *testing.T, pipeline *Pipeline) common.Address {
+	t.Helper()
+	result, err := pipeline.Deploy(context.Background(), deployRequest(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.ContractAddress
+}
+
+func TestHookOrder(t *testing.T) {
+	chain := newTestChain(t)
+	var steps []string
+	metrics := &Metrics{}
+	pipeline := newTestPipeline(t, chain, 0, nil,
+		recorder{"first", &steps}.Hooks(), LogHooks(), metrics.Hooks(), recorder{"second", &steps}.Hooks())
+	ctx := context.Background()
+
+	address := deploy(t, pipeline)
+	if _, err := pipeline.Transact(ctx, callRequest(t, address, "increment")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pipeline.Transact(ctx, callRequest(t, address, "decrement")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pipeline.Transact(ctx, callRequest(t, address, "decrement")); err == nil {
+		t.Fatal("decrementing at zero succeeded")
+	}
+
+	expectSteps(t, steps,
+		"first prepared deploy", "second prepared deploy",
+		"first sent deploy", "second sent deploy",
+		"first mined deploy", "second mined deploy",
+		"first prepared increment", "second prepared increment",
+		"first sent increment", "second sent increment",
+		"first mined increment", "second mined increment",
+		"first prepared decrement", "second prepared decrement",
+		"first sent decrement", "second sent decrement",
+		"first mined decrement", "second mined decrement",
+		"first failed:estimate decrement", "second failed:estimate decrement",
+	)
+	if metrics.Sent != 3 || metrics.Mined != 3 || metrics.Failed[StageEstimate] != 1 || metrics.GasUsed == 0 {
+		t.Fatalf("metrics sent %d, mined %d, failed %v, gas used %d", metrics.Sent, metrics.Mined, metrics.Failed, metrics.GasUsed)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	chain := newTestChain(t)
+	var steps []string
+	ctx := context.Background()
+	address := deploy(t, newTestPipeline(t, chain, 0, nil))
+
+	nonce, err := chain.Client().PendingNonceAt(ctx, chain.Address(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := newTestPipeline(t, chain, 0, nil, recorder{"first", &steps}.Hooks(), DryRunHooks(), recorder{"second", &steps}.Hooks())
+	result, err := pipeline.Transact(ctx, callRequest(t, address, "increment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || result.Tx != nil || result.Receipt != nil {
+		t.Fatalf("dry run result %+v", result)
+	}
+	if result.Estimate == nil || result.Opts.GasLimit != result.Estimate.Limit {
+		t.Fatalf("dry run did not estimate: %+v", result)
+	}
+	// Hooks after the dry run and the later steps do not run, and nothing reaches the node
+	expectSteps(t, steps, "first prepared increment")
+	after, err := chain.Client().PendingNonceAt(ctx, chain.Address(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != nonce {
+		t.Fatalf("nonce moved from %d to %d during a dry run", nonce, after)
+	}
+}
+
+func TestReceiptEvents(t *testing.T) {
+	chain := newTestChain(t)
+	pipeline := newTestPipeline(t, chain, 0, nil)
+	ctx := context.Background()
+
+	deployed, err := pipeline.Deploy(ctx, deployRequest(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployed.Receipt.ContractAddress != deployed.ContractAddress || len(deployed.Events) != 0 {
+		t.Fatalf("deploy result: address %s, receipt %s, events %v",
+			deployed.ContractAddress.Hex(), deployed.Receipt.ContractAddress.Hex(), deployed.Events)
+	}
+
+	address := deployed.ContractAddress
+	for i, method := range []string{"increment", "increment", "decrement", "reset"} {
+		result, err := pipeline.Transact(ctx, callRequest(t, address, method))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Receipt.Status != types.ReceiptStatusSuccessful || result.Receipt.TxHash != result.Tx.Hash() {
+			t.Fatalf("%s receipt %+v", method, result.Receipt)
+		}
+		if result.Estimate.Fallback || result.Tx.Gas() != result.Estimate.Limit {
+			t.Fatalf("%s sent with gas %d, estimate %+v", method, result.Tx.Gas(), result.Estimate)
+		}
+		if len(result.Events) != 1 {
+			t.Fatalf("%s events %v, want one", method, result.Events)
+		}
+		event := result.Events[0]
+		want := []struct {
+			name  string
+			count int64
+		}{{"CountIncremented", 1}, {"CountIncremented", 2}, {"CountDecremented", 1}, {"CountReset", 0}}[i]
+		count, ok := event.Fields["newCount"].(*big.Int)
+		if event.Name != want.name || !ok || count.Int64() != want.count || event.Log.TxHash != result.Tx.Hash() {
+			t.Fatalf("%s event %s %v, want %s %d", method, event.Name, event.Fields, want.name, want.count)
+		}
+	}
+
+	// Logs from other contracts or with unknown topics are skipped
+	if events := DecodeEvents(counterABI(t), []*types.Log{{Topics: []common.Hash{{1}}}, {}}); len(events) != 0 {
+		t.Fatalf("decoded unknown logs as %v", events)
+	}
+	if events := DecodeEvents(nil, deployed.Receipt.Logs); events != nil {
+		t.Fatalf("decoded without an ABI: %v", events)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	chain := newTestChain(t)
+	ctx := context.Background()
+	address := deploy(t, newTestPipeline(t, chain, 0, nil))
+
+	t.Run("revert during estimation", func(t *testing.T) {
+		var failed []Stage
+		pipeline := newTestPipeline(t, chain, 0, nil, Hooks{Failed: func(req *Request, stage Stage, err error) { failed = append(failed, stage) }})
+		result, err := pipeline.Transact(ctx, callRequest(t, address, "decrement"))
+		var pipeErr *Error
+		if !errors.As(err, &pipeErr) || pipeErr.Stage != StageEstimate || pipeErr.Label != "decrement" {
+			t.Fatalf("error %v, want an estimate error for decrement", err)
+		}
+		revert := gas.AsRevert(err)
+		if revert == nil || revert.Reason != "Counter cannot be negative" {
+			t.Fatalf("error %v, want the revert reason", err)
+		}
+		if result.Tx != nil || len(failed) != 1 || failed[0] != StageEstimate {
+			t.Fatalf("sent %v, failed hooks %v", result.Tx, failed)
+		}
+	})
+
+	t.Run("revert on chain", func(t *testing.T) {
+		var steps []string
+		// Without an estimate the revert is only seen in the receipt
+		pipeline := newTestPipeline(t, chain, 0, gas.NewEstimator(unavailable{}, gas.DefaultMultiplier), recorder{"hook", &steps}.Hooks())
+		result, err := pipeline.Transact(ctx, callRequest(t, address, "decrement"))
+		if !errors.Is(err, ErrReceiptFailed) || err.Error() != "decrement: receipt: transaction reverted on chain" {
+			t.Fatalf("error %v, want %v", err, ErrReceiptFailed)
+		}
+		if !result.Estimate.Fallback || result.Tx.Gas() != gas.DefaultCallGas || result.Receipt.Status != types.ReceiptStatusFailed {
+			t.Fatalf("result estimate %+v, receipt %+v", result.Estimate, result.Receipt)
+		}
+		expectSteps(t, steps, "hook prepared decrement", "hook sent decrement", "hook mined decrement", "hook failed:receipt decrement")
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		auth, err := bind.NewKeyedTransactorWithChainID(key, simchain.ChainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pipeline := New(chain.Client(), auth, gas.NewEstimator(unavailable{}, gas.DefaultMultiplier))
+		_, err = pipeline.Transact(ctx, callRequest(t, address, "increment"))
+		var pipeErr *Error
+		if !errors.Is(err, ErrInsufficientFunds) || !errors.As(err, &pipeErr) || pipeErr.Stage != StageSend {
+			t.Fatalf("error %v, want %v at the send stage", err, ErrInsufficientFunds)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		pipeline := newTestPipeline(t, chain, 0, nil)
+		tests := []struct {
+			name string
+			run  func() (*Result, error)
+			want string
+		}{
+			{"transact without address", func() (*Result, error) {
+				req := callRequest(t, address, "increment")
+				req.To = nil
+				return pipeline.Transact(ctx, req)
+			}, "increment: prepare: transact requires a contract address"},
+			{"deploy with address", func() (*Result, error) {
+				req := deployRequest(t)
+				req.To = &address
+				return pipeline.Deploy(ctx, req)
+			}, "deploy: prepare: deploy must not set a contract address"},
+			{"deploy without bytecode", func() (*Result, error) {
+				req := deployRequest(t)
+				req.Bytecode = nil
+				return pipeline.Deploy(ctx, req)
+			}, "deploy: prepare: deploy requires bytecode"},
+			{"unknown method", func() (*Result, error) {
+				return pipeline.Transact(ctx, callRequest(t, address, "double"))
+			}, `double: prepare: failed to pack call: method 'double' not found`},
+			{"method without ABI", func() (*Result, error) {
+				req := callRequest(t, address, "increment")
+				req.ABI = nil
+				return pipeline.Transact(ctx, req)
+			}, "increment: prepare: failed to pack call: method increment requires an ABI"},
+			{"constructor arguments without ABI", func() (*Result, error) {
+				req := deployRequest(t)
+				req.ABI = nil
+				req.Args = []interface{}{big.NewInt(1)}
+				return pipeline.Deploy(ctx, req)
+			}, "deploy: prepare: failed to pack call: constructor arguments require an ABI"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := tt.run()
+				if err == nil || err.Error() != tt.want {
+					t.Fatalf("error %v, want %q", err, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestDeployWithoutABI(t *testing.T) {
+	chain := newTestChain(t)
+	pipeline := newTestPipeline(t, chain, 0, nil)
+	ctx := context.Background()
+
+	req := deployRequest(t)
+	req.ABI = nil
+	result, err := pipeline.Deploy(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := chain.Client().CodeAt(ctx, result.ContractAddress, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) == 0 || result.Events != nil {
+		t.Fatalf("code %d bytes, events %v", len(code), result.Events)
+	}
+}
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{errors.New("nonce too low: next nonce 5, tx nonce 3"), ErrNonceTooLow},
+		{errors.New("insufficient funds for gas * price + value: balance 0"), ErrInsufficientFunds},
+		{errors.New("replacement transaction underpriced"), ErrUnderpriced},
+		{errors.New("already known"), ErrAlreadyKnown},
+		{fmt.Errorf("send: %w", ErrNonceTooLow), ErrNonceTooLow},
+	}
+	for _, tt := range tests {
+		got := mapError(tt.err)
+		if !errors.Is(got, tt.want) || !strings.Contains(got.Error(), tt.err.Error()) {
+			t.Errorf("mapError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+
+	if revert := gas.AsRevert(mapError(errors.New("execution reverted"))); revert == nil {
+		t.Error("a revert was not mapped to a RevertError")
+	}
+	other := errors.New("connection refused")
+	if got := mapError(other); got != other {
+		t.Errorf("mapError(%v) = %v, want it unchanged", other, got)
+	}
+	if mapError(nil) != nil {
+		t.Error("mapError(nil) is not nil")
+	}
+}
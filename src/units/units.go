@@ -0,0 +1,266 @@
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExponent bounds scientific notation so a typo cannot allocate huge numbers
+const maxExponent = 96
+
+// Unit is a named power-of-ten denomination of wei
+type Unit struct {
+	Name     string
+	Decimals int
+}
+
+var (
+	Wei    = Unit{Name: "wei", Decimals: 0}
+	Kwei   = Unit{Name: "kwei", Decimals: 3}
+	Mwei   = Unit{Name: "mwei", Decimals: 6}
+	Gwei   = Unit{Name: "gwei", Decimals: 9}
+	Szabo  = Unit{Name: "szabo", Decimals: 12}
+	Finney = Unit{Name: "finney", Decimals: 15}
+	Ether  = Unit{Name: "ether", Decimals: 18}
+)
+
+// unitsByName maps every accepted spelling to its unit
+var unitsByName = map[string]Unit{
+	"wei":        Wei,
+	"kwei":       Kwei,
+	"babbage":    Kwei,
+	"mwei":       Mwei,
+	"lovelace":   Mwei,
+	"gwei":       Gwei,
+	"shannon":    Gwei,
+	"szabo":      Szabo,
+	"microether": Szabo,
+	"finney":     Finney,
+	"milliether": Finney,
+	"ether":      Ether,
+	"eth":        Ether,
+}
+
+// LookupUnit returns the unit with the given name (case-insensitive)
+func LookupUnit(name string) (Unit, error) {
+	unit, ok := unitsByName[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Unit{}, fmt.Errorf("unknown unit %q", name)
+	}
+	return unit, nil
+}
+
+// Amount is an exact quantity of wei
+type Amount struct {
+	wei *big.Int
+}
+
+// NewAmount wraps a wei value; a nil value is treated as zero
+func NewAmount(wei *big.Int) Amount {
+	if wei == nil {
+		return Amount{wei: new(big.Int)}
+	}
+	return Amount{wei: new(big.Int).Set(wei)}
+}
+
+// Parse parses an amount such as "0.01 ether", "25 gwei" or "1e15 wei".
+// A bare number without a unit is rejected to avoid wei/ether mix-ups.
+func Parse(s string) (Amount, error) {
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 2:
+		unit, err := LookupUnit(fields[1])
+		if err != nil {
+			return Amount{}, err
+		}
+		return ParseIn(fields[0], unit)
+	case 1:
+		// Allow the unit to be glued to the number, e.g. "25gwei"
+		number, unitName := splitUnitSuffix(fields[0])
+		if unitName == "" {
+			return Amount{}, fmt.Errorf("amount %q has no unit (use e.g. \"0.01 ether\" or \"25 gwei\")", s)
+		}
+		unit, err := LookupUnit(unitName)
+		if err != nil {
+			return Amount{}, err
+		}
+		return ParseIn(number, unit)
+	default:
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+}
+
+// MustParse is like Parse but panics on error; intended for constants
+func MustParse(s string) Amount {
+	amount, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+// ParseIn parses a decimal number (optionally with an exponent) denominated in the given unit.
+// Amounts are values, limits and thresholds, so negative numbers are rejected.
+func ParseIn(number string, unit Unit) (Amount, error) {
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return Amount{}, fmt.Errorf("empty amount")
+	}
+
+	switch number[0] {
+	case '-':
+		return Amount{}, fmt.Errorf("amount %q is negative", number)
+	case '+':
+		number = number[1:]
+	}
+
+	mantissa, exponent := number, 0
+	if i := strings.IndexAny(number, "eE"); i >= 0 {
+		mantissa = number[:i]
+		exp, err := strconv.Atoi(number[i+1:])
+		if err != nil || exp > maxExponent || exp < -maxExponent {
+			return Amount{}, fmt.Errorf("invalid exponent in %q", number)
+		}
+		exponent = exp
+	}
+
+	intPart, fracPart := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		intPart, fracPart = mantissa[:i], mantissa[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return Amount{}, fmt.Errorf("invalid amount %q", number)
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Amount{}, fmt.Errorf("invalid amount %q", number)
+	}
+
+	digits, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", number)
+	}
+	scale := unit.Decimals + exponent - len(fracPart)
+
+	wei := digits
+	if scale >= 0 {
+		wei.Mul(wei, pow10(scale))
+	} else {
+		quotient, remainder := new(big.Int).QuoRem(wei, pow10(-scale), new(big.Int))
+		if remainder.Sign() != 0 {
+			return Amount{}, fmt.Errorf("amount \"%s %s\" is more precise than 1 wei", number, unit.Name)
+		}
+		wei = quotient
+	}
+
+	return Amount{wei: wei}, nil
+}
+
+// Wei returns a copy of the amount in wei
+func (a Amount) Wei() *big.Int {
+	if a.wei == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.wei)
+}
+
+// IsZero reports whether the amount is zero
+func (a Amount) IsZero() bool {
+	return a.wei == nil || a.wei.Sign() == 0
+}
+
+// Cmp compares two amounts like big.Int.Cmp
+func (a Amount) Cmp(b Amount) int {
+	return a.Wei().Cmp(b.Wei())
+}
+
+// Add returns a + b
+func (a Amount) Add(b Amount) Amount {
+	return Amount{wei: new(big.Int).Add(a.Wei(), b.Wei())}
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{wei: new(big.Int).Sub(a.Wei(), b.Wei())}
+}
+
+// Number formats the amount in the given unit without a unit suffix.
+// precision is the number of fractional digits (rounded half away from zero);
+// a negative precision prints the exact value with trailing zeros trimmed.
+func (a Amount) Number(unit Unit, precision int) string {
+	wei := a.Wei()
+	negative := wei.Sign() < 0
+	wei.Abs(wei)
+
+	if precision >= 0 && precision < unit.Decimals {
+		// Round to the requested number of digits before splitting
+		step := pow10(unit.Decimals - precision)
+		half := new(big.Int).Rsh(step, 1)
+		wei.Add(wei, half)
+		wei.Sub(wei, new(big.Int).Mod(wei, step))
+	}
+
+	intPart, fracPart := new(big.Int).QuoRem(wei, pow10(unit.Decimals), new(big.Int))
+	frac := ""
+	if unit.Decimals > 0 {
+		frac = fmt.Sprintf("%0*s", unit.Decimals, fracPart.String())
+	}
+
+	switch {
+	case precision < 0:
+		frac = strings.TrimRight(frac, "0")
+	case precision <= len(frac):
+		frac = frac[:precision]
+	default:
+		frac += strings.Repeat("0", precision-len(frac))
+	}
+
+	text := intPart.String()
+	if frac != "" {
+		text += "." + frac
+	}
+	if negative && strings.Trim(text, "0.") != "" {
+		text = "-" + text
+	}
+	return text
+}
+
+// Format formats the amount in the given unit followed by the unit name
+func (a Amount) Format(unit Unit, precision int) string {
+	return a.Number(unit, precision) + " " + unit.Name
+}
+
+// String formats the amount exactly in ether
+func (a Amount) String() string {
+	return a.Format(Ether, -1)
+}
+
+// FormatWei is a shorthand for formatting a raw wei value
+func FormatWei(wei *big.Int, unit Unit, precision int) string {
+	return NewAmount(wei).Format(unit, precision)
+}
+
+// splitUnitSuffix splits "25gwei" into "25" and "gwei"; the unit is everything after the last digit
+func splitUnitSuffix(s string) (string, string) {
+	for i := len(s); i > 0; i-- {
+		c := s[i-1]
+		if (c >= '0' && c <= '9') || c == '.' {
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,86 @@
+package units
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		wei     string
+		wantErr bool
+	}{
+		{"0.01 ether", "10000000000000000", false},
+		{"25gwei", "25000000000", false},
+		{"1e15 wei", "1000000000000000", false},
+		{"+1 wei", "1", false},
+		{"1.5 eth", "1500000000000000000", false},
+		{"-1 ether", "", true},
+		{"-0.5gwei", "", true},
+		{"1", "", true},
+		{"0.1 wei", "", true},
+		{"1 lightyear", "", true},
+	}
+	for _, tt := range tests {
+		amount, err := Parse(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %s, want error", tt.input, amount.Wei())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if got := amount.Wei().String(); got != tt.wei {
+			t.Errorf("Parse(%q) = %s wei, want %s", tt.input, got, tt.wei)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		wei       string
+		unit      Unit
+		precision int
+		want      string
+	}{
+		{"1500000000000000000", Ether, -1, "1.5"},
+		{"1", Ether, -1, "0.000000000000000001"},
+		{"1000000000000000000", Ether, -1, "1"},
+		{"0", Gwei, -1, "0"},
+		{"123456789", Wei, -1, "123456789"},
+		{"1500000000", Gwei, 0, "2"},
+		{"1499999999", Gwei, 0, "1"},
+		{"1234567890000000000", Ether, 4, "1.2346"},
+		{"999999999999999999", Ether, 2, "1.00"},
+		{"10000000000000000", Ether, 6, "0.010000"},
+		{"25000000000", Gwei, 3, "25.000"},
+		{"7", Wei, 2, "7.00"},
+		{"-1500000000000000000", Ether, -1, "-1.5"},
+		{"-1500000000", Gwei, 0, "-2"},
+		{"-999999999999999999", Ether, 2, "-1.00"},
+		{"-1", Ether, 2, "0.00"},
+	}
+	for _, tt := range tests {
+		wei, ok := new(big.Int).SetString(tt.wei, 10)
+		if !ok {
+			t.Fatalf("bad test value %s", tt.wei)
+		}
+		if got := NewAmount(wei).Number(tt.unit, tt.precision); got != tt.want {
+			t.Errorf("%s wei in %s at %d = %q, want %q", tt.wei, tt.unit.Name, tt.precision, got, tt.want)
+		}
+		if got, want := FormatWei(wei, tt.unit, tt.precision), tt.want+" "+tt.unit.Name; got != want {
+			t.Errorf("FormatWei(%s, %s, %d) = %q, want %q", tt.wei, tt.unit.Name, tt.precision, got, want)
+		}
+	}
+
+	// Formatting never changes the amount
+	amount := MustParse("1.5 gwei")
+	amount.Number(Gwei, 0)
+	if amount.Wei().String() != "1500000000" {
+		t.Errorf("Number modified the amount to %s wei", amount.Wei())
+	}
+}
@@ -0,0 +1,171 @@
+package verify
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Metadata is the CBOR map solc appends to runtime code
+type Metadata struct {
+	Solc         string `json:"solc,omitempty"`  // compiler version, e.g. "0.8.30"
+	IPFS         string `json:"ipfs,omitempty"`  // CIDv0 of the metadata JSON, e.g. "Qm..."
+	Bzzr0        string `json:"bzzr0,omitempty"` // Swarm hash used by solc < 0.6
+	Bzzr1        string `json:"bzzr1,omitempty"`
+	Experimental bool   `json:"experimental,omitempty"`
+	Raw          []byte `json:"-"` // the CBOR bytes, without the 2-byte length
+}
+
+// SplitMetadata separates runtime code from its CBOR metadata tail.
+// ok is false when the code does not end in a well-formed metadata section.
+func SplitMetadata(code []byte) (body []byte, meta *Metadata, ok bool) {
+	if len(code) < 2 {
+		return code, nil, false
+	}
+	size := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	if size == 0 || size+2 > len(code) {
+		return code, nil, false
+	}
+	raw := code[len(code)-2-size : len(code)-2]
+	meta, err := decodeMetadata(raw)
+	if err != nil {
+		return code, nil, false
+	}
+	return code[:len(code)-2-size], meta, true
+}
+
+// decodeMetadata understands the small CBOR subset solc emits: a map of text keys to
+// byte strings, text strings and booleans
+func decodeMetadata(raw []byte) (*Metadata, error) {
+	d := &cborDecoder{data: raw}
+	major, count, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, errors.New("metadata is not a CBOR map")
+	}
+
+	meta := &Metadata{Raw: raw}
+	for i := uint64(0); i < count; i++ {
+		key, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		major, arg, err := d.header()
+		if err != nil {
+			return nil, err
+		}
+		switch major {
+		case 2, 3: // byte string, text string
+			value, err := d.take(arg)
+			if err != nil {
+				return nil, err
+			}
+			switch key {
+			case "solc":
+				if major == 3 {
+					// Pre-release builds carry the full version string
+					meta.Solc = string(value)
+				} else if len(value) == 3 {
+					meta.Solc = fmt.Sprintf("%d.%d.%d", value[0], value[1], value[2])
+				}
+			case "ipfs":
+				meta.IPFS = base58(value)
+			case "bzzr0":
+				meta.Bzzr0 = hexutil.Encode(value)
+			case "bzzr1":
+				meta.Bzzr1 = hexutil.Encode(value)
+			}
+		case 7: // simple values: 20 false, 21 true
+			if key == "experimental" {
+				meta.Experimental = arg == 21
+			}
+		default:
+			return nil, fmt.Errorf("unexpected CBOR type %d for %q", major, key)
+		}
+	}
+	if d.pos != len(raw) {
+		return nil, errors.New("trailing bytes after metadata map")
+	}
+	return meta, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// header reads a CBOR item header and returns its major type and argument
+func (d *cborDecoder) header() (major byte, arg uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errors.New("truncated CBOR")
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24, info == 25, info == 26, info == 27:
+		n := 1 << (info - 24)
+		raw, err := d.take(uint64(n))
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, c := range raw {
+			arg = arg<<8 | uint64(c)
+		}
+		return major, arg, nil
+	}
+	return 0, 0, fmt.Errorf("unsupported CBOR item 0x%02x", b)
+}
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errors.New("truncated CBOR")
+	}
+	out := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return out, nil
+}
+
+func (d *cborDecoder) text() (string, error) {
+	major, n, err := d.header()
+	if err != nil {
+		return "", err
+	}
+	if major != 3 {
+		return "", errors.New("CBOR map key is not text")
+	}
+	b, err := d.take(n)
+	return string(b), err
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58 encodes with the Bitcoin alphabet, as used by IPFS CIDv0
+func base58(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
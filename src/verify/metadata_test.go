@@ -0,0 +1,110 @@
+package verify
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBase58(t *testing.T) {
+	// Vectors from Bitcoin Core's base58_encode_decode.json
+	tests := []struct {
+		hex  string
+		want string
+	}{
+		{"", ""},
+		{"61", "2g"},
+		{"626262", "a3gV"},
+		{"73696d706c792061206c6f6e6720737472696e67", "2cFupjhnEsSn59qHXstmK2ffpLv2"},
+		{"00eb15231dfceb60925886b67d065299925915aeb172c06647", "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+		{"516b6fcd0f", "ABnLTmg"},
+		{"00000000000000000000", "1111111111"},
+	}
+	for _, tt := range tests {
+		if got := base58(common.FromHex(tt.hex)); got != tt.want {
+			t.Errorf("base58(%s) = %q, want %q", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeMetadata(t *testing.T) {
+	multihash := "122001ca5e3be7468c52122f50cd3a54557f712b6e6823c587ed28a4466a3e73860a"
+	swarm := "b8ab9a23c3e1b9c8cd3c4c48e1b1f3a0a4fb0b4f1d5e7e2d8b1c4f6e3a2d1c0b"
+
+	tests := []struct {
+		name string
+		cbor string
+		want Metadata
+	}{
+		{
+			// Counter compiled with solc 0.8.30: {"ipfs": h'1220…', "solc": h'00081e'}
+			"solc 0.8.30 with IPFS",
+			"a2" + "6469706673" + "5822" + multihash + "64736f6c63" + "4300081e",
+			Metadata{Solc: "0.8.30", IPFS: "QmNTe6Ko4hEM4hejTLSLHd6aNH5n5YbniZruAQJWHeof7P"},
+		},
+		{
+			// Pre-release compilers store the full version as text
+			"pre-release with experimental",
+			"a2" + "64736f6c63" + "6e" + "302e382e33302d6e696768746c79" + "6c6578706572696d656e74616c" + "f5",
+			Metadata{Solc: "0.8.30-nightly", Experimental: true},
+		},
+		{
+			"legacy Swarm hash",
+			"a1" + "65627a7a7230" + "5820" + swarm,
+			Metadata{Bzzr0: "0x" + swarm},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := common.FromHex(tt.cbor)
+			meta, err := decodeMetadata(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if meta.Solc != tt.want.Solc || meta.IPFS != tt.want.IPFS || meta.Bzzr0 != tt.want.Bzzr0 || meta.Experimental != tt.want.Experimental {
+				t.Errorf("got %+v, want %+v", *meta, tt.want)
+			}
+			if !bytes.Equal(meta.Raw, raw) {
+				t.Error("Raw does not hold the CBOR bytes")
+			}
+		})
+	}
+}
+
+func TestDecodeMetadataRejectsMalformed(t *testing.T) {
+	for name, cbor := range map[string]string{
+		"not a map":       "8164736f6c63",
+		"non-text key":    "a1014300081e",
+		"truncated value": "a164736f6c63430008",
+		"trailing bytes":  "a164736f6c634300081e00",
+		"nested map":      "a164736f6c63a0",
+		"empty":           "",
+	} {
+		if _, err := decodeMetadata(common.FromHex(cbor)); err == nil {
+			t.Errorf("%s: decoded without an error", name)
+		}
+	}
+}
+
+func TestSplitMetadata(t *testing.T) {
+	body := common.FromHex("6080604052")
+	cbor := common.FromHex("a164736f6c634300081e")
+	code := append(append(bytes.Clone(body), cbor...), 0x00, byte(len(cbor)))
+
+	gotBody, meta, ok := SplitMetadata(code)
+	if !ok || !bytes.Equal(gotBody, body) || meta.Solc != "0.8.30" {
+		t.Errorf("SplitMetadata = %x, %+v, %v", gotBody, meta, ok)
+	}
+
+	for name, code := range map[string][]byte{
+		"no metadata":      body,
+		"length too large": append(bytes.Clone(body), 0xff, 0xff),
+		"not CBOR":         append(bytes.Clone(body), 0x00, 0x03),
+		"too short":        {0x00},
+	} {
+		if gotBody, meta, ok := SplitMetadata(code); ok || meta != nil || !bytes.Equal(gotBody, code) {
+			t.Errorf("%s: SplitMetadata = %x, %+v, %v", name, gotBody, meta, ok)
+		}
+	}
+}
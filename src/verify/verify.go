@@ -0,0 +1,205 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fuckEthereum/src/artifact"
+)
+
+// Status is the outcome of a verification
+type Status string
+
+const (
+	Match    Status = "match"    // executable code and metadata are identical
+	Partial  Status = "partial"  // executable code is identical, metadata differs (source comments, paths or settings)
+	Mismatch Status = "mismatch" // the address runs different code
+)
+
+// ErrMismatch is returned by Check when the code does not match
+var ErrMismatch = errors.New("deployed code does not match")
+
+// Range is a byte range in runtime code
+type Range struct {
+	Start  int
+	Length int
+}
+
+// Expected is the code a deployment should run
+type Expected struct {
+	Name       string
+	Runtime    []byte  // runtime code as compiled, immutables zero-filled
+	Immutables []Range // immutable slots from the compiler output, when known
+}
+
+// FromCreation derives the expected runtime code from creation code, such as CounterMetaData.Bin.
+// The runtime is the code after the init code's RETURN; constructor arguments must not be appended.
+func FromCreation(name string, creation []byte) (Expected, error) {
+	for pc := 0; pc < len(creation); {
+		op := vm.OpCode(creation[pc])
+		if op == vm.RETURN && pc+1 < len(creation) && vm.OpCode(creation[pc+1]) == vm.INVALID {
+			runtime := creation[pc+2:]
+			if len(runtime) == 0 {
+				break
+			}
+			return Expected{Name: name, Runtime: runtime}, nil
+		}
+		pc++
+		if op.IsPush() {
+			pc += int(op - vm.PUSH0)
+		}
+	}
+	return Expected{}, fmt.Errorf("cannot locate the runtime code of %s in its creation code", name)
+}
+
+// FromArtifact returns the expected runtime of a Foundry or Hardhat artifact, linked against libs
+func FromArtifact(art *artifact.Artifact, libs map[string]common.Address) (Expected, error) {
+	if art.DeployedBytecode == "" {
+		creation, err := art.Link(libs)
+		if err != nil {
+			return Expected{}, err
+		}
+		return FromCreation(art.Name, creation)
+	}
+
+	runtime, err := art.LinkDeployed(libs)
+	if err != nil {
+		return Expected{}, err
+	}
+	expected := Expected{Name: art.Name, Runtime: runtime}
+	for _, refs := range art.ImmutableReferences {
+		for _, ref := range refs {
+			expected.Immutables = append(expected.Immutables, Range{Start: ref.Start, Length: ref.Length})
+		}
+	}
+	return expected, nil
+}
+
+// Result is the verification report for one address
+type Result struct {
+	Status       Status
+	Name         string
+	Address      common.Address
+	CodeSize     int
+	Reason       string
+	FirstDiff    int     // offset of the first differing byte on mismatch, -1 otherwise
+	Masked       []Range // immutable or library-address slots whose deployed value differs from the compiled placeholder
+	Metadata     *Metadata
+	WantMetadata *Metadata
+}
+
+// CodeReader is implemented by ethclient.Client
+type CodeReader interface {
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// Verify fetches the code at address and compares it with the expected runtime
+func Verify(ctx context.Context, reader CodeReader, address common.Address, expected Expected) (*Result, error) {
+	code, err := reader.CodeAt(ctx, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code at %s: %v", address.Hex(), err)
+	}
+	result := Compare(expected, code)
+	result.Address = address
+	return result, nil
+}
+
+// Compare checks deployed runtime code against the expected runtime
+func Compare(expected Expected, code []byte) *Result {
+	result := &Result{Name: expected.Name, CodeSize: len(code), FirstDiff: -1}
+	if len(code) == 0 {
+		result.Status = Mismatch
+		result.Reason = "no code at address"
+		return result
+	}
+
+	wantBody, wantMeta, _ := SplitMetadata(expected.Runtime)
+	body, meta, _ := SplitMetadata(code)
+	result.Metadata, result.WantMetadata = meta, wantMeta
+
+	if len(body) != len(wantBody) {
+		result.Status = Mismatch
+		result.Reason = fmt.Sprintf("code size %d differs from expected %d (without metadata)", len(body), len(wantBody))
+		return result
+	}
+
+	slots := placeholders(wantBody, expected.Immutables)
+	for i := 0; i < len(body); i++ {
+		if body[i] == wantBody[i] {
+			continue
+		}
+		slot, ok := slotAt(slots, i)
+		if !ok {
+			result.Status = Mismatch
+			result.FirstDiff = i
+			result.Reason = fmt.Sprintf("code differs at byte %d", i)
+			return result
+		}
+		result.Masked = append(result.Masked, slot)
+		i = slot.Start + slot.Length - 1
+	}
+
+	switch {
+	case meta == nil && wantMeta == nil, meta != nil && wantMeta != nil && bytes.Equal(meta.Raw, wantMeta.Raw):
+		result.Status = Match
+	default:
+		result.Status = Partial
+		result.Reason = "executable code matches but the metadata hash differs (source, file paths or compiler settings changed)"
+	}
+	return result
+}
+
+// placeholders lists where deployed code may legitimately differ: the compiler's immutable
+// references plus zero-filled PUSH20/PUSH32 operands, which is how solc leaves immutables
+// and a library's own address in runtime code
+func placeholders(code []byte, known []Range) []Range {
+	slots := append([]Range(nil), known...)
+	for pc := 0; pc < len(code); {
+		op := vm.OpCode(code[pc])
+		pc++
+		if !op.IsPush() {
+			continue
+		}
+		size := int(op - vm.PUSH0)
+		if (op == vm.PUSH20 || op == vm.PUSH32) && pc+size <= len(code) && isZero(code[pc:pc+size]) {
+			slots = append(slots, Range{Start: pc, Length: size})
+		}
+		pc += size
+	}
+	return slots
+}
+
+func slotAt(slots []Range, i int) (Range, bool) {
+	for _, slot := range slots {
+		if i >= slot.Start && i < slot.Start+slot.Length {
+			return slot, true
+		}
+	}
+	return Range{}, false
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Check is Verify that fails unless the result is at least a partial match
+func Check(ctx context.Context, reader CodeReader, address common.Address, expected Expected) (*Result, error) {
+	result, err := Verify(ctx, reader, address, expected)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == Mismatch {
+		return result, fmt.Errorf("%w %s at %s: %s", ErrMismatch, expected.Name, address.Hex(), result.Reason)
+	}
+	return result, nil
+}
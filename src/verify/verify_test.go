@@ -0,0 +1,156 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fuckEthereum/contracts"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+func counterExpected(t *testing.T) Expected {
+	t.Helper()
+	expected, err := FromCreation("Counter", common.FromHex(contracts.CounterMetaData.Bin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expected
+}
+
+// edit returns a copy of code with f applied
+func edit(code []byte, f func(c []byte)) []byte {
+	c := bytes.Clone(code)
+	f(c)
+	return c
+}
+
+// operand returns the offset of the operand of the first PUSH32 whose operand starts with prefix
+func operand(t *testing.T, code []byte, prefix string) int {
+	t.Helper()
+	i := bytes.Index(code, append([]byte{0x7f}, common.FromHex(prefix)...))
+	if i < 0 {
+		t.Fatalf("PUSH32 %s... not found", prefix)
+	}
+	return i + 1
+}
+
+func TestCompareCounter(t *testing.T) {
+	expected := counterExpected(t)
+	runtime := expected.Runtime
+	body, _, ok := SplitMetadata(runtime)
+	if !ok {
+		t.Fatal("Counter runtime has no metadata")
+	}
+	// CountIncremented topic, and the Error(string) selector left-aligned in a PUSH32
+	topic := operand(t, runtime, "36bd77ef")
+	selector := operand(t, runtime, "08c379a0")
+
+	tests := []struct {
+		name      string
+		code      []byte
+		status    Status
+		firstDiff int
+	}{
+		{"identical", runtime, Match, -1},
+		{"metadata hash tweaked", edit(runtime, func(c []byte) { c[len(body)+10] ^= 0xff }), Partial, -1},
+		{"metadata stripped", body, Partial, -1},
+		{"opcode flipped", edit(runtime, func(c []byte) { c[0] = 0x61 }), Mismatch, 0},
+		{"event topic changed", edit(runtime, func(c []byte) { c[topic+5] ^= 0x01 }), Mismatch, topic + 5},
+		{"zero tail of a non-zero PUSH32 changed", edit(runtime, func(c []byte) { c[selector+31] = 0x01 }), Mismatch, selector + 31},
+		{"code appended", append(bytes.Clone(body), 0x00), Mismatch, -1},
+		{"no code", nil, Mismatch, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Compare(expected, tt.code)
+			if result.Status != tt.status {
+				t.Fatalf("status %s (%s), want %s", result.Status, result.Reason, tt.status)
+			}
+			if result.FirstDiff != tt.firstDiff {
+				t.Errorf("first difference at %d, want %d", result.FirstDiff, tt.firstDiff)
+			}
+			if len(result.Masked) != 0 {
+				t.Errorf("masked %v, want none", result.Masked)
+			}
+		})
+	}
+}
+
+func TestComparePlaceholders(t *testing.T) {
+	address := common.HexToAddress("0x00000000000000000000000000000000deadbeef").Bytes()
+	// PUSH20 <zero address> POP  PUSH2 0x0000 POP  PUSH32 <zero word> POP
+	compiled := append(append([]byte{0x73}, make([]byte, 20)...), 0x50, 0x61, 0x00, 0x00, 0x50, 0x7f)
+	compiled = append(append(compiled, make([]byte, 32)...), 0x50)
+	word := bytes.Repeat([]byte{0x11}, 32)
+
+	deployed := edit(compiled, func(c []byte) {
+		copy(c[1:], address)
+		copy(c[23:], []byte{0xab, 0xcd})
+		copy(c[27:], word)
+	})
+
+	tests := []struct {
+		name       string
+		immutables []Range
+		code       []byte
+		status     Status
+		masked     []Range
+	}{
+		{"zero-filled slots", nil, compiled, Match, nil},
+		{"library address and immutables filled", []Range{{Start: 23, Length: 2}}, deployed, Match,
+			[]Range{{Start: 1, Length: 20}, {Start: 23, Length: 2}, {Start: 27, Length: 32}}},
+		{"PUSH2 without an immutable reference", nil, deployed, Mismatch, []Range{{Start: 1, Length: 20}}},
+		{"opcode next to a slot", nil, edit(deployed, func(c []byte) { c[21] = 0x00 }), Mismatch, []Range{{Start: 1, Length: 20}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Compare(Expected{Name: "Library", Runtime: compiled, Immutables: tt.immutables}, tt.code)
+			if result.Status != tt.status {
+				t.Fatalf("status %s (%s), want %s", result.Status, result.Reason, tt.status)
+			}
+			if len(result.Masked) != len(tt.masked) {
+				t.Fatalf("masked %v, want %v", result.Masked, tt.masked)
+			}
+			for i := range tt.masked {
+				if result.Masked[i] != tt.masked[i] {
+					t.Fatalf("masked %v, want %v", result.Masked, tt.masked)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDeployedCounter(t *testing.T) {
+	chain, err := simchain.New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	auth, err := bind.NewKeyedTransactorWithChainID(chain.Key(0), simchain.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, _, err := contracts.DeployCounter(auth, chain.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	result, err := Check(ctx, chain.Client(), address, counterExpected(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != Match || result.Address != address || result.Metadata == nil || result.Metadata.Solc != "0.8.30" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	// An account without code
+	if _, err := Check(ctx, chain.Client(), chain.Address(0), counterExpected(t)); !errors.Is(err, ErrMismatch) {
+		t.Errorf("externally owned account: got %v, want ErrMismatch", err)
+	}
+}
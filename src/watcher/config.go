@@ -0,0 +1,137 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fuckEthereum/src/units"
+)
+
+// DefaultFile is where the watcher configuration is loaded from unless WATCH_FILE is set
+const DefaultFile = "watch.json"
+
+// TargetConfig describes one watched address. Amounts use the units syntax, e.g. "0.05 ether".
+type TargetConfig struct {
+	Address    string `json:"address"`
+	Label      string `json:"label,omitempty"`
+	MinBalance string `json:"minBalance,omitempty"` // alert when the balance drops below this
+	MaxChange  string `json:"maxChange,omitempty"`  // alert when the balance moves more than this between checks
+}
+
+// SinkConfig selects an alert destination: "stdout", "webhook" (url) or "file" (path)
+type SinkConfig struct {
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// Config is the watcher configuration file
+type Config struct {
+	EveryBlocks  uint64         `json:"everyBlocks,omitempty"`  // check every N blocks (default 1)
+	PollInterval string         `json:"pollInterval,omitempty"` // head polling interval without subscriptions (default 12s)
+	Targets      []TargetConfig `json:"targets"`
+	Sinks        []SinkConfig   `json:"sinks,omitempty"` // default stdout
+}
+
+// Target is a compiled TargetConfig
+type Target struct {
+	Address    common.Address
+	Label      string
+	MinBalance *units.Amount
+	MaxChange  *units.Amount
+}
+
+// Name returns the label or the address
+func (t *Target) Name() string {
+	if t.Label != "" {
+		return t.Label
+	}
+	return t.Address.Hex()
+}
+
+// Load reads a watcher configuration file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watcher config: %v", err)
+	}
+	return Parse(data)
+}
+
+// LoadFromEnv loads the file named by WATCH_FILE or DefaultFile
+func LoadFromEnv() (*Config, error) {
+	path := os.Getenv("WATCH_FILE")
+	if path == "" {
+		path = DefaultFile
+	}
+	return Load(path)
+}
+
+// Parse decodes and validates a watcher configuration
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse watcher config: %v", err)
+	}
+	if _, err := c.Compile(); err != nil {
+		return nil, err
+	}
+	if _, err := c.Interval(); err != nil {
+		return nil, err
+	}
+	if _, err := BuildSinks(c.Sinks); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Interval returns the polling interval
+func (c *Config) Interval() (time.Duration, error) {
+	if c.PollInterval == "" {
+		return 12 * time.Second, nil
+	}
+	interval, err := time.ParseDuration(c.PollInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pollInterval: %v", err)
+	}
+	return interval, nil
+}
+
+// Compile parses the targets
+func (c *Config) Compile() ([]*Target, error) {
+	if len(c.Targets) == 0 {
+		return nil, fmt.Errorf("watcher config has no targets")
+	}
+
+	targets := make([]*Target, 0, len(c.Targets))
+	for i, tc := range c.Targets {
+		if !common.IsHexAddress(tc.Address) {
+			return nil, fmt.Errorf("target %d: invalid address %q", i, tc.Address)
+		}
+		target := &Target{Address: common.HexToAddress(tc.Address), Label: tc.Label}
+
+		amounts := []struct {
+			name  string
+			value string
+			dst   **units.Amount
+		}{
+			{"minBalance", tc.MinBalance, &target.MinBalance},
+			{"maxChange", tc.MaxChange, &target.MaxChange},
+		}
+		for _, a := range amounts {
+			if a.value == "" {
+				continue
+			}
+			amount, err := units.Parse(a.value)
+			if err != nil {
+				return nil, fmt.Errorf("target %d %s: %v", i, a.name, err)
+			}
+			*a.dst = &amount
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
@@ -0,0 +1,132 @@
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Alert kinds
+const (
+	AlertLowBalance = "low_balance"
+	AlertChange     = "unexpected_change"
+)
+
+// Alert is delivered to every sink
+type Alert struct {
+	Kind      string    `json:"kind"`
+	Time      time.Time `json:"time"`
+	Block     uint64    `json:"block"`
+	Address   string    `json:"address"`
+	Label     string    `json:"label,omitempty"`
+	Balance   string    `json:"balance"`            // wei
+	Previous  string    `json:"previous,omitempty"` // wei, unexpected_change only
+	Threshold string    `json:"threshold"`          // wei
+	Message   string    `json:"message"`
+}
+
+// Sink delivers alerts somewhere
+type Sink interface {
+	Send(ctx context.Context, alert Alert) error
+}
+
+// StdoutSink prints alerts
+type StdoutSink struct{}
+
+// Send prints the alert message
+func (StdoutSink) Send(ctx context.Context, alert Alert) error {
+	fmt.Printf("🚨 [%s] #%d %s\n", alert.Kind, alert.Block, alert.Message)
+	return nil
+}
+
+// WebhookSink POSTs alerts as JSON
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// Send posts the alert; any non-2xx response is an error
+func (s *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// FileSink appends alerts to a JSONL file
+type FileSink struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send appends the alert as one JSON line
+func (s *FileSink) Send(ctx context.Context, alert Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create alert directory: %v", err)
+	}
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open alert file: %v", err)
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(alert)
+}
+
+// BuildSinks creates sinks from configuration; no configuration means stdout only
+func BuildSinks(configs []SinkConfig) ([]Sink, error) {
+	if len(configs) == 0 {
+		return []Sink{StdoutSink{}}, nil
+	}
+
+	sinks := make([]Sink, 0, len(configs))
+	for i, c := range configs {
+		switch c.Type {
+		case "stdout":
+			sinks = append(sinks, StdoutSink{})
+		case "webhook":
+			if c.URL == "" {
+				return nil, fmt.Errorf("sink %d: webhook requires url", i)
+			}
+			sinks = append(sinks, &WebhookSink{URL: c.URL})
+		case "file":
+			if c.Path == "" {
+				return nil, fmt.Errorf("sink %d: file requires path", i)
+			}
+			sinks = append(sinks, &FileSink{Path: c.Path})
+		default:
+			return nil, fmt.Errorf("sink %d: unknown type %q", i, c.Type)
+		}
+	}
+	return sinks, nil
+}
@@ -0,0 +1,137 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fuckEthereum/src/units"
+)
+
+// Backend is everything the watcher needs from a node
+type Backend interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// targetState is what the watcher remembers about a target between checks
+type targetState struct {
+	balance *big.Int
+	low     bool // a low-balance alert fired and the balance has not recovered yet
+}
+
+// Watcher checks balances every N blocks and sends alerts to sinks.
+// Feed it heads with OnHead, e.g. from a follower.Follower.
+type Watcher struct {
+	backend Backend
+	targets []*Target
+	sinks   []Sink
+	every   uint64
+	state   map[common.Address]*targetState
+	last    uint64
+	checked bool
+}
+
+// New creates a watcher from a configuration
+func New(backend Backend, config *Config) (*Watcher, error) {
+	targets, err := config.Compile()
+	if err != nil {
+		return nil, err
+	}
+	sinks, err := BuildSinks(config.Sinks)
+	if err != nil {
+		return nil, err
+	}
+
+	every := config.EveryBlocks
+	if every == 0 {
+		every = 1
+	}
+
+	return &Watcher{
+		backend: backend,
+		targets: targets,
+		sinks:   sinks,
+		every:   every,
+		state:   make(map[common.Address]*targetState),
+	}, nil
+}
+
+// OnHead checks balances if at least N blocks passed since the last check
+func (w *Watcher) OnHead(ctx context.Context, number uint64) error {
+	if w.checked && number < w.last+w.every {
+		return nil
+	}
+	if err := w.Check(ctx, number); err != nil {
+		return err
+	}
+	w.last = number
+	w.checked = true
+	return nil
+}
+
+// Check reads every balance at a block and fires alerts
+func (w *Watcher) Check(ctx context.Context, number uint64) error {
+	block := new(big.Int).SetUint64(number)
+	for _, target := range w.targets {
+		balance, err := w.backend.BalanceAt(ctx, target.Address, block)
+		if err != nil {
+			return fmt.Errorf("failed to get balance of %s: %v", target.Name(), err)
+		}
+
+		state, seen := w.state[target.Address]
+		if !seen {
+			state = &targetState{}
+			w.state[target.Address] = state
+			fmt.Printf("👀 #%d %s: %s\n", number, target.Name(), units.FormatWei(balance, units.Ether, 6))
+		}
+
+		if target.MinBalance != nil {
+			below := balance.Cmp(target.MinBalance.Wei()) < 0
+			if below && !state.low {
+				w.alert(ctx, Alert{
+					Kind:      AlertLowBalance,
+					Block:     number,
+					Address:   target.Address.Hex(),
+					Label:     target.Label,
+					Balance:   balance.String(),
+					Threshold: target.MinBalance.Wei().String(),
+					Message: fmt.Sprintf("%s balance %s is below %s", target.Name(),
+						units.FormatWei(balance, units.Ether, 6), target.MinBalance.Format(units.Ether, -1)),
+				})
+			}
+			state.low = below
+		}
+
+		if target.MaxChange != nil && state.balance != nil {
+			delta := new(big.Int).Sub(balance, state.balance)
+			if new(big.Int).Abs(delta).Cmp(target.MaxChange.Wei()) > 0 {
+				w.alert(ctx, Alert{
+					Kind:      AlertChange,
+					Block:     number,
+					Address:   target.Address.Hex(),
+					Label:     target.Label,
+					Balance:   balance.String(),
+					Previous:  state.balance.String(),
+					Threshold: target.MaxChange.Wei().String(),
+					Message: fmt.Sprintf("%s balance changed by %s (%s → %s)", target.Name(),
+						units.FormatWei(delta, units.Ether, 6),
+						units.FormatWei(state.balance, units.Ether, 6), units.FormatWei(balance, units.Ether, 6)),
+				})
+			}
+		}
+		state.balance = balance
+	}
+	return nil
+}
+
+// alert delivers to every sink; a failing sink does not stop the others
+func (w *Watcher) alert(ctx context.Context, alert Alert) {
+	alert.Time = time.Now().UTC()
+	for _, sink := range w.sinks {
+		if err := sink.Send(ctx, alert); err != nil {
+			fmt.Printf("⚠️  Failed to deliver %s alert: %v\n", alert.Kind, err)
+		}
+	}
+}
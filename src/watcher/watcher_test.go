@@ -0,0 +1,269 @@
+package watcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/fuckEthereum/src/simchain"
+)
+
+// webhook collects the alerts posted to it
+type webhook struct {
+	mu     sync.Mutex
+	alerts []Alert
+	status int
+}
+
+func (h *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var alert Alert
+	if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&alert) != nil {
+		http.Error(w, "bad alert", http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.alerts = append(h.alerts, alert)
+	if h.status != 0 {
+		w.WriteHeader(h.status)
+	}
+}
+
+func (h *webhook) kinds() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	kinds := make([]string, len(h.alerts))
+	for i, alert := range h.alerts {
+		kinds[i] = alert.Kind
+	}
+	return kinds
+}
+
+func readAlerts(t *testing.T, path string) []Alert {
+	t.Helper()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var alerts []Alert
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var alert Alert
+		if err := json.Unmarshal(scanner.Bytes(), &alert); err != nil {
+			t.Fatal(err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// send moves value wei from account from to account to and returns the new block number
+func send(t *testing.T, chain *simchain.Chain, from, to int, value *big.Int) uint64 {
+	t.Helper()
+	ctx := context.Background()
+	client := chain.Client()
+
+	nonce, err := client.PendingNonceAt(ctx, chain.Address(from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := chain.Address(to)
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       &recipient,
+		Value:    value,
+		Gas:      params.TxGas,
+		GasPrice: gasPrice,
+	}), types.LatestSignerForChainID(simchain.ChainID), chain.Key(from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	number, err := client.BlockNumber(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return number
+}
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.Ether))
+}
+
+func TestCheckAlerts(t *testing.T) {
+	chain, err := simchain.New(2, ether(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	hook := &webhook{}
+	server := httptest.NewServer(hook)
+	defer server.Close()
+	alertFile := filepath.Join(t.TempDir(), "alerts", "alerts.jsonl")
+
+	config, err := Parse([]byte(`{
+		"targets": [
+			{"address": "` + chain.Address(0).Hex() + `", "label": "hot wallet", "minBalance": "4 ether", "maxChange": "3 ether"}
+		],
+		"sinks": [
+			{"type": "webhook", "url": "` + server.URL + `"},
+			{"type": "file", "path": "` + alertFile + `"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := New(chain.Client(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	steps := []struct {
+		name  string
+		value int64 // ether sent away before the check, 0 for none
+		want  []string
+	}{
+		{"first check only records the balance", 0, nil},
+		{"small change", 1, nil},
+		{"large change", 4, []string{AlertChange}},
+		{"drops below the minimum", 1, []string{AlertLowBalance}},
+		{"stays low without repeating", 1, nil},
+	}
+	var seen []string
+	for _, step := range steps {
+		number, err := chain.Client().BlockNumber(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.value > 0 {
+			number = send(t, chain, 0, 1, ether(step.value))
+		}
+		if err := watcher.Check(ctx, number); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		seen = append(seen, step.want...)
+		if got := hook.kinds(); !slices.Equal(got, seen) {
+			t.Fatalf("%s: webhook alerts %v, want %v", step.name, got, seen)
+		}
+	}
+
+	// Refill above the minimum, then drop again: the low-balance alert fires once more
+	number := send(t, chain, 1, 0, ether(2))
+	if err := watcher.Check(ctx, number); err != nil {
+		t.Fatal(err)
+	}
+	number = send(t, chain, 0, 1, ether(2))
+	if err := watcher.Check(ctx, number); err != nil {
+		t.Fatal(err)
+	}
+	seen = append(seen, AlertLowBalance)
+	if got := hook.kinds(); !slices.Equal(got, seen) {
+		t.Fatalf("webhook alerts %v, want %v", got, seen)
+	}
+
+	alerts := readAlerts(t, alertFile)
+	if len(alerts) != len(seen) {
+		t.Fatalf("file has %d alerts, want %d", len(alerts), len(seen))
+	}
+	change := alerts[0]
+	if change.Label != "hot wallet" || change.Address != chain.Address(0).Hex() ||
+		change.Threshold != ether(3).String() || change.Previous == "" || change.Time.IsZero() {
+		t.Errorf("unexpected change alert %+v", change)
+	}
+	low := alerts[1]
+	if low.Kind != AlertLowBalance || low.Threshold != ether(4).String() || low.Previous != "" {
+		t.Errorf("unexpected low-balance alert %+v", low)
+	}
+}
+
+func TestOnHeadEveryBlocks(t *testing.T) {
+	chain, err := simchain.New(2, ether(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	alertFile := filepath.Join(t.TempDir(), "alerts.jsonl")
+	watcher, err := New(chain.Client(), &Config{
+		EveryBlocks: 3,
+		Targets:     []TargetConfig{{Address: chain.Address(0).Hex(), MinBalance: "9.5 ether"}},
+		Sinks:       []SinkConfig{{Type: "file", Path: alertFile}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := watcher.OnHead(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	number := send(t, chain, 0, 1, ether(1))
+	if err := watcher.OnHead(ctx, number); err != nil {
+		t.Fatal(err)
+	}
+	if alerts := readAlerts(t, alertFile); len(alerts) != 0 {
+		t.Fatalf("checked before 3 blocks passed: %+v", alerts)
+	}
+
+	chain.Backend().Commit()
+	chain.Backend().Commit()
+	if err := watcher.OnHead(ctx, number+2); err != nil {
+		t.Fatal(err)
+	}
+	if alerts := readAlerts(t, alertFile); len(alerts) != 1 || alerts[0].Kind != AlertLowBalance {
+		t.Fatalf("alerts %+v, want one low_balance", alerts)
+	}
+}
+
+func TestWebhookSinkRejectsErrorStatus(t *testing.T) {
+	hook := &webhook{status: http.StatusInternalServerError}
+	server := httptest.NewServer(hook)
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL}
+	if err := sink.Send(context.Background(), Alert{Kind: AlertLowBalance}); err == nil {
+		t.Fatal("expected an error for a 500 response")
+	}
+	if len(hook.kinds()) != 1 {
+		t.Fatal("webhook was not called")
+	}
+}
+
+func TestParseRejectsInvalidConfig(t *testing.T) {
+	address := `"0x0000000000000000000000000000000000000001"`
+	for _, doc := range []string{
+		`{"targets": []}`,
+		`{"targets": [{"address": "nope"}]}`,
+		`{"targets": [{"address": ` + address + `, "minBalance": "1"}]}`,
+		`{"targets": [{"address": ` + address + `}], "pollInterval": "soon"}`,
+		`{"targets": [{"address": ` + address + `}], "sinks": [{"type": "webhook"}]}`,
+		`{"targets": [{"address": ` + address + `}], "sinks": [{"type": "pager"}]}`,
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want error", doc)
+		}
+	}
+}